@@ -0,0 +1,158 @@
+package miscio
+
+import (
+	"io"
+	"time"
+)
+
+// ioResult is the outcome of a Read or Write running in the background.
+type ioResult struct {
+	n   int
+	err error
+}
+
+// asyncReader runs each Read of an io.Reader in a goroutine, so that waiting
+// for it can be abandoned. An abandoned Read keeps running into a buffer owned
+// by the asyncReader, and the next read waits for it and returns its data
+// instead of starting another. It is not safe for concurrent use.
+type asyncReader struct {
+	r        io.Reader
+	buf      []byte
+	pending  chan ioResult
+	leftover []byte
+}
+
+// read reads into p like io.Reader, unless cancel is closed or timeout fires
+// first, in which case it reports ok == false.
+func (ar *asyncReader) read(p []byte, cancel <-chan struct{}, timeout <-chan time.Time) (res ioResult, ok bool) {
+	if len(ar.leftover) > 0 {
+		n := copy(p, ar.leftover)
+		ar.leftover = ar.leftover[n:]
+
+		return ioResult{n: n}, true
+	}
+
+	if ar.pending == nil {
+		if cap(ar.buf) < len(p) {
+			ar.buf = make([]byte, len(p))
+		}
+
+		buf := ar.buf[:len(p)]
+		pending := make(chan ioResult, 1)
+		ar.pending = pending
+
+		go func() {
+			n, err := ar.r.Read(buf)
+			pending <- ioResult{n, err}
+		}()
+	}
+
+	select {
+	case res = <-ar.pending:
+		ar.pending = nil
+
+		// An abandoned Read may have been for a larger p than this one; keep
+		// whatever does not fit for the next read.
+		n := copy(p, ar.buf[:res.n])
+		ar.leftover = ar.buf[n:res.n]
+		res.n = n
+
+		return res, true
+	case <-cancel:
+		return ioResult{}, false
+	case <-timeout:
+		return ioResult{}, false
+	}
+}
+
+// asyncWriter runs each Write to an io.Writer in a goroutine, from a copy of
+// p, so that waiting for it can be abandoned. An abandoned Write may still
+// complete; the next write waits for it first, and fails with its error, if
+// any, without writing anything further. It is not safe for concurrent use.
+type asyncWriter struct {
+	w       io.Writer
+	buf     []byte
+	pending chan ioResult
+}
+
+// write writes p like io.Writer, unless cancel is closed or timeout fires
+// first, in which case it reports ok == false. written reports whether any
+// bytes were written, including by an earlier abandoned Write.
+func (aw *asyncWriter) write(p []byte, cancel <-chan struct{},
+	timeout <-chan time.Time) (res ioResult, written, ok bool) {
+	if aw.pending != nil {
+		select {
+		case prev := <-aw.pending:
+			aw.pending = nil
+			written = prev.n > 0
+
+			if prev.err != nil {
+				return ioResult{err: prev.err}, written, true
+			}
+		case <-cancel:
+			return ioResult{}, false, false
+		case <-timeout:
+			return ioResult{}, false, false
+		}
+	}
+
+	aw.buf = append(aw.buf[:0], p...)
+	buf := aw.buf
+	pending := make(chan ioResult, 1)
+	aw.pending = pending
+
+	go func() {
+		n, err := aw.w.Write(buf)
+		pending <- ioResult{n, err}
+	}()
+
+	select {
+	case res = <-aw.pending:
+		aw.pending = nil
+
+		return res, written || res.n > 0, true
+	case <-cancel:
+		return ioResult{}, written, false
+	case <-timeout:
+		return ioResult{}, written, false
+	}
+}
+
+// flush waits for any earlier abandoned Write, like write, then flushes the
+// underlying io.Writer with flushNext, in a goroutine so that waiting for it
+// can be abandoned too. An abandoned flush is waited for by the next write or
+// flush.
+func (aw *asyncWriter) flush(cancel <-chan struct{}, timeout <-chan time.Time) (res ioResult, ok bool) {
+	if aw.pending != nil {
+		select {
+		case prev := <-aw.pending:
+			aw.pending = nil
+
+			if prev.err != nil {
+				return ioResult{err: prev.err}, true
+			}
+		case <-cancel:
+			return ioResult{}, false
+		case <-timeout:
+			return ioResult{}, false
+		}
+	}
+
+	pending := make(chan ioResult, 1)
+	aw.pending = pending
+
+	go func() {
+		pending <- ioResult{err: flushNext(aw.w)}
+	}()
+
+	select {
+	case res = <-aw.pending:
+		aw.pending = nil
+
+		return res, true
+	case <-cancel:
+		return ioResult{}, false
+	case <-timeout:
+		return ioResult{}, false
+	}
+}
@@ -0,0 +1,218 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SyncMode controls how much of an AtomicFileWriter's work is flushed to stable
+// storage before Close returns.
+type SyncMode int
+
+const (
+	// SyncFile fsyncs the temporary file before renaming it into place, so a
+	// crash can never leave a partially written file at the destination. This
+	// is the default.
+	SyncFile SyncMode = iota
+	// SyncNone skips fsync entirely. After a crash the destination may hold an
+	// empty or partial file, so this is only suitable for data that can be
+	// regenerated.
+	SyncNone
+	// SyncFileAndDir fsyncs the file, and also the directory after the rename,
+	// so the rename itself survives a crash once Close returns.
+	SyncFileAndDir
+)
+
+// DefaultAtomicFileMode is the permission bits of a file written by an
+// AtomicFileWriter, unless changed with WithFileMode.
+const DefaultAtomicFileMode os.FileMode = 0o644
+
+// AtomicFileOption configures an AtomicFileWriter.
+type AtomicFileOption func(aw *AtomicFileWriter)
+
+// WithSyncMode sets how much an AtomicFileWriter fsyncs. See SyncMode.
+func WithSyncMode(mode SyncMode) AtomicFileOption {
+	return func(aw *AtomicFileWriter) {
+		aw.syncMode = mode
+	}
+}
+
+// WithFileMode sets the permission bits of the file an AtomicFileWriter
+// creates. They are set with chmod, so the process's umask does not apply.
+func WithFileMode(mode os.FileMode) AtomicFileOption {
+	return func(aw *AtomicFileWriter) {
+		aw.mode = mode
+	}
+}
+
+// AtomicFileWriter is an io.WriteCloser that replaces a file atomically: data
+// is written to a temporary file in the destination's directory, which Close
+// renames over the destination. Readers of the destination see either the old
+// contents or the complete new contents, never anything in between. Abort or
+// CloseWithError discards the temporary file, leaving the destination as it
+// was.
+//
+// The usual pattern is to defer Abort, which does nothing once Close has been
+// called:
+//
+//	aw, err := miscio.NewAtomicFileWriter(path)
+//	if err != nil {
+//		return err
+//	}
+//	defer aw.Abort()
+//	if err := write(aw); err != nil {
+//		return err
+//	}
+//	return aw.Close()
+//
+// All methods are safe for concurrent use.
+type AtomicFileWriter struct {
+	m        sync.Mutex
+	path     string
+	f        *os.File
+	syncMode SyncMode
+	mode     os.FileMode
+	err      error // Set once closed or aborted; returned by later Writes.
+	leak     *leakTracker
+}
+
+var _ io.WriteCloser = (*AtomicFileWriter)(nil)
+
+// NewAtomicFileWriter returns an AtomicFileWriter that will replace the file at
+// path. It creates the temporary file right away, returning any error from
+// doing so.
+func NewAtomicFileWriter(path string, opts ...AtomicFileOption) (*AtomicFileWriter, error) {
+	aw := &AtomicFileWriter{
+		path:     path,
+		syncMode: SyncFile,
+		mode:     DefaultAtomicFileMode,
+	}
+
+	for _, opt := range opts {
+		opt(aw)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+
+	aw.f = f
+	aw.leak = trackLeak("*miscio.AtomicFileWriter")
+
+	return aw, nil
+}
+
+// Write implements io.Writer for AtomicFileWriter, writing to the temporary
+// file. It returns ErrWriteAfterClose after Close or Abort, or the error passed
+// to CloseWithError.
+func (aw *AtomicFileWriter) Write(p []byte) (int, error) {
+	aw.m.Lock()
+	defer aw.m.Unlock()
+
+	if aw.err != nil {
+		return 0, aw.err
+	}
+
+	return aw.f.Write(p)
+}
+
+// Close renames the temporary file into place, after fsyncing according to the
+// SyncMode. If any step fails, the temporary file is removed, the destination
+// is left as it was, and the error is returned. Calls after the first, or after
+// Abort, do nothing and return nil.
+func (aw *AtomicFileWriter) Close() error {
+	aw.m.Lock()
+	defer aw.m.Unlock()
+
+	if aw.err != nil {
+		return nil
+	}
+
+	aw.err = ErrWriteAfterClose
+	aw.leak.markClosed()
+
+	if err := aw.commit(); err != nil {
+		aw.f.Close()
+		os.Remove(aw.f.Name())
+
+		return err
+	}
+
+	return nil
+}
+
+// commit does the work of Close. It must be called with aw.m held.
+func (aw *AtomicFileWriter) commit() error {
+	if aw.syncMode != SyncNone {
+		if err := aw.f.Sync(); err != nil {
+			return err
+		}
+	}
+
+	if err := aw.f.Chmod(aw.mode); err != nil {
+		return err
+	}
+
+	if err := aw.f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(aw.f.Name(), aw.path); err != nil {
+		return err
+	}
+
+	if aw.syncMode == SyncFileAndDir {
+		return syncDir(filepath.Dir(aw.path))
+	}
+
+	return nil
+}
+
+// syncDir fsyncs the directory at path.
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Sync(); err != nil {
+		d.Close()
+
+		return err
+	}
+
+	return d.Close()
+}
+
+// Abort discards the temporary file, leaving the destination as it was, and
+// returns any error from removing it. Calls after the first, or after Close,
+// do nothing and return nil.
+func (aw *AtomicFileWriter) Abort() error {
+	return aw.CloseWithError(nil)
+}
+
+// CloseWithError is like Abort, but later Writes return err instead of
+// ErrWriteAfterClose, if err is not nil.
+func (aw *AtomicFileWriter) CloseWithError(err error) error {
+	aw.m.Lock()
+	defer aw.m.Unlock()
+
+	if aw.err != nil {
+		return nil
+	}
+
+	if err == nil {
+		err = ErrWriteAfterClose
+	}
+
+	aw.err = err
+	aw.leak.markClosed()
+
+	aw.f.Close()
+
+	return os.Remove(aw.f.Name())
+}
@@ -0,0 +1,107 @@
+package miscio
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dirEntries returns the names of the files in dir.
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+
+	return names
+}
+
+func TestAtomicFileWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	if err := ioutil.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	aw, err := NewAtomicFileWriter(path, WithSyncMode(SyncFileAndDir), WithFileMode(0o640))
+	if err != nil {
+		t.Fatalf("NewAtomicFileWriter failed: %v", err)
+	}
+	defer aw.Abort()
+
+	fmt.Fprint(aw, "new contents")
+
+	if data, _ := ioutil.ReadFile(path); string(data) != "old" {
+		t.Errorf("expected the destination to be untouched before Close, got %q", data)
+	}
+
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if data, _ := ioutil.ReadFile(path); string(data) != "new contents" {
+		t.Errorf("expected the new contents after Close, got %q", data)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("expected mode 0640, got %v (%v)", info.Mode(), err)
+	}
+
+	if _, err := aw.Write([]byte("more")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected a closed error from Write after Close, got %v", err)
+	}
+
+	if err := aw.Abort(); err != nil {
+		t.Errorf("expected Abort after Close to do nothing, got %v", err)
+	}
+
+	if names := dirEntries(t, dir); len(names) != 1 {
+		t.Errorf("expected only the destination to remain, got %q", names)
+	}
+}
+
+func TestAtomicFileWriterAbort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	errBoom := errors.New("boom")
+
+	aw, err := NewAtomicFileWriter(path, WithSyncMode(SyncNone))
+	if err != nil {
+		t.Fatalf("NewAtomicFileWriter failed: %v", err)
+	}
+
+	fmt.Fprint(aw, "partial")
+
+	if err := aw.CloseWithError(errBoom); err != nil {
+		t.Fatalf("CloseWithError failed: %v", err)
+	}
+
+	if _, err := aw.Write([]byte("x")); err != errBoom {
+		t.Errorf("expected Write to return the close error, got %v", err)
+	}
+
+	if err := aw.Close(); err != nil {
+		t.Errorf("expected Close after abort to do nothing, got %v", err)
+	}
+
+	if names := dirEntries(t, dir); len(names) != 0 {
+		t.Errorf("expected nothing to be left behind, got %q", names)
+	}
+}
+
+func TestAtomicFileWriterMissingDir(t *testing.T) {
+	if _, err := NewAtomicFileWriter(filepath.Join(t.TempDir(), "nope", "file")); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
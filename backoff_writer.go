@@ -0,0 +1,175 @@
+package miscio
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// backoffPolicy decides whether and when a BackoffWriter or BackoffWriterAt
+// retries a failed write.
+type backoffPolicy struct {
+	ctx        context.Context
+	retryable  func(err error) bool
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	after      func(d time.Duration) <-chan time.Time
+}
+
+// BackoffOption configures a BackoffWriter or BackoffWriterAt at construction.
+type BackoffOption func(bp *backoffPolicy)
+
+// WithWriteRetries sets how many consecutive failed attempts, without any bytes
+// written in between, a BackoffWriter or BackoffWriterAt makes before giving up
+// on a write. The default is 5.
+func WithWriteRetries(n int) BackoffOption {
+	return func(bp *backoffPolicy) {
+		bp.maxRetries = n
+	}
+}
+
+// WithWriteBackoff sets the delay before the first retry of a write, which
+// doubles with each consecutive failure up to max. The defaults are 100ms and
+// 5s.
+func WithWriteBackoff(min, max time.Duration) BackoffOption {
+	return func(bp *backoffPolicy) {
+		bp.minBackoff, bp.maxBackoff = min, max
+	}
+}
+
+func newBackoffPolicy(ctx context.Context, retryable func(err error) bool, opts []BackoffOption) backoffPolicy {
+	if retryable == nil {
+		retryable = retryableError
+	}
+
+	bp := backoffPolicy{
+		ctx:        ctx,
+		retryable:  retryable,
+		maxRetries: defaultRetryReaderMaxRetries,
+		minBackoff: defaultRetryReaderMinBackoff,
+		maxBackoff: defaultRetryReaderMaxBackoff,
+		after:      time.After,
+	}
+
+	for _, opt := range opts {
+		opt(&bp)
+	}
+
+	return bp
+}
+
+// write calls op until all of p is written, each time with the part of p not
+// written yet. It returns how many bytes of p were written, and the error that
+// made it give up, if any.
+func (bp *backoffPolicy) write(p []byte, op func(p []byte, written int) (int, error)) (int, error) {
+	written, failures := 0, 0
+
+	for {
+		if err := bp.ctx.Err(); err != nil {
+			return written, err
+		}
+
+		n, err := op(p[written:], written)
+		written += n
+
+		if n > 0 {
+			failures = 0
+		}
+
+		switch {
+		case written == len(p):
+			return written, nil
+		case err == nil && n > 0:
+			// A short write without an error: carry on from where it
+			// stopped.
+			continue
+		case err == nil:
+			err = io.ErrShortWrite
+		}
+
+		if !bp.retryable(err) || failures >= bp.maxRetries {
+			return written, err
+		}
+
+		delay := bp.minBackoff << uint(failures)
+		if delay > bp.maxBackoff || delay <= 0 {
+			delay = bp.maxBackoff
+		}
+
+		failures++
+
+		select {
+		case <-bp.after(delay):
+		case <-bp.ctx.Done():
+			return written, bp.ctx.Err()
+		}
+	}
+}
+
+// BackoffWriter wraps an io.Writer, such as a connection to a flaky network
+// sink, retrying writes that fail with a transient error. When a write fails,
+// or is short, the next attempt resumes with the bytes not yet written, after
+// an exponential backoff. It is safe for concurrent use if the underlying
+// writer is, though concurrent retried writes may of course interleave.
+type BackoffWriter struct {
+	w  io.Writer
+	bp backoffPolicy
+}
+
+var (
+	_ io.Writer = (*BackoffWriter)(nil)
+	_ Flusher   = (*BackoffWriter)(nil)
+)
+
+// NewBackoffWriter returns a BackoffWriter writing to w, retrying errors for
+// which retryable returns true until ctx is done. If retryable is nil, every
+// error is retried except context cancellation and writing to something closed
+// (os.ErrClosed, io.ErrClosedPipe, ErrWriteAfterClose), even when wrapped.
+func NewBackoffWriter(ctx context.Context, w io.Writer, retryable func(err error) bool,
+	opts ...BackoffOption) *BackoffWriter {
+	return &BackoffWriter{w: w, bp: newBackoffPolicy(ctx, retryable, opts)}
+}
+
+// Write implements io.Writer for BackoffWriter. It returns an error only once
+// it gives up: when an error is not retryable, the retries are exhausted, or
+// ctx is done, in which case it returns ctx.Err().
+func (bw *BackoffWriter) Write(p []byte) (int, error) {
+	return bw.bp.write(p, func(p []byte, _ int) (int, error) {
+		return bw.w.Write(p)
+	})
+}
+
+// Flush implements Flusher for BackoffWriter. It flushes the underlying writer,
+// without retrying.
+func (bw *BackoffWriter) Flush() error {
+	return flushNext(bw.w)
+}
+
+// BackoffWriterAt is the io.WriterAt counterpart of BackoffWriter, for feeding
+// a flaky sink from ParallelCopy or a WriterAtReadCloser pipeline. It is safe
+// for concurrent use if the underlying io.WriterAt is; each WriteAt retries
+// independently.
+type BackoffWriterAt struct {
+	w  io.WriterAt
+	bp backoffPolicy
+}
+
+var _ io.WriterAt = (*BackoffWriterAt)(nil)
+
+// NewBackoffWriterAt returns a BackoffWriterAt writing to w, retrying errors
+// for which retryable returns true until ctx is done. If retryable is nil, the
+// same errors are retried as by NewBackoffWriter.
+func NewBackoffWriterAt(ctx context.Context, w io.WriterAt, retryable func(err error) bool,
+	opts ...BackoffOption) *BackoffWriterAt {
+	return &BackoffWriterAt{w: w, bp: newBackoffPolicy(ctx, retryable, opts)}
+}
+
+// WriteAt implements io.WriterAt for BackoffWriterAt. A retry resumes at the
+// offset just past the bytes already written. It returns an error only once it
+// gives up, as BackoffWriter's Write does.
+func (bw *BackoffWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return bw.bp.write(p, func(p []byte, written int) (int, error) {
+		return bw.w.WriteAt(p, off+int64(written))
+	})
+}
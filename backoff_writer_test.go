@@ -0,0 +1,128 @@
+package miscio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// errTransient is the error tests inject as retryable.
+var errTransient = errors.New("transient")
+
+func isTransient(err error) bool { return err == errTransient }
+
+func TestBackoffWriter(t *testing.T) {
+	var out bytes.Buffer
+
+	fw := NewFaultWriter(NewShortWriter(&out, ShortByMax(4)), FailOnCall(2, errTransient), FailOnCall(3, errTransient))
+	bw := NewBackoffWriter(context.Background(), fw, isTransient, WithWriteBackoff(time.Second, 3*time.Second))
+
+	var delays []time.Duration
+	bw.bp.after = instantAfter(&delays)
+
+	n, err := bw.Write([]byte("hello, world"))
+	if n != 12 || err != nil {
+		t.Fatalf("expected the whole write to succeed, got (%d, %v)", n, err)
+	}
+
+	if out.String() != "hello, world" {
+		t.Errorf("expected each retry to resume where the last stopped, got %q", out.String())
+	}
+
+	if expected := []time.Duration{time.Second, 2 * time.Second}; !reflect.DeepEqual(delays, expected) {
+		t.Errorf("expected delays %v, got %v", expected, delays)
+	}
+}
+
+func TestBackoffWriterGivesUp(t *testing.T) {
+	errFatal := errors.New("fatal")
+
+	var out bytes.Buffer
+
+	var delays []time.Duration
+
+	fw := NewFaultWriter(&out, FailAfterBytes(3, errFatal))
+	bw := NewBackoffWriter(context.Background(), fw, isTransient)
+	bw.bp.after = instantAfter(&delays)
+
+	if n, err := bw.Write([]byte("abcdef")); n != 3 || err != errFatal {
+		t.Errorf("expected (3, fatal) for a non-retryable error, got (%d, %v)", n, err)
+	}
+
+	fw = NewFaultWriter(&out, FailAfterBytes(0, errTransient))
+	bw = NewBackoffWriter(context.Background(), fw, nil, WithWriteRetries(2))
+	bw.bp.after = instantAfter(&delays)
+
+	if n, err := bw.Write([]byte("abc")); n != 0 || err != errTransient || fw.Calls() != 3 {
+		t.Errorf("expected to give up after 2 retries, got (%d, %v) after %d calls", n, err, fw.Calls())
+	}
+}
+
+func TestBackoffWriterDefaultPredicate(t *testing.T) {
+	for _, err := range []error{ErrWriteAfterClose, io.ErrClosedPipe, fmt.Errorf("send: %w", context.Canceled)} {
+		fw := NewFaultWriter(ioutil.Discard, FailAfterBytes(0, err))
+		bw := NewBackoffWriter(context.Background(), fw, nil)
+
+		var delays []time.Duration
+		bw.bp.after = instantAfter(&delays)
+
+		if _, werr := bw.Write([]byte("x")); werr != err || fw.Calls() != 1 {
+			t.Errorf("expected %v not to be retried, got %v after %d calls", err, werr, fw.Calls())
+		}
+	}
+}
+
+func TestBackoffWriterContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var out bytes.Buffer
+
+	fw := NewFaultWriter(&out, FailAfterBytes(0, errTransient))
+	bw := NewBackoffWriter(ctx, fw, isTransient, WithWriteBackoff(time.Hour, time.Hour))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	if _, err := bw.Write([]byte("x")); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestBackoffWriterAt(t *testing.T) {
+	wr := NewWriterAtReadSeeker(0)
+	calls := 0
+
+	// Each call writes at most 4 bytes, failing if that is short.
+	short := WriterAtFunc(func(p []byte, off int64) (int, error) {
+		calls++
+
+		if len(p) <= 4 {
+			return wr.WriteAt(p, off)
+		}
+
+		n, _ := wr.WriteAt(p[:4], off)
+
+		return n, errTransient
+	})
+	bw := NewBackoffWriterAt(context.Background(), short, isTransient)
+
+	var delays []time.Duration
+	bw.bp.after = instantAfter(&delays)
+
+	if n, err := bw.WriteAt([]byte("0123456789"), 5); n != 10 || err != nil || calls != 3 {
+		t.Fatalf("expected the whole write to succeed in 3 calls, got (%d, %v) after %d", n, err, calls)
+	}
+
+	buf := make([]byte, 10)
+	if _, err := wr.ReadAt(buf, 5); err != nil || string(buf) != "0123456789" {
+		t.Errorf("expected the data at offset 5, got (%q, %v)", buf, err)
+	}
+}
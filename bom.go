@@ -0,0 +1,180 @@
+package miscio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// bomEncoding identifies the byte order mark at the start of a stream.
+type bomEncoding int
+
+const (
+	bomNone bomEncoding = iota
+	bomUTF8
+	bomUTF16BE
+	bomUTF16LE
+)
+
+// sniffBOM reads the first few bytes of r, and returns the byte order mark
+// found, if any, along with a reader yielding the rest of r, after the mark.
+func sniffBOM(r io.Reader) (bomEncoding, io.Reader, error) {
+	var head [3]byte
+
+	n, err := io.ReadFull(r, head[:])
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = nil
+	}
+
+	if err != nil {
+		return bomNone, nil, err
+	}
+
+	enc, skip := bomNone, 0
+
+	switch {
+	case n >= 3 && head[0] == 0xEF && head[1] == 0xBB && head[2] == 0xBF:
+		enc, skip = bomUTF8, 3
+	case n >= 2 && head[0] == 0xFE && head[1] == 0xFF:
+		enc, skip = bomUTF16BE, 2
+	case n >= 2 && head[0] == 0xFF && head[1] == 0xFE:
+		enc, skip = bomUTF16LE, 2
+	}
+
+	return enc, io.MultiReader(bytes.NewReader(head[skip:n]), r), nil
+}
+
+// bomReader defers sniffing the BOM of r to the first Read.
+type bomReader struct {
+	r         io.Reader
+	transcode bool
+	sniffed   bool
+	err       error
+}
+
+// SkipBOMReader returns an io.Reader that reads from r, dropping a leading
+// UTF-8 byte order mark if there is one. If r starts with a UTF-16 byte order
+// mark instead, the first Read fails with ErrUTF16, since passing UTF-16 on to
+// a consumer expecting UTF-8 would only fail later and less clearly; use
+// TranscodeBOMReader to accept UTF-16 input.
+func SkipBOMReader(r io.Reader) io.Reader {
+	return &bomReader{r: r}
+}
+
+// TranscodeBOMReader is like SkipBOMReader, but input starting with a UTF-16
+// (big or little endian) byte order mark is transcoded to UTF-8 rather than
+// rejected. Invalid UTF-16, such as an unpaired surrogate or a trailing odd
+// byte, is replaced by U+FFFD.
+func TranscodeBOMReader(r io.Reader) io.Reader {
+	return &bomReader{r: r, transcode: true}
+}
+
+func (br *bomReader) Read(p []byte) (int, error) {
+	if !br.sniffed {
+		br.sniffed = true
+
+		enc, rest, err := sniffBOM(br.r)
+		if err != nil {
+			br.err = err
+
+			return 0, err
+		}
+
+		br.r = rest
+
+		switch enc {
+		case bomUTF16BE, bomUTF16LE:
+			if !br.transcode {
+				br.err = ErrUTF16
+
+				return 0, br.err
+			}
+
+			order := binary.ByteOrder(binary.BigEndian)
+			if enc == bomUTF16LE {
+				order = binary.LittleEndian
+			}
+
+			br.r = &utf16Reader{r: rest, order: order}
+		case bomNone, bomUTF8:
+		}
+	}
+
+	if br.err != nil {
+		return 0, br.err
+	}
+
+	return br.r.Read(p)
+}
+
+// utf16Reader transcodes UTF-16 read from r into UTF-8.
+type utf16Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	in    []byte
+	out   []byte
+	err   error
+}
+
+func (ur *utf16Reader) Read(p []byte) (int, error) {
+	for len(ur.out) == 0 && ur.err == nil {
+		var buf [4096]byte
+
+		n, err := ur.r.Read(buf[:])
+		ur.in = append(ur.in, buf[:n]...)
+		ur.err = err
+		ur.decode(err != nil)
+	}
+
+	if len(ur.out) > 0 {
+		n := copy(p, ur.out)
+		ur.out = ur.out[n:]
+
+		return n, nil
+	}
+
+	return 0, ur.err
+}
+
+// decode transcodes as much of ur.in as possible into ur.out. If final is
+// true, no more input is coming, so incomplete sequences are replaced.
+func (ur *utf16Reader) decode(final bool) {
+	in := ur.in
+
+	for len(in) >= 2 {
+		r := rune(ur.order.Uint16(in))
+		size := 2
+
+		if utf16.IsSurrogate(r) {
+			if len(in) < 4 && !final {
+				break
+			}
+
+			r = utf8.RuneError
+
+			if len(in) >= 4 {
+				if pair := utf16.DecodeRune(rune(ur.order.Uint16(in)), rune(ur.order.Uint16(in[2:]))); pair != utf8.RuneError {
+					r, size = pair, 4
+				}
+			}
+		}
+
+		ur.out = appendRune(ur.out, r)
+		in = in[size:]
+	}
+
+	if final && len(in) > 0 {
+		ur.out = appendRune(ur.out, utf8.RuneError)
+		in = nil
+	}
+
+	ur.in = append(ur.in[:0], in...)
+}
+
+func appendRune(b []byte, r rune) []byte {
+	var buf [utf8.UTFMax]byte
+
+	return append(b, buf[:utf8.EncodeRune(buf[:], r)]...)
+}
@@ -0,0 +1,69 @@
+package miscio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+func TestSkipBOMReader(t *testing.T) {
+	for input, expected := range map[string]string{
+		"\xEF\xBB\xBFhello": "hello",
+		"hello":             "hello",
+		"hi":                "hi",
+		"":                  "",
+		"\xEF\xBB":          "\xEF\xBB",
+	} {
+		got, err := ioutil.ReadAll(SkipBOMReader(iotest.OneByteReader(bytes.NewReader([]byte(input)))))
+		if err != nil || string(got) != expected {
+			t.Errorf("reading %q got (%q, %v), want %q", input, got, err, expected)
+		}
+	}
+
+	if _, err := ioutil.ReadAll(SkipBOMReader(bytes.NewReader([]byte("\xFF\xFEh\x00")))); err != ErrUTF16 {
+		t.Errorf("expected ErrUTF16 for UTF-16 input, got %v", err)
+	}
+}
+
+func utf16Bytes(s string, bigEndian bool) []byte {
+	b := []byte{0xFF, 0xFE}
+	if bigEndian {
+		b = []byte{0xFE, 0xFF}
+	}
+
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+
+	return b
+}
+
+func TestTranscodeBOMReader(t *testing.T) {
+	const text = "héllo, 世界 🌍"
+
+	for _, bigEndian := range []bool{false, true} {
+		input := utf16Bytes(text, bigEndian)
+
+		got, err := ioutil.ReadAll(TranscodeBOMReader(iotest.OneByteReader(bytes.NewReader(input))))
+		if err != nil || string(got) != text {
+			t.Errorf("bigEndian=%v got (%q, %v), want %q", bigEndian, got, err, text)
+		}
+	}
+
+	// A lone high surrogate followed by an odd trailing byte.
+	got, _ := ioutil.ReadAll(TranscodeBOMReader(bytes.NewReader([]byte{0xFF, 0xFE, 0x3D, 0xD8, 'a', 0, 'b'})))
+	if string(got) != "�a�" {
+		t.Errorf("invalid UTF-16 got %q", got)
+	}
+
+	got, _ = ioutil.ReadAll(TranscodeBOMReader(bytes.NewReader([]byte("\xEF\xBB\xBFplain"))))
+	if string(got) != "plain" {
+		t.Errorf("UTF-8 input got %q", got)
+	}
+}
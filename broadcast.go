@@ -0,0 +1,283 @@
+package miscio
+
+import (
+	"io"
+	"sync"
+)
+
+// SlowReaderPolicy decides what a BroadcastWriter does when a Write does not
+// fit in a reader's buffer.
+type SlowReaderPolicy int
+
+const (
+	// SlowReaderBlock makes Write wait until the reader has made room. One
+	// slow reader therefore holds up the writer and every other reader.
+	SlowReaderBlock SlowReaderPolicy = iota
+	// SlowReaderDrop discards the whole Write for that reader only. The
+	// reader sees a gap in the stream; Dropped reports how many bytes it
+	// missed.
+	SlowReaderDrop
+	// SlowReaderDisconnect detaches the reader, which returns ErrSlowReader
+	// once it has read what was buffered.
+	SlowReaderDisconnect
+)
+
+// String implements fmt.Stringer for SlowReaderPolicy.
+func (p SlowReaderPolicy) String() string {
+	switch p {
+	case SlowReaderBlock:
+		return "block"
+	case SlowReaderDrop:
+		return "drop"
+	case SlowReaderDisconnect:
+		return "disconnect"
+	default:
+		return "unknown"
+	}
+}
+
+// BroadcastWriter is an io.WriteCloser that fans everything written to it out
+// to any number of BroadcastReaders, e.g. to stream live process output to
+// several clients. Readers may attach at any time, and receive the stream from
+// that point on. Each reader buffers up to a fixed number of bytes; what
+// happens when a reader falls further behind is set by the SlowReaderPolicy.
+//
+// A BroadcastWriter with no readers discards what is written to it. All
+// methods are safe for concurrent use.
+type BroadcastWriter struct {
+	writeMu sync.Mutex
+	m       sync.Mutex
+	readers map[*BroadcastReader]struct{}
+	limit   int
+	policy  SlowReaderPolicy
+	closed  bool
+}
+
+var _ io.WriteCloser = (*BroadcastWriter)(nil)
+
+// NewBroadcastWriter returns a BroadcastWriter whose readers each buffer up to
+// limit bytes, applying policy to readers that fall further behind.
+func NewBroadcastWriter(limit int, policy SlowReaderPolicy) *BroadcastWriter {
+	if limit < 1 {
+		limit = 1
+	}
+
+	return &BroadcastWriter{
+		readers: make(map[*BroadcastReader]struct{}),
+		limit:   limit,
+		policy:  policy,
+	}
+}
+
+// Attach returns a new BroadcastReader receiving everything written from now
+// on. The reader should be closed once no longer needed, or it will hold up or
+// miss data according to the policy. Attaching to a closed BroadcastWriter
+// returns a reader that is immediately at io.EOF.
+func (bw *BroadcastWriter) Attach() *BroadcastReader {
+	br := &BroadcastReader{bw: bw}
+	br.cond = sync.NewCond(&br.m)
+
+	bw.m.Lock()
+	defer bw.m.Unlock()
+
+	if bw.closed {
+		br.err = io.EOF
+
+		return br
+	}
+
+	bw.readers[br] = struct{}{}
+
+	return br
+}
+
+// Write implements io.Writer for BroadcastWriter, copying p to every attached
+// reader. It returns ErrWriteAfterClose after Close.
+func (bw *BroadcastWriter) Write(p []byte) (int, error) {
+	bw.writeMu.Lock()
+	defer bw.writeMu.Unlock()
+
+	bw.m.Lock()
+	if bw.closed {
+		bw.m.Unlock()
+
+		return 0, ErrWriteAfterClose
+	}
+
+	readers := make([]*BroadcastReader, 0, len(bw.readers))
+	for br := range bw.readers {
+		readers = append(readers, br)
+	}
+	bw.m.Unlock()
+
+	for _, br := range readers {
+		if !br.deliver(p, bw.limit, bw.policy) {
+			bw.detach(br)
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close implements io.Closer for BroadcastWriter. Attached readers return
+// io.EOF once they have read everything buffered.
+func (bw *BroadcastWriter) Close() error {
+	bw.m.Lock()
+	defer bw.m.Unlock()
+
+	bw.closed = true
+
+	for br := range bw.readers {
+		br.finish(io.EOF)
+	}
+
+	bw.readers = nil
+
+	return nil
+}
+
+// Readers returns the number of attached readers.
+func (bw *BroadcastWriter) Readers() int {
+	bw.m.Lock()
+	defer bw.m.Unlock()
+
+	return len(bw.readers)
+}
+
+func (bw *BroadcastWriter) detach(br *BroadcastReader) {
+	bw.m.Lock()
+	defer bw.m.Unlock()
+
+	delete(bw.readers, br)
+}
+
+// BroadcastReader is one reader of a BroadcastWriter's stream, as returned by
+// Attach. Read and Close are safe to call concurrently with each other, but
+// Read is not safe for concurrent use.
+type BroadcastReader struct {
+	bw      *BroadcastWriter
+	m       sync.Mutex
+	cond    *sync.Cond
+	buf     []byte
+	err     error
+	dropped int64
+}
+
+var _ io.ReadCloser = (*BroadcastReader)(nil)
+
+// deliver hands p to the reader according to policy, and reports false if the
+// reader should be detached.
+func (br *BroadcastReader) deliver(p []byte, limit int, policy SlowReaderPolicy) bool {
+	br.m.Lock()
+	defer br.m.Unlock()
+
+	if br.err != nil {
+		return false
+	}
+
+	if len(br.buf)+len(p) <= limit {
+		br.buf = append(br.buf, p...)
+		br.cond.Broadcast()
+
+		return true
+	}
+
+	switch policy {
+	case SlowReaderDrop:
+		br.dropped += int64(len(p))
+
+		return true
+	case SlowReaderDisconnect:
+		br.err = ErrSlowReader
+		br.cond.Broadcast()
+
+		return false
+	case SlowReaderBlock:
+	}
+
+	// Pass p on as room becomes available, since it may be larger than the
+	// limit altogether.
+	for len(p) > 0 {
+		for len(br.buf) >= limit && br.err == nil {
+			br.cond.Wait()
+		}
+
+		if br.err != nil {
+			return false
+		}
+
+		n := limit - len(br.buf)
+		if n > len(p) {
+			n = len(p)
+		}
+
+		br.buf = append(br.buf, p[:n]...)
+		p = p[n:]
+		br.cond.Broadcast()
+	}
+
+	return true
+}
+
+// finish ends the stream for the reader with err, unless it already ended.
+func (br *BroadcastReader) finish(err error) {
+	br.m.Lock()
+	defer br.m.Unlock()
+
+	if br.err == nil {
+		br.err = err
+	}
+
+	br.cond.Broadcast()
+}
+
+// Read implements io.Reader for BroadcastReader. It waits for data, and
+// returns io.EOF once the BroadcastWriter is closed, or ErrSlowReader if the
+// reader was disconnected, after the buffered data has been read.
+func (br *BroadcastReader) Read(p []byte) (int, error) {
+	br.m.Lock()
+	defer br.m.Unlock()
+
+	for len(br.buf) == 0 && br.err == nil {
+		br.cond.Wait()
+	}
+
+	if len(br.buf) == 0 {
+		return 0, br.err
+	}
+
+	n := copy(p, br.buf)
+	br.buf = br.buf[n:]
+
+	if len(br.buf) == 0 {
+		br.buf = nil
+	}
+
+	br.cond.Broadcast()
+
+	return n, nil
+}
+
+// Dropped returns the number of bytes this reader missed under the
+// SlowReaderDrop policy.
+func (br *BroadcastReader) Dropped() int64 {
+	br.m.Lock()
+	defer br.m.Unlock()
+
+	return br.dropped
+}
+
+// Close detaches the reader from its BroadcastWriter and discards anything
+// buffered. Subsequent Reads return io.EOF.
+func (br *BroadcastReader) Close() error {
+	br.bw.detach(br)
+
+	br.m.Lock()
+	defer br.m.Unlock()
+
+	br.buf = nil
+	br.err = io.EOF
+	br.cond.Broadcast()
+
+	return nil
+}
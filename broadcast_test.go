@@ -0,0 +1,138 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestBroadcastWriter(t *testing.T) {
+	bw := NewBroadcastWriter(64, SlowReaderBlock)
+	bw.Write([]byte("before anyone listens\n"))
+
+	r1 := bw.Attach()
+	bw.Write([]byte("one "))
+
+	r2 := bw.Attach()
+	bw.Write([]byte("two"))
+	bw.Close()
+
+	for _, tc := range []struct {
+		r        io.Reader
+		expected string
+	}{
+		{r1, "one two"},
+		{r2, "two"},
+	} {
+		got, err := ioutil.ReadAll(tc.r)
+		if err != nil || string(got) != tc.expected {
+			t.Errorf("expected %q, got (%q, %v)", tc.expected, got, err)
+		}
+	}
+
+	if _, err := bw.Write([]byte("late")); err != ErrWriteAfterClose {
+		t.Errorf("expected ErrWriteAfterClose, got %v", err)
+	}
+
+	if _, err := bw.Attach().Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected a reader attached after Close to be at io.EOF, got %v", err)
+	}
+}
+
+func TestBroadcastWriterBlock(t *testing.T) {
+	bw := NewBroadcastWriter(4, SlowReaderBlock)
+	r := bw.Attach()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		bw.Write([]byte("larger than the limit"))
+		bw.Close()
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected Write to block on the slow reader")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	got, err := ioutil.ReadAll(r)
+	if err != nil || string(got) != "larger than the limit" {
+		t.Errorf("got (%q, %v)", got, err)
+	}
+
+	<-done
+}
+
+func TestBroadcastWriterDrop(t *testing.T) {
+	bw := NewBroadcastWriter(8, SlowReaderDrop)
+	slow := bw.Attach()
+
+	bw.Write([]byte("12345"))
+	bw.Write([]byte("6789")) // Doesn't fit behind "12345".
+	bw.Write([]byte("abc"))
+	bw.Close()
+
+	got, err := ioutil.ReadAll(slow)
+	if err != nil || string(got) != "12345abc" {
+		t.Errorf("got (%q, %v)", got, err)
+	}
+
+	if d := slow.Dropped(); d != 4 {
+		t.Errorf("expected 4 bytes dropped, got %d", d)
+	}
+}
+
+func TestBroadcastWriterDisconnect(t *testing.T) {
+	bw := NewBroadcastWriter(8, SlowReaderDisconnect)
+	slow := bw.Attach()
+	fast := bw.Attach()
+
+	bw.Write([]byte("12345"))
+
+	buf := make([]byte, 8)
+	fast.Read(buf)
+
+	bw.Write([]byte("6789"))
+
+	if n := bw.Readers(); n != 1 {
+		t.Errorf("expected the slow reader to be detached, %d readers left", n)
+	}
+
+	got, err := ioutil.ReadAll(slow)
+	if err != ErrSlowReader || string(got) != "12345" {
+		t.Errorf("expected buffered data then ErrSlowReader, got (%q, %v)", got, err)
+	}
+
+	bw.Close()
+
+	if got, err := ioutil.ReadAll(fast); err != nil || string(got) != "6789" {
+		t.Errorf("got (%q, %v)", got, err)
+	}
+}
+
+func TestBroadcastReaderClose(t *testing.T) {
+	bw := NewBroadcastWriter(1, SlowReaderBlock)
+	r := bw.Attach()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		bw.Write([]byte("blocked"))
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	r.Close()
+	<-done
+
+	if n := bw.Readers(); n != 0 {
+		t.Errorf("expected no readers after Close, got %d", n)
+	}
+
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected io.EOF after Close, got %v", err)
+	}
+}
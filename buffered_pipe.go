@@ -0,0 +1,209 @@
+package miscio
+
+import (
+	"io"
+	"sync"
+)
+
+// bufferedPipe is the state shared by the two ends of a BufferedPipe: a ring
+// buffer of fixed size, and the errors each end was closed with.
+type bufferedPipe struct {
+	wrMu sync.Mutex // Serializes Writes, so they are never interleaved.
+	m    sync.Mutex
+	cond *sync.Cond
+
+	ring  []byte
+	start int // Index of the first unread byte.
+	n     int // Number of unread bytes.
+
+	rerr error // Set once the reader is closed.
+	werr error // Set once the writer is closed.
+}
+
+// BufferedPipe creates an in-memory pipe like io.Pipe, except that the writer
+// can run up to size bytes ahead of the reader before a Write blocks. Data is
+// copied through the buffer, so callers may reuse their slices as soon as a
+// call returns.
+//
+// As with io.Pipe, it is safe to call Read and Write in parallel with each
+// other or with Close, and parallel calls to Read or to Write are sequentially
+// gated.
+func BufferedPipe(size int) (*BufferedPipeReader, *BufferedPipeWriter) {
+	if size < 1 {
+		size = 1
+	}
+
+	p := &bufferedPipe{ring: make([]byte, size)}
+	p.cond = sync.NewCond(&p.m)
+
+	return &BufferedPipeReader{p}, &BufferedPipeWriter{p}
+}
+
+func (p *bufferedPipe) read(b []byte) (int, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for p.n == 0 && p.rerr == nil && p.werr == nil {
+		p.cond.Wait()
+	}
+
+	switch {
+	case p.rerr != nil:
+		return 0, io.ErrClosedPipe
+	case p.n == 0:
+		return 0, p.werr
+	}
+
+	read := 0
+
+	for read < len(b) && p.n > 0 {
+		end := p.start + p.n
+		if end > len(p.ring) {
+			end = len(p.ring)
+		}
+
+		c := copy(b[read:], p.ring[p.start:end])
+		read += c
+		p.start = (p.start + c) % len(p.ring)
+		p.n -= c
+	}
+
+	if p.n == 0 {
+		p.start = 0
+	}
+
+	p.cond.Broadcast()
+
+	return read, nil
+}
+
+func (p *bufferedPipe) write(b []byte) (int, error) {
+	p.wrMu.Lock()
+	defer p.wrMu.Unlock()
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	written := 0
+
+	for written < len(b) {
+		for p.n == len(p.ring) && p.rerr == nil && p.werr == nil {
+			p.cond.Wait()
+		}
+
+		switch {
+		case p.werr != nil:
+			return written, io.ErrClosedPipe
+		case p.rerr != nil:
+			return written, p.rerr
+		}
+
+		for written < len(b) && p.n < len(p.ring) {
+			tail := (p.start + p.n) % len(p.ring)
+
+			end := len(p.ring)
+			if tail < p.start {
+				end = p.start
+			}
+
+			c := copy(p.ring[tail:end], b[written:])
+			written += c
+			p.n += c
+		}
+
+		p.cond.Broadcast()
+	}
+
+	return written, nil
+}
+
+func (p *bufferedPipe) closeRead(err error) {
+	if err == nil {
+		err = io.ErrClosedPipe
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.rerr == nil {
+		p.rerr = err
+	}
+
+	p.cond.Broadcast()
+}
+
+func (p *bufferedPipe) closeWrite(err error) {
+	if err == nil {
+		err = io.EOF
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.werr == nil {
+		p.werr = err
+	}
+
+	p.cond.Broadcast()
+}
+
+// BufferedPipeReader is the read half of a BufferedPipe.
+type BufferedPipeReader struct {
+	p *bufferedPipe
+}
+
+var _ io.ReadCloser = (*BufferedPipeReader)(nil)
+
+// Read implements io.Reader for BufferedPipeReader. It returns whatever is
+// buffered, waiting for a Write if nothing is. Once the writer is closed, Read
+// returns the remaining buffered data and then the error the writer was closed
+// with (io.EOF for Close).
+func (r *BufferedPipeReader) Read(p []byte) (int, error) {
+	return r.p.read(p)
+}
+
+// Close closes the reader; subsequent writes to the write half of the pipe
+// return io.ErrClosedPipe.
+func (r *BufferedPipeReader) Close() error {
+	return r.CloseWithError(nil)
+}
+
+// CloseWithError closes the reader; subsequent writes to the write half of the
+// pipe return err, or io.ErrClosedPipe if err is nil. Anything still buffered
+// is discarded. CloseWithError never overwrites an earlier error, and always
+// returns nil.
+func (r *BufferedPipeReader) CloseWithError(err error) error {
+	r.p.closeRead(err)
+
+	return nil
+}
+
+// BufferedPipeWriter is the write half of a BufferedPipe.
+type BufferedPipeWriter struct {
+	p *bufferedPipe
+}
+
+var _ io.WriteCloser = (*BufferedPipeWriter)(nil)
+
+// Write implements io.Writer for BufferedPipeWriter. It copies p into the
+// pipe's buffer, blocking while the buffer is full, and returns once all of p
+// is buffered (not necessarily read). If the reader is closed first, Write
+// returns the number of bytes buffered along with the reader's error.
+func (w *BufferedPipeWriter) Write(p []byte) (int, error) {
+	return w.p.write(p)
+}
+
+// Close closes the writer; once the reader has consumed the buffered data,
+// subsequent reads return io.EOF.
+func (w *BufferedPipeWriter) Close() error {
+	return w.CloseWithError(nil)
+}
+
+// CloseWithError closes the writer; once the reader has consumed the buffered
+// data, subsequent reads return err, or io.EOF if err is nil. CloseWithError
+// never overwrites an earlier error, and always returns nil.
+func (w *BufferedPipeWriter) CloseWithError(err error) error {
+	w.p.closeWrite(err)
+
+	return nil
+}
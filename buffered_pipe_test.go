@@ -0,0 +1,89 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestBufferedPipe(t *testing.T) {
+	r, w := BufferedPipe(4)
+
+	// Fits in the buffer, so doesn't wait for a reader.
+	if n, err := w.Write([]byte("abc")); n != 3 || err != nil {
+		t.Fatalf("Write got (%d, %v)", n, err)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		w.Write([]byte("defghijklmnop"))
+		w.Close()
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected Write to block once the buffer filled")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	buf := make([]byte, 2)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "ab" {
+		t.Errorf("Read got (%q, %v)", buf[:n], err)
+	}
+
+	rest, err := ioutil.ReadAll(r)
+	if err != nil || string(rest) != "cdefghijklmnop" {
+		t.Errorf("ReadAll got (%q, %v)", rest, err)
+	}
+
+	<-done
+
+	if _, err := w.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Errorf("expected io.ErrClosedPipe writing after Close, got %v", err)
+	}
+}
+
+func TestBufferedPipeCloseWithError(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	t.Run("writer", func(t *testing.T) {
+		r, w := BufferedPipe(8)
+		w.Write([]byte("last"))
+		w.CloseWithError(errBoom)
+		w.CloseWithError(errors.New("ignored"))
+
+		got, err := ioutil.ReadAll(r)
+		if err != errBoom || string(got) != "last" {
+			t.Errorf("expected buffered data then errBoom, got (%q, %v)", got, err)
+		}
+	})
+
+	t.Run("reader", func(t *testing.T) {
+		r, w := BufferedPipe(2)
+
+		done := make(chan error)
+
+		go func() {
+			n, err := w.Write([]byte("abcd"))
+			if n != 2 {
+				t.Errorf("expected 2 bytes buffered before the reader closed, got %d", n)
+			}
+			done <- err
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		r.CloseWithError(errBoom)
+
+		if err := <-done; err != errBoom {
+			t.Errorf("expected errBoom from Write, got %v", err)
+		}
+
+		if _, err := r.Read(make([]byte, 1)); err != io.ErrClosedPipe {
+			t.Errorf("expected io.ErrClosedPipe reading after Close, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package miscio
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// DefaultBufferedWriterAtSize is the flush threshold of a BufferedWriterAt
+// created with a non-positive size.
+const DefaultBufferedWriterAtSize = 1 << 20
+
+// BufferedWriterAt buffers writes to an underlying io.WriterAt, coalescing
+// adjacent and overlapping writes in memory so that many small, out-of-order
+// writes reach the destination as a few large ones. Buffered data is written
+// out when Flush is called, or automatically once more than the configured
+// size is buffered. Like bufio.Writer, it must be flushed after the last
+// write.
+//
+// Overlapping writes are last-writer-wins, as for a plain io.WriterAt. All
+// methods are safe for concurrent use.
+type BufferedWriterAt struct {
+	m        sync.Mutex
+	w        io.WriterAt
+	size     int
+	align    int64
+	chunks   []bufChunk
+	buffered int
+}
+
+var (
+	_ io.WriterAt = (*BufferedWriterAt)(nil)
+	_ Flusher     = (*BufferedWriterAt)(nil)
+)
+
+// BufferedWriterAtOption configures a BufferedWriterAt at construction.
+type BufferedWriterAtOption func(bw *BufferedWriterAt)
+
+// WithAlignment makes the automatic flushes of a BufferedWriterAt write only
+// whole multiples of n bytes, starting and ending at offsets that are multiples
+// of n, for destinations such as block devices or multipart uploads that work
+// best (or only) with aligned writes. The unaligned ends of each run stay
+// buffered until later writes complete them, or until Flush, which writes
+// everything regardless of alignment.
+func WithAlignment(n int) BufferedWriterAtOption {
+	return func(bw *BufferedWriterAt) {
+		bw.align = int64(n)
+	}
+}
+
+// NewBufferedWriterAt returns a BufferedWriterAt writing to w, which flushes
+// once more than size bytes are buffered. If size is not positive,
+// DefaultBufferedWriterAtSize is used.
+func NewBufferedWriterAt(w io.WriterAt, size int, opts ...BufferedWriterAtOption) *BufferedWriterAt {
+	if size <= 0 {
+		size = DefaultBufferedWriterAtSize
+	}
+
+	bw := &BufferedWriterAt{w: w, size: size}
+
+	for _, opt := range opts {
+		opt(bw)
+	}
+
+	return bw
+}
+
+// WriteAt implements io.WriterAt for BufferedWriterAt. It copies p into the
+// buffer and reports success, unless this pushes the buffer over its size, in
+// which case it flushes. If that flush fails, WriteAt returns len(p) along with
+// the error: p itself was buffered, and is written by a later Flush along with
+// whatever else failed to be written.
+func (bw *BufferedWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrNegativeOffset, off)
+	}
+
+	bw.m.Lock()
+	defer bw.m.Unlock()
+
+	bw.insert(p, off)
+
+	if bw.buffered > bw.size {
+		if err := bw.flush(false); err != nil {
+			return len(p), err
+		}
+	}
+
+	return len(p), nil
+}
+
+// insert adds p at off to the buffered chunks, keeping bw.buffered up to date.
+// It must be called with bw.m held.
+func (bw *BufferedWriterAt) insert(p []byte, off int64) {
+	end := off + int64(len(p))
+
+	// Sequential writes extend the last chunk in place, so a stream of small
+	// writes costs amortized linear time rather than copying the chunk again
+	// each time.
+	if n := len(bw.chunks); n > 0 && off >= bw.chunks[n-1].off && off <= bw.chunks[n-1].end() {
+		last := &bw.chunks[n-1]
+		overlap := copy(last.data[off-last.off:], p)
+		last.data = append(last.data, p[overlap:]...)
+		bw.buffered += len(p) - overlap
+
+		return
+	}
+
+	// Only bytes not already buffered add to the total.
+	added := int64(len(p))
+
+	for i := sort.Search(len(bw.chunks), func(i int) bool { return bw.chunks[i].end() > off }); i < len(bw.chunks); i++ {
+		c := bw.chunks[i]
+		if c.off >= end {
+			break
+		}
+
+		lo, hi := c.off, c.end()
+		if lo < off {
+			lo = off
+		}
+
+		if hi > end {
+			hi = end
+		}
+
+		added -= hi - lo
+	}
+
+	bw.chunks = insertChunk(bw.chunks, p, off)
+	bw.buffered += int(added)
+}
+
+// Flush implements Flusher for BufferedWriterAt. It writes all buffered data to
+// the underlying io.WriterAt, one WriteAt per contiguous run, in ascending
+// offset order, then flushes the underlying io.WriterAt if it is a Flusher
+// itself. If a write fails, Flush returns the error, and that run and the ones
+// after it stay buffered for the next Flush.
+func (bw *BufferedWriterAt) Flush() error {
+	bw.m.Lock()
+	defer bw.m.Unlock()
+
+	if err := bw.flush(true); err != nil {
+		return err
+	}
+
+	return flushNext(bw.w)
+}
+
+// flush writes buffered runs out. Unless all is true, only the aligned part of
+// each run is written, if an alignment is set. It must be called with bw.m
+// held.
+func (bw *BufferedWriterAt) flush(all bool) error {
+	var kept []bufChunk
+
+	for i, c := range bw.chunks {
+		start, end := c.off, c.end()
+		if !all && bw.align > 0 {
+			start = (start + bw.align - 1) / bw.align * bw.align
+			end = end / bw.align * bw.align
+		}
+
+		if start >= end {
+			kept = append(kept, c)
+
+			continue
+		}
+
+		data := c.data[start-c.off : end-c.off]
+
+		n, err := bw.w.WriteAt(data, start)
+		if err == nil && n < len(data) {
+			err = io.ErrShortWrite
+		}
+
+		if err != nil {
+			bw.chunks = append(kept, bw.chunks[i:]...)
+
+			return err
+		}
+
+		// Copy the unaligned ends, so they don't pin the whole run in memory.
+		if start > c.off {
+			kept = append(kept, bufChunk{off: c.off, data: append([]byte(nil), c.data[:start-c.off]...)})
+		}
+
+		if end < c.end() {
+			kept = append(kept, bufChunk{off: end, data: append([]byte(nil), c.data[end-c.off:]...)})
+		}
+
+		bw.buffered -= len(data)
+	}
+
+	bw.chunks = kept
+
+	return nil
+}
+
+// Buffered returns the number of bytes currently buffered.
+func (bw *BufferedWriterAt) Buffered() int {
+	bw.m.Lock()
+	defer bw.m.Unlock()
+
+	return bw.buffered
+}
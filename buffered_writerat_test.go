@@ -0,0 +1,92 @@
+package miscio
+
+import "testing"
+
+func TestBufferedWriterAt(t *testing.T) {
+	dw := NewDryRunWriter(true)
+	bw := NewBufferedWriterAt(dw, 8)
+
+	for _, off := range []int64{3, 1, 0, 2} {
+		bw.WriteAt([]byte{byte('a' + off)}, off)
+	}
+
+	bw.WriteAt([]byte("XY"), 1)
+
+	if bw.Buffered() != 4 || len(dw.Plan()) != 0 {
+		t.Fatalf("expected 4 bytes buffered and nothing written, got %d and %v", bw.Buffered(), dw.Plan())
+	}
+
+	bw.WriteAt([]byte("far"), 100)
+
+	if err := bw.Flush(); err != nil {
+		t.Fatalf("Flush failed with %s", err)
+	}
+
+	plan := dw.Plan()
+	if len(plan) != 3 || string(plan[0].Data) != "aXYd" || plan[0].Offset != 0 || plan[1].Offset != 100 ||
+		plan[2].Op != PlanFlush {
+		t.Errorf("expected two coalesced writes and a flush, got %+v", plan)
+	}
+
+	bw.WriteAt([]byte("0123456789"), 0)
+
+	if bw.Buffered() != 0 || len(dw.Plan()) != 4 {
+		t.Errorf("expected exceeding the size to flush, %d bytes still buffered", bw.Buffered())
+	}
+}
+
+func TestBufferedWriterAtAlignment(t *testing.T) {
+	dw := NewDryRunWriter(true)
+	bw := NewBufferedWriterAt(dw, 8, WithAlignment(4))
+
+	// 11 bytes from offset 3 overflow the buffer; only [4, 12) is aligned.
+	for i, b := range []byte("0123456789a") {
+		bw.WriteAt([]byte{b}, int64(3+i))
+	}
+
+	plan := dw.Plan()
+	if len(plan) != 1 || plan[0].Offset != 4 || string(plan[0].Data) != "12345678" {
+		t.Fatalf("expected one aligned write of [4, 12), got %+v", plan)
+	}
+
+	if bw.Buffered() != 3 {
+		t.Errorf("expected the 3 unaligned bytes to stay buffered, got %d", bw.Buffered())
+	}
+
+	if err := bw.Flush(); err != nil {
+		t.Fatalf("Flush failed with %s", err)
+	}
+
+	if plan = dw.Plan(); len(plan) != 4 || string(plan[1].Data) != "0" || string(plan[2].Data) != "9a" {
+		t.Errorf("expected Flush to write the unaligned ends, got %+v", plan)
+	}
+}
+
+func TestBufferedWriterAtSequential(t *testing.T) {
+	dw := NewDryRunWriter(true)
+	bw := NewBufferedWriterAt(dw, 1<<20)
+
+	for off := int64(0); off < 100000; off++ {
+		bw.WriteAt([]byte{byte(off)}, off)
+	}
+
+	bw.WriteAt([]byte("xx"), 99999)
+
+	if bw.Buffered() != 100001 {
+		t.Fatalf("expected 100001 bytes buffered, got %d", bw.Buffered())
+	}
+
+	bw.WriteAt([]byte("y"), 5)
+
+	if bw.Buffered() != 100001 {
+		t.Errorf("expected an overwrite not to change the count, got %d", bw.Buffered())
+	}
+
+	if err := bw.Flush(); err != nil {
+		t.Fatalf("Flush failed with %s", err)
+	}
+
+	if plan := dw.Plan(); len(plan) != 2 || len(plan[0].Data) != 100001 || plan[0].Data[5] != 'y' {
+		t.Errorf("expected a single write of the whole run")
+	}
+}
@@ -0,0 +1,167 @@
+package miscio
+
+import (
+	"container/list"
+	"io"
+	"sync"
+)
+
+// CachingReaderAtStats is a point-in-time view of a CachingReaderAt's cache,
+// as returned by (*CachingReaderAt).Stats.
+type CachingReaderAtStats struct {
+	// Hits and Misses count block lookups served from the cache and from the
+	// underlying io.ReaderAt respectively. A ReadAt spanning several blocks
+	// makes one lookup per block.
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// Evictions is the total number of blocks dropped to stay within the
+	// cache's bound.
+	Evictions uint64 `json:"evictions"`
+	// Blocks is the number of blocks currently cached.
+	Blocks int `json:"blocks"`
+}
+
+// cachedBlock is one block of a CachingReaderAt's cache. data is shorter than
+// the block size only for the final block of the underlying reader.
+type cachedBlock struct {
+	idx  int64
+	data []byte
+}
+
+// CachingReaderAt wraps an io.ReaderAt with a cache of fixed-size blocks,
+// evicting the least recently used block once the cache is full, so that
+// repeated random reads of the same regions (a zip central directory, a parquet
+// footer) don't keep hitting a slow backend. The underlying reader's content is
+// assumed not to change.
+//
+// All methods are safe for concurrent use. Reads of the same uncached block
+// that race may each fetch it from the underlying reader.
+type CachingReaderAt struct {
+	r         io.ReaderAt
+	blockSize int64
+	maxBlocks int
+
+	m         sync.Mutex
+	lru       *list.List // Of *cachedBlock, most recently used first.
+	blocks    map[int64]*list.Element
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+var _ io.ReaderAt = (*CachingReaderAt)(nil)
+
+// NewCachingReaderAt returns a CachingReaderAt reading from r in blocks of
+// blockSize bytes, and caching at most maxBlocks of them.
+func NewCachingReaderAt(r io.ReaderAt, blockSize, maxBlocks int) *CachingReaderAt {
+	if blockSize < 1 {
+		blockSize = 1
+	}
+
+	if maxBlocks < 1 {
+		maxBlocks = 1
+	}
+
+	return &CachingReaderAt{
+		r:         r,
+		blockSize: int64(blockSize),
+		maxBlocks: maxBlocks,
+		lru:       list.New(),
+		blocks:    make(map[int64]*list.Element),
+	}
+}
+
+// ReadAt implements io.ReaderAt for CachingReaderAt. Reads from the underlying
+// reader are always whole, block-aligned blocks.
+func (c *CachingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	n := 0
+
+	for n < len(p) {
+		pos := off + int64(n)
+
+		data, err := c.block(pos / c.blockSize)
+		if err != nil {
+			return n, err
+		}
+
+		within := pos % c.blockSize
+		if within >= int64(len(data)) {
+			return n, io.EOF
+		}
+
+		n += copy(p[n:], data[within:])
+
+		if int64(len(data)) < c.blockSize && n < len(p) {
+			// A short block is the last one.
+			return n, io.EOF
+		}
+	}
+
+	return n, nil
+}
+
+// block returns the data of block idx, from the cache if possible.
+func (c *CachingReaderAt) block(idx int64) ([]byte, error) {
+	c.m.Lock()
+
+	if el, ok := c.blocks[idx]; ok {
+		c.hits++
+		c.lru.MoveToFront(el)
+		c.m.Unlock()
+
+		return el.Value.(*cachedBlock).data, nil
+	}
+
+	c.misses++
+	c.m.Unlock()
+
+	buf := make([]byte, c.blockSize)
+
+	n, err := c.r.ReadAt(buf, idx*c.blockSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	data := buf[:n]
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if _, ok := c.blocks[idx]; !ok {
+		c.blocks[idx] = c.lru.PushFront(&cachedBlock{idx: idx, data: data})
+
+		for c.lru.Len() > c.maxBlocks {
+			oldest := c.lru.Remove(c.lru.Back()).(*cachedBlock)
+			delete(c.blocks, oldest.idx)
+			c.evictions++
+		}
+	}
+
+	return data, nil
+}
+
+// Stats returns the cache's current statistics.
+func (c *CachingReaderAt) Stats() CachingReaderAtStats {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return CachingReaderAtStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Blocks:    c.lru.Len(),
+	}
+}
+
+// Purge empties the cache. The statistics are not reset.
+func (c *CachingReaderAt) Purge() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.lru.Init()
+	c.blocks = make(map[int64]*list.Element)
+}
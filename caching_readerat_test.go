@@ -0,0 +1,60 @@
+package miscio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCachingReaderAt(t *testing.T) {
+	src := &callCountingReaderAt{r: strings.NewReader("0123456789abcdefghij")}
+	c := NewCachingReaderAt(src, 4, 2)
+
+	buf := make([]byte, 6)
+	if n, err := c.ReadAt(buf, 2); err != nil || string(buf[:n]) != "234567" {
+		t.Errorf("ReadAt got (%q, %v)", buf[:n], err)
+	}
+
+	// Both blocks are cached now.
+	if n, err := c.ReadAt(buf[:3], 4); err != nil || string(buf[:n]) != "456" {
+		t.Errorf("ReadAt got (%q, %v)", buf[:n], err)
+	}
+
+	if src.calls != 2 {
+		t.Errorf("expected 2 reads of the backend, got %d", src.calls)
+	}
+
+	// Evicts block 0, the least recently used.
+	c.ReadAt(buf[:1], 8)
+	c.ReadAt(buf[:1], 0)
+
+	if src.calls != 4 {
+		t.Errorf("expected 4 reads of the backend after eviction, got %d", src.calls)
+	}
+
+	// The final block is short.
+	if n, err := c.ReadAt(buf, 17); err != io.EOF || string(buf[:n]) != "hij" {
+		t.Errorf("expected a short read with io.EOF, got (%q, %v)", buf[:n], err)
+	}
+
+	if n, err := c.ReadAt(buf, 20); n != 0 || err != io.EOF {
+		t.Errorf("expected (0, io.EOF) at the end, got (%d, %v)", n, err)
+	}
+
+	expected := CachingReaderAtStats{Hits: 2, Misses: 6, Evictions: 4, Blocks: 2}
+	if stats := c.Stats(); stats != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, stats)
+	}
+}
+
+// callCountingReaderAt counts the ReadAt calls made on it.
+type callCountingReaderAt struct {
+	r     io.ReaderAt
+	calls int
+}
+
+func (c *callCountingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.calls++
+
+	return c.r.ReadAt(p, off)
+}
@@ -0,0 +1,103 @@
+package miscio
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+type channelReader struct {
+	ch  <-chan []byte
+	cur []byte
+}
+
+// ChannelReader returns an io.Reader that reads the slices received from ch
+// as one continuous stream, returning io.EOF once ch is closed and drained. A
+// received slice that does not fit in a Read's buffer is returned over several
+// Reads. The slices must not be modified after they are sent. The returned
+// reader is not safe for concurrent use.
+func ChannelReader(ch <-chan []byte) io.Reader {
+	return &channelReader{ch: ch}
+}
+
+func (cr *channelReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for len(cr.cur) == 0 {
+		b, ok := <-cr.ch
+		if !ok {
+			return 0, io.EOF
+		}
+
+		cr.cur = b
+	}
+
+	n := copy(p, cr.cur)
+	cr.cur = cr.cur[n:]
+
+	return n, nil
+}
+
+// ChannelWriter is an io.WriteCloser that sends a copy of each Write to a
+// channel, as returned by NewChannelWriter. It is safe for concurrent use.
+type ChannelWriter struct {
+	ctx       context.Context
+	ch        chan<- []byte
+	m         sync.RWMutex
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+var _ io.WriteCloser = (*ChannelWriter)(nil)
+
+// NewChannelWriter returns a ChannelWriter sending to ch. Close closes ch, so
+// the ChannelWriter must be its only sender. A Write blocked on a full
+// channel returns ctx.Err() once ctx is done, or ErrWriteAfterClose once Close
+// is called.
+func NewChannelWriter(ctx context.Context, ch chan<- []byte) *ChannelWriter {
+	return &ChannelWriter{ctx: ctx, ch: ch, closed: make(chan struct{})}
+}
+
+// Write implements io.Writer for ChannelWriter, sending a copy of p. Empty
+// writes send nothing.
+func (cw *ChannelWriter) Write(p []byte) (int, error) {
+	cw.m.RLock()
+	defer cw.m.RUnlock()
+
+	select {
+	case <-cw.closed:
+		return 0, ErrWriteAfterClose
+	default:
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	select {
+	case cw.ch <- append([]byte(nil), p...):
+		return len(p), nil
+	case <-cw.ctx.Done():
+		return 0, cw.ctx.Err()
+	case <-cw.closed:
+		return 0, ErrWriteAfterClose
+	}
+}
+
+// Close implements io.Closer for ChannelWriter. It unblocks pending Writes,
+// waits for them to return, and then closes the channel, so that a
+// ChannelReader on the other end sees io.EOF.
+func (cw *ChannelWriter) Close() error {
+	cw.closeOnce.Do(func() {
+		close(cw.closed)
+
+		cw.m.Lock()
+		defer cw.m.Unlock()
+
+		close(cw.ch)
+	})
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package miscio
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestChannelReader(t *testing.T) {
+	ch := make(chan []byte, 4)
+	ch <- []byte("hello")
+	ch <- nil
+	ch <- []byte(" world")
+	close(ch)
+
+	r := ChannelReader(ch)
+
+	buf := make([]byte, 3)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "hel" {
+		t.Errorf("partial Read got (%q, %v)", buf[:n], err)
+	}
+
+	rest, err := ioutil.ReadAll(r)
+	if err != nil || string(rest) != "lo world" {
+		t.Errorf("ReadAll got (%q, %v)", rest, err)
+	}
+}
+
+func TestChannelWriter(t *testing.T) {
+	ch := make(chan []byte, 1)
+	w := NewChannelWriter(context.Background(), ch)
+
+	buf := []byte("data")
+	w.Write(buf)
+	buf[0] = 'X'
+
+	if got := <-ch; string(got) != "data" {
+		t.Errorf("expected a copy of the written data, got %q", got)
+	}
+
+	w.Write([]byte("fills the channel"))
+
+	done := make(chan error)
+
+	go func() {
+		_, err := w.Write([]byte("blocked"))
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	w.Close()
+
+	if err := <-done; err != ErrWriteAfterClose {
+		t.Errorf("expected a blocked Write to fail with ErrWriteAfterClose, got %v", err)
+	}
+
+	rest, _ := ioutil.ReadAll(ChannelReader(ch))
+	if string(rest) != "fills the channel" {
+		t.Errorf("expected Close to close the channel after the sent data, got %q", rest)
+	}
+}
+
+func TestChannelWriterContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	w := NewChannelWriter(ctx, make(chan []byte))
+	defer w.Close()
+
+	if _, err := io.WriteString(w, "nobody listening"); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package miscio
+
+import "io"
+
+// ChunkReader splits an io.Reader into fixed-size chunks, the read-side
+// counterpart of writing a stream in chunks with WriteAt. Every chunk is
+// exactly the chunk size, except that the final one may be shorter, which Next
+// signals with ErrShortChunk.
+//
+// A ChunkReader is not safe for concurrent use.
+type ChunkReader struct {
+	r   io.Reader
+	buf []byte
+	off int64 // Offset of the next chunk.
+	err error
+}
+
+// NewChunkReader returns a ChunkReader yielding size-byte chunks of r.
+func NewChunkReader(r io.Reader, size int) *ChunkReader {
+	if size < 1 {
+		size = 1
+	}
+
+	return &ChunkReader{r: r, buf: make([]byte, size)}
+}
+
+// Next returns the next chunk and its offset in the stream. The chunk is only
+// valid until the next call to Next. It returns:
+//
+//   - (chunk, off, nil) for a full chunk;
+//   - (chunk, off, ErrShortChunk) for a final chunk shorter than the chunk size;
+//   - (nil, off, io.EOF) once the stream is exhausted;
+//   - (nil, off, err) if reading from the underlying reader fails with err,
+//     in which case the data read towards the failed chunk is lost.
+//
+// Once Next returns an error, every later call returns io.EOF or the same
+// error.
+func (cr *ChunkReader) Next() ([]byte, int64, error) {
+	if cr.err != nil {
+		return nil, cr.off, cr.err
+	}
+
+	off := cr.off
+
+	n, err := io.ReadFull(cr.r, cr.buf)
+	cr.off += int64(n)
+
+	switch err {
+	case nil:
+		return cr.buf, off, nil
+	case io.EOF:
+		cr.err = io.EOF
+
+		return nil, off, io.EOF
+	case io.ErrUnexpectedEOF:
+		cr.err = io.EOF
+
+		return cr.buf[:n], off, ErrShortChunk
+	default:
+		cr.err = err
+
+		return nil, off, err
+	}
+}
+
+// Each calls fn with each chunk and its offset in turn, until the stream is
+// exhausted, returning nil, or until reading or fn fails, returning the error.
+// Each treats a short final chunk like any other.
+func (cr *ChunkReader) Each(fn func(chunk []byte, off int64) error) error {
+	for {
+		chunk, off, err := cr.Next()
+
+		switch err {
+		case nil, ErrShortChunk:
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+
+		if ferr := fn(chunk, off); ferr != nil {
+			return ferr
+		}
+	}
+}
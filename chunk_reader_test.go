@@ -0,0 +1,65 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestChunkReader(t *testing.T) {
+	cr := NewChunkReader(strings.NewReader("0123456789"), 4)
+
+	for _, expected := range []struct {
+		chunk string
+		off   int64
+		err   error
+	}{
+		{"0123", 0, nil},
+		{"4567", 4, nil},
+		{"89", 8, ErrShortChunk},
+		{"", 10, io.EOF},
+		{"", 10, io.EOF},
+	} {
+		chunk, off, err := cr.Next()
+		if string(chunk) != expected.chunk || off != expected.off || err != expected.err {
+			t.Errorf("expected (%q, %d, %v), got (%q, %d, %v)", expected.chunk, expected.off, expected.err, chunk, off, err)
+		}
+	}
+
+	// An exact multiple of the chunk size has no short chunk.
+	cr = NewChunkReader(strings.NewReader("01234567"), 4)
+
+	var offsets []int64
+
+	err := cr.Each(func(chunk []byte, off int64) error {
+		if len(chunk) != 4 {
+			t.Errorf("expected a full chunk at %d, got %q", off, chunk)
+		}
+
+		offsets = append(offsets, off)
+
+		return nil
+	})
+
+	if err != nil || len(offsets) != 2 {
+		t.Errorf("Each got offsets %v and %v", offsets, err)
+	}
+}
+
+func TestChunkReaderError(t *testing.T) {
+	errBoom := errors.New("boom")
+	cr := NewChunkReader(io.MultiReader(strings.NewReader("0123ab"), errReader{errBoom}), 4)
+
+	var got []string
+
+	err := cr.Each(func(chunk []byte, off int64) error {
+		got = append(got, string(chunk))
+
+		return nil
+	})
+
+	if err != errBoom || len(got) != 1 || got[0] != "0123" {
+		t.Errorf("expected one chunk and errBoom, got %q and %v", got, err)
+	}
+}
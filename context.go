@@ -0,0 +1,128 @@
+package miscio
+
+import (
+	"context"
+	"io"
+)
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// ContextReader returns an io.Reader that reads from r until ctx is done, after
+// which every Read returns ctx.Err() without calling r. A Read already in
+// progress when ctx is done is not interrupted; see InterruptibleContextReader.
+func ContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return cr.r.Read(p)
+}
+
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+// ContextWriter returns an io.Writer that writes to w until ctx is done, after
+// which every Write returns ctx.Err() without calling w. A Write already in
+// progress when ctx is done is not interrupted; see
+// InterruptibleContextWriter. The returned writer is a Flusher, flushing w
+// (if w is one) until ctx is done, after which Flush returns ctx.Err().
+func ContextWriter(ctx context.Context, w io.Writer) io.Writer {
+	return &contextWriter{ctx: ctx, w: w}
+}
+
+func (cw *contextWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return cw.w.Write(p)
+}
+
+// Flush implements Flusher by flushing the underlying writer, unless ctx is
+// done.
+func (cw *contextWriter) Flush() error {
+	if err := cw.ctx.Err(); err != nil {
+		return err
+	}
+
+	return flushNext(cw.w)
+}
+
+type interruptibleReader struct {
+	ctx context.Context
+	ar  asyncReader
+}
+
+// InterruptibleContextReader is like ContextReader, but a Read blocked in r
+// returns ctx.Err() as soon as ctx is done. To make that possible, each Read
+// runs in a goroutine, into a buffer owned by the returned reader; a Read that
+// is cut short keeps running until r returns. The returned reader is not safe
+// for concurrent use.
+func InterruptibleContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &interruptibleReader{ctx: ctx, ar: asyncReader{r: r}}
+}
+
+func (ir *interruptibleReader) Read(p []byte) (int, error) {
+	if err := ir.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	res, ok := ir.ar.read(p, ir.ctx.Done(), nil)
+	if !ok {
+		return 0, ir.ctx.Err()
+	}
+
+	return res.n, res.err
+}
+
+type interruptibleWriter struct {
+	ctx context.Context
+	aw  asyncWriter
+}
+
+// InterruptibleContextWriter is like ContextWriter, but a Write blocked in w
+// returns ctx.Err() as soon as ctx is done. To make that possible, each Write
+// runs in a goroutine, from a copy of p; a Write that is cut short keeps
+// running until w returns, so some or all of p may still be written. The
+// returned writer is a Flusher, whose Flush is interrupted the same way. It is
+// not safe for concurrent use.
+func InterruptibleContextWriter(ctx context.Context, w io.Writer) io.Writer {
+	return &interruptibleWriter{ctx: ctx, aw: asyncWriter{w: w}}
+}
+
+func (iw *interruptibleWriter) Write(p []byte) (int, error) {
+	if err := iw.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	res, _, ok := iw.aw.write(p, iw.ctx.Done(), nil)
+	if !ok {
+		return 0, iw.ctx.Err()
+	}
+
+	return res.n, res.err
+}
+
+// Flush implements Flusher by flushing the underlying writer, after waiting
+// for any Write that was cut short, unless ctx is done first.
+func (iw *interruptibleWriter) Flush() error {
+	if err := iw.ctx.Err(); err != nil {
+		return err
+	}
+
+	res, ok := iw.aw.flush(iw.ctx.Done(), nil)
+	if !ok {
+		return iw.ctx.Err()
+	}
+
+	return res.err
+}
@@ -0,0 +1,83 @@
+package miscio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextReaderWriter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var dst bytes.Buffer
+
+	r := ContextReader(ctx, strings.NewReader("hello world"))
+	w := ContextWriter(ctx, &dst)
+
+	buf := make([]byte, 5)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Errorf("Read before cancel got (%q, %v)", buf[:n], err)
+	}
+
+	w.Write(buf)
+	cancel()
+
+	if _, err := r.Read(buf); err != context.Canceled {
+		t.Errorf("expected context.Canceled from Read, got %v", err)
+	}
+
+	if _, err := w.Write(buf); err != context.Canceled || dst.String() != "hello" {
+		t.Errorf("expected context.Canceled from Write, got %v and %q", err, dst.String())
+	}
+}
+
+func TestInterruptibleContextReader(t *testing.T) {
+	pr, _ := io.Pipe()
+	defer pr.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := InterruptibleContextReader(ctx, pr).Read(make([]byte, 4)); err != context.DeadlineExceeded {
+		t.Errorf("expected a blocked Read to be interrupted, got %v", err)
+	}
+
+	// A fresh pipe, as the interrupted Read is still waiting on the first.
+	_, pw := io.Pipe()
+	defer pw.Close()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := InterruptibleContextWriter(ctx, pw).Write([]byte("data")); err != context.DeadlineExceeded {
+		t.Errorf("expected a blocked Write to be interrupted, got %v", err)
+	}
+}
+
+func TestContextWriterFlush(t *testing.T) {
+	for name, wrap := range map[string]func(context.Context, io.Writer) io.Writer{
+		"ContextWriter":              ContextWriter,
+		"InterruptibleContextWriter": InterruptibleContextWriter,
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var out bytes.Buffer
+
+		w := wrap(ctx, bufio.NewWriter(&out))
+		w.Write([]byte("data"))
+
+		if err := w.(Flusher).Flush(); err != nil || out.String() != "data" {
+			t.Errorf("%s: expected Flush to reach the bufio.Writer, got %q, %v", name, out.String(), err)
+		}
+
+		cancel()
+
+		if err := w.(Flusher).Flush(); err != context.Canceled {
+			t.Errorf("%s: expected Flush to fail once ctx is done, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package miscio
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// CountingReader wraps an io.Reader, counting the bytes read through it and
+// the calls made. The counters are updated atomically, so they may be read
+// from other goroutines while the reader is in use.
+type CountingReader struct {
+	// n and calls are accessed atomically, and come first to keep them 64-bit
+	// aligned on 32-bit platforms.
+	n     int64
+	calls int64
+	r     io.Reader
+}
+
+var (
+	_ io.Reader   = (*CountingReader)(nil)
+	_ io.WriterTo = (*CountingReader)(nil)
+)
+
+// NewCountingReader returns a CountingReader reading from r.
+func NewCountingReader(r io.Reader) *CountingReader {
+	return &CountingReader{r: r}
+}
+
+// Read implements io.Reader for CountingReader.
+func (cr *CountingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	atomic.AddInt64(&cr.n, int64(n))
+	atomic.AddInt64(&cr.calls, 1)
+
+	return n, err
+}
+
+// WriteTo implements io.WriterTo for CountingReader. Like io.Copy, it uses the
+// underlying reader's WriteTo, or else w's ReadFrom, if either exists, handing
+// over the original reader and writer, so wrapping a reader does not defeat
+// io.Copy's optimizations (such as sendfile or copy_file_range). Bytes copied
+// that way are counted when the call returns; otherwise they are counted as
+// they pass, so Count stays current during a long copy. Either way, WriteTo
+// counts as a single call.
+func (cr *CountingReader) WriteTo(w io.Writer) (int64, error) {
+	atomic.AddInt64(&cr.calls, 1)
+
+	var (
+		n   int64
+		err error
+	)
+
+	if wt, ok := cr.r.(io.WriterTo); ok {
+		n, err = wt.WriteTo(w)
+	} else if rf, ok := w.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(cr.r)
+	} else {
+		return io.Copy(w, countingSource{r: cr.r, n: &cr.n})
+	}
+
+	atomic.AddInt64(&cr.n, n)
+
+	return n, err
+}
+
+// Count returns the number of bytes read so far.
+func (cr *CountingReader) Count() int64 { return atomic.LoadInt64(&cr.n) }
+
+// Calls returns the number of calls to Read and WriteTo so far.
+func (cr *CountingReader) Calls() int64 { return atomic.LoadInt64(&cr.calls) }
+
+// CountingWriter wraps an io.Writer, counting the bytes written through it and
+// the calls made. The counters are updated atomically, so they may be read
+// from other goroutines while the writer is in use.
+type CountingWriter struct {
+	n     int64
+	calls int64
+	w     io.Writer
+}
+
+var (
+	_ io.Writer     = (*CountingWriter)(nil)
+	_ io.ReaderFrom = (*CountingWriter)(nil)
+	_ Flusher       = (*CountingWriter)(nil)
+)
+
+// NewCountingWriter returns a CountingWriter writing to w.
+func NewCountingWriter(w io.Writer) *CountingWriter {
+	return &CountingWriter{w: w}
+}
+
+// Write implements io.Writer for CountingWriter.
+func (cw *CountingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	atomic.AddInt64(&cw.n, int64(n))
+	atomic.AddInt64(&cw.calls, 1)
+
+	return n, err
+}
+
+// ReadFrom implements io.ReaderFrom for CountingWriter. It uses the underlying
+// writer's ReadFrom, or else r's WriteTo, if either exists, handing over the
+// original reader and writer so their optimizations still apply. Bytes copied
+// that way are counted when the call returns; otherwise they are counted as
+// they pass, so Count stays current during a long copy. Either way, ReadFrom
+// counts as a single call.
+func (cw *CountingWriter) ReadFrom(r io.Reader) (int64, error) {
+	atomic.AddInt64(&cw.calls, 1)
+
+	var (
+		n   int64
+		err error
+	)
+
+	if rf, ok := cw.w.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else if wt, ok := r.(io.WriterTo); ok {
+		n, err = wt.WriteTo(cw.w)
+	} else {
+		return io.Copy(countingSink{w: cw.w, n: &cw.n}, r)
+	}
+
+	atomic.AddInt64(&cw.n, n)
+
+	return n, err
+}
+
+// Flush implements Flusher for CountingWriter by flushing the underlying
+// writer.
+func (cw *CountingWriter) Flush() error {
+	return flushNext(cw.w)
+}
+
+// Count returns the number of bytes written so far.
+func (cw *CountingWriter) Count() int64 { return atomic.LoadInt64(&cw.n) }
+
+// Calls returns the number of calls to Write and ReadFrom so far.
+func (cw *CountingWriter) Calls() int64 { return atomic.LoadInt64(&cw.calls) }
+
+// countingSource is an io.Reader adding the bytes read through it to *n. It
+// deliberately implements nothing else, so io.Copy reads it with Read; it is
+// only used when neither end of a copy has a faster path to lose.
+type countingSource struct {
+	r io.Reader
+	n *int64
+}
+
+func (cs countingSource) Read(p []byte) (int, error) {
+	n, err := cs.r.Read(p)
+	atomic.AddInt64(cs.n, int64(n))
+
+	return n, err
+}
+
+// countingSink is an io.Writer adding the bytes written through it to *n. It
+// deliberately implements nothing else, so io.Copy writes to it with Write; it
+// is only used when neither end of a copy has a faster path to lose.
+type countingSink struct {
+	w io.Writer
+	n *int64
+}
+
+func (cs countingSink) Write(p []byte) (int, error) {
+	n, err := cs.w.Write(p)
+	atomic.AddInt64(cs.n, int64(n))
+
+	return n, err
+}
+
+// CountingWriterAt wraps an io.WriterAt, counting the bytes written through it
+// and the calls made. Overlapping writes are counted each time. It is safe
+// for concurrent use if the underlying io.WriterAt is.
+type CountingWriterAt struct {
+	n     int64
+	calls int64
+	w     io.WriterAt
+}
+
+var _ io.WriterAt = (*CountingWriterAt)(nil)
+
+// NewCountingWriterAt returns a CountingWriterAt writing to w.
+func NewCountingWriterAt(w io.WriterAt) *CountingWriterAt {
+	return &CountingWriterAt{w: w}
+}
+
+// WriteAt implements io.WriterAt for CountingWriterAt.
+func (cw *CountingWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := cw.w.WriteAt(p, off)
+	atomic.AddInt64(&cw.n, int64(n))
+	atomic.AddInt64(&cw.calls, 1)
+
+	return n, err
+}
+
+// Count returns the number of bytes written so far.
+func (cw *CountingWriterAt) Count() int64 { return atomic.LoadInt64(&cw.n) }
+
+// Calls returns the number of calls to WriteAt so far.
+func (cw *CountingWriterAt) Calls() int64 { return atomic.LoadInt64(&cw.calls) }
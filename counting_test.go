@@ -0,0 +1,156 @@
+package miscio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountingReader(t *testing.T) {
+	cr := NewCountingReader(strings.NewReader("hello world"))
+
+	buf := make([]byte, 5)
+	io.ReadFull(cr, buf)
+
+	if cr.Count() != 5 || cr.Calls() != 1 {
+		t.Errorf("after Read got Count %d and Calls %d", cr.Count(), cr.Calls())
+	}
+
+	var dst bytes.Buffer
+	if n, err := io.Copy(&dst, cr); n != 6 || err != nil || dst.String() != " world" {
+		t.Errorf("io.Copy got (%d, %v) and %q", n, err, dst.String())
+	}
+
+	if cr.Count() != 11 || cr.Calls() != 2 {
+		t.Errorf("expected io.Copy to go through WriteTo; Count %d and Calls %d", cr.Count(), cr.Calls())
+	}
+}
+
+func TestCountingWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	cw := NewCountingWriter(&dst)
+	cw.Write([]byte("hello "))
+
+	if _, err := io.Copy(cw, strings.NewReader("world")); err != nil {
+		t.Fatalf("io.Copy failed with %s", err)
+	}
+
+	if cw.Count() != 11 || cw.Calls() != 2 || dst.String() != "hello world" {
+		t.Errorf("got Count %d, Calls %d and %q", cw.Count(), cw.Calls(), dst.String())
+	}
+
+	discard := NewCountingWriter(ioutil.Discard)
+	io.Copy(discard, strings.NewReader("data"))
+
+	if discard.Count() != 4 {
+		t.Errorf("expected 4 bytes through ReadFrom, got %d", discard.Count())
+	}
+}
+
+func TestCountingWriterAt(t *testing.T) {
+	cw := NewCountingWriterAt(NewWriterAtReadSeeker(0))
+	cw.WriteAt([]byte("world"), 6)
+	cw.WriteAt([]byte("hello "), 0)
+
+	if cw.Count() != 11 || cw.Calls() != 2 {
+		t.Errorf("got Count %d and Calls %d", cw.Count(), cw.Calls())
+	}
+}
+
+// awaitCount waits for count to report n, failing the test if it doesn't
+// within a few seconds.
+func awaitCount(t *testing.T, count func() int64, n int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for count() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a count of %d during the copy, have %d", n, count())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCountingDuringCopy(t *testing.T) {
+	// Hide ioutil.Discard's ReadFrom, so the bytes have to pass through the
+	// counters one Write at a time.
+	discard := struct{ io.Writer }{ioutil.Discard}
+
+	pr, pw := io.Pipe()
+	cr := NewCountingReader(pr)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		io.Copy(discard, cr)
+	}()
+
+	pw.Write([]byte("first"))
+	awaitCount(t, cr.Count, 5)
+	pw.Close()
+	<-done
+
+	pr, pw = io.Pipe()
+	cw := NewCountingWriter(discard)
+	done = make(chan struct{})
+
+	go func() {
+		defer close(done)
+		io.Copy(cw, pr)
+	}()
+
+	pw.Write([]byte("second"))
+	awaitCount(t, cw.Count, 6)
+	pw.Close()
+	<-done
+}
+
+// readerFromRecorder is an io.ReaderFrom recording the reader it was given.
+type readerFromRecorder struct{ got io.Reader }
+
+func (rr *readerFromRecorder) Write(p []byte) (int, error) { return len(p), nil }
+
+func (rr *readerFromRecorder) ReadFrom(r io.Reader) (int64, error) {
+	rr.got = r
+
+	return io.Copy(ioutil.Discard, r)
+}
+
+func TestCountingPassesThroughPeers(t *testing.T) {
+	rr := &readerFromRecorder{}
+	cr := NewCountingReader(io.LimitReader(strings.NewReader("hello"), 5))
+
+	if n, err := io.Copy(rr, cr); n != 5 || err != nil {
+		t.Fatalf("io.Copy returned %d, %v", n, err)
+	}
+
+	if _, ok := rr.got.(*io.LimitedReader); !ok {
+		t.Errorf("expected ReadFrom to get the underlying *io.LimitedReader, got %T", rr.got)
+	}
+
+	if cr.Count() != 5 || cr.Calls() != 1 {
+		t.Errorf("got Count %d and Calls %d", cr.Count(), cr.Calls())
+	}
+
+	rr = &readerFromRecorder{}
+	cw := NewCountingWriter(rr)
+	lr := io.LimitReader(strings.NewReader("hello world"), 11)
+
+	if n, err := cw.ReadFrom(lr); n != 11 || err != nil {
+		t.Fatalf("ReadFrom returned %d, %v", n, err)
+	}
+
+	if rr.got != lr {
+		t.Errorf("expected ReadFrom to get the original reader, got %T", rr.got)
+	}
+
+	if cw.Count() != 11 || cw.Calls() != 1 {
+		t.Errorf("got Count %d and Calls %d", cw.Count(), cw.Calls())
+	}
+}
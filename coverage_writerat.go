@@ -0,0 +1,108 @@
+package miscio
+
+import (
+	"io"
+	"sync"
+)
+
+// CoverageWriterAt wraps an io.WriterAt, recording which byte ranges have been
+// written through it, and which were written more than once. Use it to check
+// at the end of a chunked upload or download that every byte of the target was
+// written exactly once. Only the bytes the underlying io.WriterAt reports as
+// written are recorded.
+//
+// All methods are safe for concurrent use if the underlying io.WriterAt is.
+type CoverageWriterAt struct {
+	w io.WriterAt
+
+	m       sync.Mutex
+	written IntervalSet
+	overlap IntervalSet
+}
+
+var _ io.WriterAt = (*CoverageWriterAt)(nil)
+
+// NewCoverageWriterAt returns a CoverageWriterAt writing to w. To only check a
+// chunking scheme, without storing any data, pass DiscardWriterAt.
+func NewCoverageWriterAt(w io.WriterAt) *CoverageWriterAt {
+	return &CoverageWriterAt{w: w}
+}
+
+// WriteAt implements io.WriterAt for CoverageWriterAt.
+func (cw *CoverageWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := cw.w.WriteAt(p, off)
+	if n <= 0 {
+		return n, err
+	}
+
+	end := off + int64(n)
+
+	cw.m.Lock()
+	defer cw.m.Unlock()
+
+	for _, r := range cw.written.Covered(off, end) {
+		cw.overlap.Add(r.Start, r.End)
+	}
+
+	cw.written.Add(off, end)
+
+	return n, err
+}
+
+// Ranges returns the maximal ranges written, in ascending order.
+func (cw *CoverageWriterAt) Ranges() []Range {
+	cw.m.Lock()
+	defer cw.m.Unlock()
+
+	return cw.written.Ranges()
+}
+
+// Covered returns the number of distinct bytes written.
+func (cw *CoverageWriterAt) Covered() int64 {
+	cw.m.Lock()
+	defer cw.m.Unlock()
+
+	return cw.written.Len()
+}
+
+// Gaps returns the maximal ranges within [a, b) that have not been written, in
+// ascending order.
+func (cw *CoverageWriterAt) Gaps(a, b int64) []Range {
+	cw.m.Lock()
+	defer cw.m.Unlock()
+
+	return cw.written.Gaps(a, b)
+}
+
+// Overlaps returns the maximal ranges that were written more than once, in
+// ascending order.
+func (cw *CoverageWriterAt) Overlaps() []Range {
+	cw.m.Lock()
+	defer cw.m.Unlock()
+
+	return cw.overlap.Ranges()
+}
+
+// Check returns nil if every byte in [0, size) was written exactly once, and
+// nothing beyond it was written; otherwise it returns an *ErrCoverage
+// describing the problem.
+func (cw *CoverageWriterAt) Check(size int64) error {
+	cw.m.Lock()
+	defer cw.m.Unlock()
+
+	err := &ErrCoverage{
+		Size:     size,
+		Gaps:     cw.written.Gaps(0, size),
+		Overlaps: cw.overlap.Ranges(),
+	}
+
+	if ranges := cw.written.Ranges(); len(ranges) > 0 && ranges[len(ranges)-1].End > size {
+		err.Beyond = ranges[len(ranges)-1].End
+	}
+
+	if len(err.Gaps) == 0 && len(err.Overlaps) == 0 && err.Beyond == 0 {
+		return nil
+	}
+
+	return err
+}
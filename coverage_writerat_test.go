@@ -0,0 +1,49 @@
+package miscio
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCoverageWriterAt(t *testing.T) {
+	cw := NewCoverageWriterAt(DiscardWriterAt)
+
+	for _, off := range []int64{0, 10, 30} {
+		cw.WriteAt(make([]byte, 10), off)
+	}
+
+	cw.WriteAt(make([]byte, 4), 8)
+
+	if gaps := cw.Gaps(0, 40); len(gaps) != 1 || gaps[0] != (Range{Start: 20, End: 30}) {
+		t.Errorf("unexpected gaps %v", gaps)
+	}
+
+	if overlaps := cw.Overlaps(); len(overlaps) != 1 || overlaps[0] != (Range{Start: 8, End: 12}) {
+		t.Errorf("unexpected overlaps %v", overlaps)
+	}
+
+	if cw.Covered() != 30 {
+		t.Errorf("expected 30 bytes covered, got %d", cw.Covered())
+	}
+
+	var covErr *ErrCoverage
+	if err := cw.Check(35); !errors.As(err, &covErr) || covErr.Beyond != 40 {
+		t.Fatalf("expected an ErrCoverage reporting writes up to 40, got %v", err)
+	}
+
+	if len(covErr.Gaps) != 1 || len(covErr.Overlaps) != 1 {
+		t.Errorf("unexpected ErrCoverage %+v", covErr)
+	}
+}
+
+func TestCoverageWriterAtExactlyOnce(t *testing.T) {
+	cw := NewCoverageWriterAt(NewWriterAtReadSeeker(0))
+
+	for _, off := range []int64{20, 0, 10} {
+		cw.WriteAt(make([]byte, 10), off)
+	}
+
+	if err := cw.Check(30); err != nil {
+		t.Errorf("expected exactly-once coverage, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package miscio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DefaultRepeatFormat is the marker format used by NewDedupWriter if none is
+// given. It takes the number of repeats.
+const DefaultRepeatFormat = "last message repeated %d times\n"
+
+// DedupWriter wraps an io.Writer, collapsing runs of consecutive identical
+// lines into the first line, followed by a marker line saying how many more
+// times it was repeated, like syslog does. The marker is written once a
+// different line arrives, or on Flush. Lines are only compared once complete,
+// so a partial line is held back until its newline (or Flush).
+//
+// Flush should be called after the last Write. It is safe for concurrent use.
+type DedupWriter struct {
+	m       sync.Mutex
+	w       io.Writer
+	format  string
+	partial []byte
+	last    []byte
+	repeats int
+	out     []byte
+}
+
+var (
+	_ io.WriteCloser = (*DedupWriter)(nil)
+	_ Flusher        = (*DedupWriter)(nil)
+)
+
+// NewDedupWriter returns a DedupWriter writing to w, whose repeat markers are
+// formatted with format (a fmt format string taking the number of repeats;
+// DefaultRepeatFormat if empty).
+func NewDedupWriter(w io.Writer, format string) *DedupWriter {
+	if format == "" {
+		format = DefaultRepeatFormat
+	}
+
+	return &DedupWriter{w: w, format: format}
+}
+
+// Write implements io.Writer for DedupWriter.
+func (dw *DedupWriter) Write(p []byte) (int, error) {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	n := len(p)
+	dw.out = dw.out[:0]
+
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			dw.partial = append(dw.partial, p...)
+
+			break
+		}
+
+		dw.partial = append(dw.partial, p[:i+1]...)
+		p = p[i+1:]
+
+		dw.addLine()
+	}
+
+	if err := dw.flushOut(); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// addLine handles the complete line in dw.partial. It must be called with dw.m
+// held.
+func (dw *DedupWriter) addLine() {
+	if dw.last != nil && bytes.Equal(dw.partial, dw.last) {
+		dw.repeats++
+		dw.partial = dw.partial[:0]
+
+		return
+	}
+
+	dw.appendMarker()
+	dw.out = append(dw.out, dw.partial...)
+	dw.last = append(dw.last[:0], dw.partial...)
+	dw.partial = dw.partial[:0]
+}
+
+// appendMarker appends the repeat marker for the current run, if any. It must
+// be called with dw.m held.
+func (dw *DedupWriter) appendMarker() {
+	if dw.repeats > 0 {
+		dw.out = append(dw.out, fmt.Sprintf(dw.format, dw.repeats)...)
+		dw.repeats = 0
+	}
+}
+
+// flushOut writes dw.out to the underlying writer. It must be called with dw.m
+// held.
+func (dw *DedupWriter) flushOut() error {
+	if len(dw.out) == 0 {
+		return nil
+	}
+
+	n, err := dw.w.Write(dw.out)
+	if err == nil && n < len(dw.out) {
+		err = io.ErrShortWrite
+	}
+
+	return err
+}
+
+// Flush implements Flusher for DedupWriter: it writes out the marker for a
+// pending run of repeats, and any partial line, then flushes the underlying
+// writer.
+func (dw *DedupWriter) Flush() error {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	dw.out = dw.out[:0]
+	dw.appendMarker()
+
+	if len(dw.partial) > 0 {
+		dw.out = append(dw.out, dw.partial...)
+		dw.partial = dw.partial[:0]
+		dw.last = nil
+	}
+
+	if err := dw.flushOut(); err != nil {
+		return err
+	}
+
+	return flushNext(dw.w)
+}
+
+// Close implements io.Closer for DedupWriter by calling Flush. It does not
+// close the underlying writer.
+func (dw *DedupWriter) Close() error {
+	return dw.Flush()
+}
@@ -0,0 +1,31 @@
+package miscio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDedupWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	dw := NewDedupWriter(&dst, "")
+
+	for _, chunk := range []string{"connecting\nretry\nre", "try\nretry\n", "retry\nconnected\npartial"} {
+		if n, err := dw.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) got (%d, %v)", chunk, n, err)
+		}
+	}
+
+	if expected := "connecting\nretry\nlast message repeated 3 times\nconnected\n"; dst.String() != expected {
+		t.Errorf("before Flush got %q, want %q", dst.String(), expected)
+	}
+
+	dst.Reset()
+	dw.Write([]byte(" line\n"))
+	dw.Write([]byte("partial line\n"))
+	dw.Flush()
+
+	if expected := "partial line\nlast message repeated 1 times\n"; dst.String() != expected {
+		t.Errorf("after Flush got %q, want %q", dst.String(), expected)
+	}
+}
@@ -0,0 +1,99 @@
+package miscio
+
+import (
+	"io"
+	"sync"
+)
+
+// DiscardWriterAt is an io.WriterAt on which all WriteAt calls succeed without
+// doing anything, the io.WriterAt counterpart of ioutil.Discard.
+var DiscardWriterAt io.WriterAt = discardWriterAt{}
+
+type discardWriterAt struct{}
+
+func (discardWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	return len(p), nil
+}
+
+// TrackingDiscardWriterAt is an io.WriterAt that discards everything written
+// to it, but keeps account of which ranges were written, and how much. It is
+// meant for dry runs of chunked or parallel transfers, e.g. to validate the
+// chunking logic of a ParallelCopy-style pipeline without allocating storage
+// for the data.
+//
+// All methods are safe for concurrent use.
+type TrackingDiscardWriterAt struct {
+	m       sync.Mutex
+	written IntervalSet
+	total   int64
+	calls   int64
+}
+
+var _ io.WriterAt = (*TrackingDiscardWriterAt)(nil)
+
+// NewTrackingDiscardWriterAt returns a new TrackingDiscardWriterAt.
+func NewTrackingDiscardWriterAt() *TrackingDiscardWriterAt {
+	return &TrackingDiscardWriterAt{}
+}
+
+// WriteAt implements io.WriterAt for TrackingDiscardWriterAt.
+func (dw *TrackingDiscardWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	dw.written.Add(off, off+int64(len(p)))
+	dw.total += int64(len(p))
+	dw.calls++
+
+	return len(p), nil
+}
+
+// Ranges returns the maximal ranges written, in ascending order.
+func (dw *TrackingDiscardWriterAt) Ranges() []Range {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	return dw.written.Ranges()
+}
+
+// Gaps returns the maximal ranges within [a, b) that have not been written, in
+// ascending order.
+func (dw *TrackingDiscardWriterAt) Gaps(a, b int64) []Range {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	return dw.written.Gaps(a, b)
+}
+
+// Covered returns the number of distinct bytes written.
+func (dw *TrackingDiscardWriterAt) Covered() int64 {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	return dw.written.Len()
+}
+
+// Total returns the number of bytes written, counting overlapping writes each
+// time.
+func (dw *TrackingDiscardWriterAt) Total() int64 {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	return dw.total
+}
+
+// Calls returns the number of calls to WriteAt.
+func (dw *TrackingDiscardWriterAt) Calls() int64 {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	return dw.calls
+}
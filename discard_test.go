@@ -0,0 +1,43 @@
+package miscio
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDiscardWriterAt(t *testing.T) {
+	if n, err := DiscardWriterAt.WriteAt([]byte("abc"), 1<<40); n != 3 || err != nil {
+		t.Errorf("WriteAt got (%d, %v)", n, err)
+	}
+}
+
+func TestTrackingDiscardWriterAt(t *testing.T) {
+	dw := NewTrackingDiscardWriterAt()
+
+	// Simulate a chunked transfer of 100 bytes that forgets the last chunk and
+	// writes one chunk twice.
+	var wg sync.WaitGroup
+
+	for _, off := range []int64{0, 20, 40, 60, 40} {
+		wg.Add(1)
+
+		go func(off int64) {
+			defer wg.Done()
+			dw.WriteAt(make([]byte, 20), off)
+		}(off)
+	}
+
+	wg.Wait()
+
+	if gaps := dw.Gaps(0, 100); len(gaps) != 1 || gaps[0] != (Range{Start: 80, End: 100}) {
+		t.Errorf("expected the missing chunk as the only gap, got %v", gaps)
+	}
+
+	if ranges := dw.Ranges(); len(ranges) != 1 || ranges[0] != (Range{Start: 0, End: 80}) {
+		t.Errorf("expected a single written range, got %v", ranges)
+	}
+
+	if dw.Covered() != 80 || dw.Total() != 100 || dw.Calls() != 5 {
+		t.Errorf("expected 80 covered, 100 total in 5 calls, got %d, %d in %d", dw.Covered(), dw.Total(), dw.Calls())
+	}
+}
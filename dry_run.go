@@ -0,0 +1,232 @@
+package miscio
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// PlanOp identifies the kind of operation recorded in a PlanEntry.
+type PlanOp int
+
+const (
+	// PlanWrite is a call to Write.
+	PlanWrite PlanOp = iota
+	// PlanWriteAt is a call to WriteAt.
+	PlanWriteAt
+	// PlanSync is a call to Sync.
+	PlanSync
+	// PlanClose is a call to Close.
+	PlanClose
+	// PlanMark is a caller-defined event, such as a log rotation or a shard
+	// boundary, recorded with Mark.
+	PlanMark
+	// PlanFlush is a call to Flush.
+	PlanFlush
+)
+
+// String implements fmt.Stringer for PlanOp.
+func (op PlanOp) String() string {
+	switch op {
+	case PlanWrite:
+		return "write"
+	case PlanWriteAt:
+		return "writeat"
+	case PlanSync:
+		return "sync"
+	case PlanClose:
+		return "close"
+	case PlanMark:
+		return "mark"
+	case PlanFlush:
+		return "flush"
+	default:
+		return "unknown"
+	}
+}
+
+// PlanEntry is a single operation recorded by a DryRunWriter.
+type PlanEntry struct {
+	Op PlanOp
+	// Offset is the offset of a PlanWriteAt, or the stream position at which
+	// a PlanWrite began.
+	Offset int64
+	// Size is the number of bytes written by a PlanWrite or PlanWriteAt.
+	Size int
+	// Label is the label passed to Mark.
+	Label string
+	// Data holds a copy of the bytes written, if the DryRunWriter was created
+	// to keep data.
+	Data []byte
+}
+
+// DryRunWriter accepts every Write, WriteAt, Sync, Flush and Close without
+// touching any real destination, recording each one as a PlanEntry. The
+// recorded plan can be inspected to validate how a pipeline would drive its
+// sink, and, if data was kept, replayed against a real sink later. It is safe
+// for concurrent use.
+type DryRunWriter struct {
+	m        sync.Mutex
+	plan     []PlanEntry
+	pos      int64
+	keepData bool
+}
+
+var (
+	_ io.WriteCloser = (*DryRunWriter)(nil)
+	_ io.WriterAt    = (*DryRunWriter)(nil)
+	_ Flusher        = (*DryRunWriter)(nil)
+)
+
+// NewDryRunWriter returns a new DryRunWriter. If keepData is true, a copy of
+// every write is kept so the plan can be replayed; otherwise only offsets and
+// sizes are recorded.
+func NewDryRunWriter(keepData bool) *DryRunWriter {
+	return &DryRunWriter{keepData: keepData}
+}
+
+func (dw *DryRunWriter) record(entry PlanEntry, p []byte) {
+	if dw.keepData && p != nil {
+		entry.Data = append([]byte{}, p...)
+	}
+
+	dw.plan = append(dw.plan, entry)
+}
+
+// Write implements io.Writer for DryRunWriter.
+func (dw *DryRunWriter) Write(p []byte) (int, error) {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	dw.record(PlanEntry{Op: PlanWrite, Offset: dw.pos, Size: len(p)}, p)
+	dw.pos += int64(len(p))
+
+	return len(p), nil
+}
+
+// WriteAt implements io.WriterAt for DryRunWriter.
+func (dw *DryRunWriter) WriteAt(p []byte, off int64) (int, error) {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	dw.record(PlanEntry{Op: PlanWriteAt, Offset: off, Size: len(p)}, p)
+
+	return len(p), nil
+}
+
+// Sync records a sync, mirroring (*os.File).Sync.
+func (dw *DryRunWriter) Sync() error {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	dw.record(PlanEntry{Op: PlanSync}, nil)
+
+	return nil
+}
+
+// Flush implements Flusher for DryRunWriter by recording a flush, so a
+// DryRunWriter at the end of a chain shows where the chain was flushed.
+func (dw *DryRunWriter) Flush() error {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	dw.record(PlanEntry{Op: PlanFlush}, nil)
+
+	return nil
+}
+
+// Close implements io.Closer for DryRunWriter. Unlike a real sink, the
+// DryRunWriter keeps accepting operations after Close.
+func (dw *DryRunWriter) Close() error {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	dw.record(PlanEntry{Op: PlanClose}, nil)
+
+	return nil
+}
+
+// Mark records a caller-defined event in the plan, such as a rotation.
+func (dw *DryRunWriter) Mark(label string) {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	dw.record(PlanEntry{Op: PlanMark, Label: label}, nil)
+}
+
+// Plan returns a copy of the operations recorded so far, in the order they
+// were made.
+func (dw *DryRunWriter) Plan() []PlanEntry {
+	dw.m.Lock()
+	defer dw.m.Unlock()
+
+	return append([]PlanEntry(nil), dw.plan...)
+}
+
+// Replay performs the recorded plan against dst. Writes, WriteAts, Syncs and
+// Closes require dst to implement io.Writer, io.WriterAt,
+// interface{ Sync() error } and io.Closer respectively; dst only needs the
+// methods the plan actually uses. Flushes flush dst if it is a Flusher (or has
+// an error-less Flush method), and are skipped otherwise, as are Marks.
+// Replay stops at the first error, which is returned along with the index of
+// the failing entry in the message.
+//
+// Replay returns ErrPlanDataNotKept if the DryRunWriter was not created to
+// keep data and the plan contains writes.
+func (dw *DryRunWriter) Replay(dst interface{}) error {
+	for i, entry := range dw.Plan() {
+		if err := replayEntry(dst, entry, dw.keepData); err != nil {
+			return fmt.Errorf("replaying plan entry %d (%s): %w", i, entry.Op, err)
+		}
+	}
+
+	return nil
+}
+
+func replayEntry(dst interface{}, entry PlanEntry, keepData bool) error {
+	var err error
+
+	switch entry.Op {
+	case PlanWrite, PlanWriteAt:
+		if !keepData {
+			return ErrPlanDataNotKept
+		}
+
+		if entry.Op == PlanWrite {
+			w, ok := dst.(io.Writer)
+			if !ok {
+				return fmt.Errorf("%w: destination does not implement io.Writer", ErrPlanUnsupported)
+			}
+
+			_, err = w.Write(entry.Data)
+
+			break
+		}
+
+		wa, ok := dst.(io.WriterAt)
+		if !ok {
+			return fmt.Errorf("%w: destination does not implement io.WriterAt", ErrPlanUnsupported)
+		}
+
+		_, err = wa.WriteAt(entry.Data, entry.Offset)
+	case PlanSync:
+		syncer, ok := dst.(interface{ Sync() error })
+		if !ok {
+			return fmt.Errorf("%w: destination does not implement Sync", ErrPlanUnsupported)
+		}
+
+		err = syncer.Sync()
+	case PlanClose:
+		closer, ok := dst.(io.Closer)
+		if !ok {
+			return fmt.Errorf("%w: destination does not implement io.Closer", ErrPlanUnsupported)
+		}
+
+		err = closer.Close()
+	case PlanFlush:
+		err = flushNext(dst)
+	case PlanMark:
+	}
+
+	return err
+}
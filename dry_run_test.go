@@ -0,0 +1,79 @@
+package miscio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDryRunWriterReplay(t *testing.T) {
+	dw := NewDryRunWriter(true)
+	dw.WriteAt([]byte("world"), 6)
+	dw.Mark("rotate")
+	dw.WriteAt([]byte("hello "), 0)
+	dw.Sync()
+	dw.Close()
+
+	plan := dw.Plan()
+	if len(plan) != 5 {
+		t.Fatalf("expected 5 plan entries, got %d", len(plan))
+	}
+
+	if plan[0].Op != PlanWriteAt || plan[0].Offset != 6 || plan[0].Size != 5 {
+		t.Errorf("unexpected first entry %+v", plan[0])
+	}
+
+	if plan[1].Op != PlanMark || plan[1].Label != "rotate" {
+		t.Errorf("unexpected mark entry %+v", plan[1])
+	}
+
+	dst := NewWriterAtReadCloser(0)
+
+	// WriterAtReadCloser has no Sync method.
+	if err := dw.Replay(dst); !errors.Is(err, ErrPlanUnsupported) {
+		t.Fatalf("expected ErrPlanUnsupported, got %v", err)
+	}
+
+	buf := make([]byte, 11)
+	n, _ := dst.Read(buf)
+
+	if string(buf[:n]) != "hello world" {
+		t.Errorf("replayed writes mismatch; got %q", buf[:n])
+	}
+}
+
+func TestDryRunWriterWithoutData(t *testing.T) {
+	dw := NewDryRunWriter(false)
+	dw.Write([]byte("abc"))
+	dw.Write([]byte("de"))
+
+	plan := dw.Plan()
+	if plan[1].Offset != 3 || plan[1].Size != 2 || plan[1].Data != nil {
+		t.Errorf("unexpected second entry %+v", plan[1])
+	}
+
+	if err := dw.Replay(&bytes.Buffer{}); !errors.Is(err, ErrPlanDataNotKept) {
+		t.Errorf("expected ErrPlanDataNotKept, got %v", err)
+	}
+}
+
+func TestDryRunWriterReplayFlush(t *testing.T) {
+	dw := NewDryRunWriter(true)
+	dw.Write([]byte("abc"))
+	dw.Flush()
+
+	if plan := dw.Plan(); len(plan) != 2 || plan[1].Op != PlanFlush {
+		t.Fatalf("expected a flush to be recorded, got %v", plan)
+	}
+
+	var out bytes.Buffer
+
+	if err := dw.Replay(bufio.NewWriter(&out)); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	if out.String() != "abc" {
+		t.Errorf("expected the replayed flush to write out the buffer, got %q", out.String())
+	}
+}
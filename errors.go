@@ -1,25 +1,208 @@
 package miscio
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 )
 
-// ErrShortBuffer thinly wraps io.ErrShortBuffer. Calls to (*RollingLineBuffer).Read
-// may return errors of this type.
+// ErrShortBuffer thinly wraps io.ErrShortBuffer, adding the buffer size a read
+// needs to succeed. Calls to (*RollingLineBuffer).Read, (*LinePipeReader).Read,
+// (*LineReader).Read and (*FrameBuffer).Read may return errors of this type.
+// Readers layered over them, inside or outside this package, can report their
+// own size requirements with NewErrShortBuffer.
 type ErrShortBuffer struct {
 	minimumSize int
 }
 
+// NewErrShortBuffer returns an ErrShortBuffer reporting that a read needs a
+// buffer of at least min bytes.
+func NewErrShortBuffer(min int) *ErrShortBuffer {
+	return &ErrShortBuffer{minimumSize: min}
+}
+
 // Unwrap allows miscio.ErrShortBuffer to satisfy an errors.Is(err, io.ErrShortBuffer)
 // check.
 func (err *ErrShortBuffer) Unwrap() error { return io.ErrShortBuffer }
 
-// SizeNeeded returns the minimum size needed for a RollingLineBuffer's Read
-// call to succeed.
+// SizeNeeded returns the minimum buffer size needed for the failed read to
+// succeed if retried. It is a stable part of the API.
 func (err *ErrShortBuffer) SizeNeeded() int { return err.minimumSize }
 
 // Error implements error for ErrShortBuffer
 func (err *ErrShortBuffer) Error() string {
 	return fmt.Errorf("%w: need buffer at least length %d", io.ErrShortBuffer, err.minimumSize).Error()
 }
+
+var (
+	// ErrPlanDataNotKept is returned by (*DryRunWriter).Replay when the
+	// DryRunWriter did not keep a copy of the data written to it.
+	ErrPlanDataNotKept = errors.New("miscio: dry run did not keep write data")
+	// ErrPlanUnsupported is returned by (*DryRunWriter).Replay when the replay
+	// destination cannot perform a recorded operation.
+	ErrPlanUnsupported = errors.New("miscio: replay destination does not support operation")
+)
+
+// ErrBeyondSize is returned (wrapped) by a WriterAtReadCloser when a write
+// would extend past the size set with SetSize or WithSize.
+var ErrBeyondSize = errors.New("miscio: write beyond expected size")
+
+// ErrBufferFull is returned (wrapped) by a non-blocking WriterAtReadCloser's
+// WriteAt when accepting the write would exceed the limit set with
+// WithMaxBuffered.
+var ErrBufferFull = errors.New("miscio: buffer full")
+
+// ErrWriteConflict is returned by a WriterAtReadCloser created WithConflictCheck
+// when a write would change bytes that were already written.
+type ErrWriteConflict struct {
+	// Offset is the absolute offset of the first byte that would change.
+	Offset int64
+}
+
+// Error implements error for ErrWriteConflict.
+func (err *ErrWriteConflict) Error() string {
+	return fmt.Sprintf("miscio: write conflicts with data already written at offset %d", err.Offset)
+}
+
+// ErrWriteLimitExceeded is returned by a LimitedWriter when a write would take
+// it past its limit.
+type ErrWriteLimitExceeded struct {
+	// Limit is the writer's limit.
+	Limit int64
+	// Written is the number of bytes written in total, including the part of
+	// the failed write that fit.
+	Written int64
+}
+
+// Error implements error for ErrWriteLimitExceeded.
+func (err *ErrWriteLimitExceeded) Error() string {
+	return fmt.Sprintf("miscio: write limit of %d bytes exceeded", err.Limit)
+}
+
+// ErrCoverage is returned by (*CoverageWriterAt).Check when the target was not
+// written exactly once.
+type ErrCoverage struct {
+	// Size is the size of the target that was checked.
+	Size int64
+	// Gaps are the ranges of the target never written.
+	Gaps []Range
+	// Overlaps are the ranges written more than once.
+	Overlaps []Range
+	// Beyond, if not zero, is the end of the highest write, which went past
+	// Size.
+	Beyond int64
+}
+
+// Error implements error for ErrCoverage.
+func (err *ErrCoverage) Error() string {
+	msg := fmt.Sprintf("miscio: coverage of %d bytes: %d gaps, %d overlaps", err.Size, len(err.Gaps), len(err.Overlaps))
+	if err.Beyond > 0 {
+		msg += fmt.Sprintf(", written up to %d", err.Beyond)
+	}
+
+	return msg
+}
+
+// MultiError combines several errors into one, as returned by the Close method
+// of a MultiCloser. errors.Is and errors.As match an error if they match any of
+// its members.
+type MultiError []error
+
+// joinErrors returns nil for no errors, the error itself for one, and a
+// MultiError otherwise.
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return MultiError(errs)
+	}
+}
+
+// Error implements error for MultiError, joining the messages of its members
+// with newlines.
+func (errs MultiError) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Is reports whether any member of errs matches target, for errors.Is.
+func (errs MultiError) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first member of errs that matches target, for errors.As.
+func (errs MultiError) As(target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrNegativeOffset is returned (wrapped) by WriteAt, ReadAt and Seek methods
+// given an offset below zero.
+var ErrNegativeOffset = errors.New("miscio: negative offset")
+
+// ErrOffsetConsumed is returned (wrapped) by (*WriterAtReadCloser).WriteAt when
+// the write starts in the part of the stream that has already been read, and
+// so can no longer be changed.
+var ErrOffsetConsumed = errors.New("miscio: offset already consumed by reader")
+
+// ErrWriteAfterClose is returned by WriteAt once a WriterAtReadCloser or
+// WriterAtReadSeeker has been closed for writing, and by the package's other
+// closable writers once closed. It wraps os.ErrClosed, so
+// errors.Is(err, os.ErrClosed) also holds.
+var ErrWriteAfterClose = fmt.Errorf("miscio: write after close: %w", os.ErrClosed)
+
+// ErrNotWritten is returned by (*WriterAtReadSeeker).ReadAt when part of the
+// requested range has not been written yet.
+var ErrNotWritten = errors.New("miscio: range not written yet")
+
+// ErrUTF16 is returned by a reader created with SkipBOMReader when its input
+// starts with a UTF-16 byte order mark.
+var ErrUTF16 = errors.New("miscio: input is UTF-16, not UTF-8")
+
+// ErrSlowReader is returned by a BroadcastReader that was disconnected for
+// falling too far behind, under the SlowReaderDisconnect policy.
+var ErrSlowReader = errors.New("miscio: reader disconnected for falling behind")
+
+// ErrNotRecorded is returned (wrapped) by (*ReplayReader).Seek when the target
+// offset is outside the recorded data it still holds.
+var ErrNotRecorded = errors.New("miscio: offset not recorded")
+
+// ErrShortChunk is returned by (*ChunkReader).Next along with a final chunk
+// that is shorter than the chunk size.
+var ErrShortChunk = errors.New("miscio: short final chunk")
+
+// ErrLineTooLong is returned by a LineReader along with the truncated prefix of
+// a line longer than its maximum line length.
+var ErrLineTooLong = errors.New("miscio: line too long")
+
+// ErrUnterminatedLine is returned by a LineReader along with a final line that
+// is not followed by a newline.
+var ErrUnterminatedLine = errors.New("miscio: unterminated final line")
+
+// ErrAlreadyRegistered is returned (wrapped) by a Registry when a buffer is
+// registered under a name that is already taken.
+var ErrAlreadyRegistered = errors.New("miscio: name already registered")
+
+// ErrFrameTooLarge is returned (wrapped) by a FrameBuffer when a frame exceeds
+// the size set with WithMaxFrameSize.
+var ErrFrameTooLarge = errors.New("miscio: frame too large")
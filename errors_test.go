@@ -0,0 +1,21 @@
+package miscio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestNewErrShortBuffer(t *testing.T) {
+	err := fmt.Errorf("reading record: %w", NewErrShortBuffer(42))
+
+	if !errors.Is(err, io.ErrShortBuffer) {
+		t.Errorf("expected %v to match io.ErrShortBuffer", err)
+	}
+
+	var sb *ErrShortBuffer
+	if !errors.As(err, &sb) || sb.SizeNeeded() != 42 {
+		t.Errorf("expected an ErrShortBuffer needing 42 bytes, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package miscio
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"sync"
+)
+
+// Default tags CaptureOutput prepends to each line from a subprocess's output
+// streams.
+const (
+	DefaultStdoutTag = "[stdout] "
+	DefaultStderrTag = "[stderr] "
+)
+
+type captureConfig struct {
+	stdoutTag string
+	stderrTag string
+	stderr    *RollingLineBuffer
+}
+
+// CaptureOption configures CaptureOutput.
+type CaptureOption func(cfg *captureConfig)
+
+// WithStreamTags sets the tags prepended to lines from stdout and stderr. Pass
+// empty strings to store lines as printed. The defaults are DefaultStdoutTag
+// and DefaultStderrTag.
+func WithStreamTags(stdout, stderr string) CaptureOption {
+	return func(cfg *captureConfig) {
+		cfg.stdoutTag = stdout
+		cfg.stderrTag = stderr
+	}
+}
+
+// WithStderrBuffer sends lines from stderr to rb instead of the buffer stdout
+// goes to. They are still tagged, unless WithStreamTags says otherwise.
+func WithStderrBuffer(rb *RollingLineBuffer) CaptureOption {
+	return func(cfg *captureConfig) {
+		cfg.stderr = rb
+	}
+}
+
+// CapturedCmd is an *exec.Cmd whose output is being captured by CaptureOutput.
+// Use its Run or Wait rather than those of the underlying *exec.Cmd, so that a
+// final line the subprocess did not terminate with a newline is captured too.
+type CapturedCmd struct {
+	*exec.Cmd
+
+	stdout *lineTagger
+	stderr *lineTagger
+}
+
+// CaptureOutput sets cmd's Stdout and Stderr so that every line the subprocess
+// prints on either is written to rb, prefixed with a tag naming its stream.
+// Lines from the two streams are stored in the order they were completed, so rb
+// ends up holding the last lines the subprocess printed, across both streams,
+// in order. A line is never split or interleaved with the other stream, no
+// matter how the subprocess's writes were broken up.
+//
+// cmd must not have been started, and its Stdout and Stderr must be nil.
+func CaptureOutput(cmd *exec.Cmd, rb *RollingLineBuffer, opts ...CaptureOption) (*CapturedCmd, error) {
+	if cmd.Stdout != nil {
+		return nil, errors.New("miscio: Stdout already set")
+	}
+
+	if cmd.Stderr != nil {
+		return nil, errors.New("miscio: Stderr already set")
+	}
+
+	cfg := captureConfig{
+		stdoutTag: DefaultStdoutTag,
+		stderrTag: DefaultStderrTag,
+		stderr:    rb,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	cc := &CapturedCmd{
+		Cmd:    cmd,
+		stdout: &lineTagger{rb: rb, tag: []byte(cfg.stdoutTag)},
+		stderr: &lineTagger{rb: cfg.stderr, tag: []byte(cfg.stderrTag)},
+	}
+
+	cmd.Stdout = cc.stdout
+	cmd.Stderr = cc.stderr
+
+	return cc, nil
+}
+
+// Run starts the command and waits for it to complete, like (*exec.Cmd).Run.
+func (cc *CapturedCmd) Run() error {
+	if err := cc.Start(); err != nil {
+		return err
+	}
+
+	return cc.Wait()
+}
+
+// Wait waits for the command to exit and its output to be captured, like
+// (*exec.Cmd).Wait, then stores any unterminated final line from each stream.
+func (cc *CapturedCmd) Wait() error {
+	err := cc.Cmd.Wait()
+
+	if ferr := cc.stdout.flush(); err == nil {
+		err = ferr
+	}
+
+	if ferr := cc.stderr.flush(); err == nil {
+		err = ferr
+	}
+
+	return err
+}
+
+// lineTagger is the io.Writer CaptureOutput attaches to one output stream. It
+// holds back partial lines, and writes each batch of complete lines, tagged, to
+// rb in a single Write.
+type lineTagger struct {
+	m       sync.Mutex
+	rb      *RollingLineBuffer
+	tag     []byte
+	partial []byte
+	out     []byte
+}
+
+func (lt *lineTagger) Write(p []byte) (int, error) {
+	lt.m.Lock()
+	defer lt.m.Unlock()
+
+	last := bytes.LastIndexByte(p, '\n')
+	if last < 0 {
+		lt.partial = append(lt.partial, p...)
+
+		return len(p), nil
+	}
+
+	lt.out = lt.out[:0]
+
+	for rest := p[:last]; ; {
+		line := rest
+		i := bytes.IndexByte(rest, '\n')
+
+		if i >= 0 {
+			line = rest[:i]
+		}
+
+		if len(lt.out) > 0 {
+			lt.out = append(lt.out, '\n')
+		}
+
+		lt.out = append(lt.out, lt.tag...)
+		lt.out = append(lt.out, lt.partial...)
+		lt.out = append(lt.out, line...)
+		lt.partial = lt.partial[:0]
+
+		if i < 0 {
+			break
+		}
+
+		rest = rest[i+1:]
+	}
+
+	lt.partial = append(lt.partial, p[last+1:]...)
+
+	if _, err := lt.rb.Write(lt.out); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// flush writes out the partial line held back, if any.
+func (lt *lineTagger) flush() error {
+	lt.m.Lock()
+	defer lt.m.Unlock()
+
+	if len(lt.partial) == 0 {
+		return nil
+	}
+
+	line := append(append([]byte(nil), lt.tag...), lt.partial...)
+	lt.partial = nil
+
+	_, err := lt.rb.Write(line)
+
+	return err
+}
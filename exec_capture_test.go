@@ -0,0 +1,78 @@
+package miscio
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestCaptureOutput(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh available")
+	}
+
+	script := `printf 'one\ntw'; sleep 0.05
+printf 'o\n' >&2; sleep 0.05
+printf 'o\nthree\n'; sleep 0.05
+printf 'oops' >&2; exit 3`
+	cmd := exec.Command(sh, "-c", script)
+	rb := NewRollingLineBuffer(10)
+
+	cc, err := CaptureOutput(cmd, rb)
+	if err != nil {
+		t.Fatalf("CaptureOutput failed: %v", err)
+	}
+
+	err = cc.Run()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("expected exit status 3, got %v", err)
+	}
+
+	expected := []string{
+		"[stdout] one",
+		"[stderr] o",
+		"[stdout] two",
+		"[stdout] three",
+		"[stderr] oops",
+	}
+	if got := rb.Snapshot(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestCaptureOutputSeparateBuffers(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh available")
+	}
+
+	cmd := exec.Command(sh, "-c", `echo out; echo err >&2`)
+	stdout, stderr := NewRollingLineBuffer(10), NewRollingLineBuffer(10)
+
+	cc, err := CaptureOutput(cmd, stdout, WithStderrBuffer(stderr), WithStreamTags("", ""))
+	if err != nil {
+		t.Fatalf("CaptureOutput failed: %v", err)
+	}
+
+	if err := cc.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if got := stdout.Snapshot(); !reflect.DeepEqual(got, []string{"out"}) {
+		t.Errorf("unexpected stdout %q", got)
+	}
+
+	if got := stderr.Snapshot(); !reflect.DeepEqual(got, []string{"err"}) {
+		t.Errorf("unexpected stderr %q", got)
+	}
+}
+
+func TestCaptureOutputAlreadySet(t *testing.T) {
+	cmd := exec.Command("true")
+	cmd.Stderr = NewRollingLineBuffer(1)
+
+	if _, err := CaptureOutput(cmd, NewRollingLineBuffer(1)); err == nil {
+		t.Error("expected an error when Stderr is already set")
+	}
+}
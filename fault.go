@@ -0,0 +1,213 @@
+package miscio
+
+import (
+	"io"
+	"sync"
+)
+
+// FaultOption configures when a FaultReader, FaultWriter or FaultWriterAt
+// injects an error. Several options may be combined; a call fails if any of
+// them says it should.
+type FaultOption func(fi *faultInjector)
+
+// FailOnCall makes the nth call (counting from 1) fail with err, without
+// reaching the underlying reader or writer. Calls before and after it are
+// unaffected.
+func FailOnCall(n int, err error) FaultOption {
+	return func(fi *faultInjector) {
+		fi.calls[n] = err
+	}
+}
+
+// FailAfterBytes lets n bytes through in total, then fails with err: the call
+// that reaches the limit transfers the bytes up to it and returns err, as does
+// every call after it.
+func FailAfterBytes(n int64, err error) FaultOption {
+	return func(fi *faultInjector) {
+		fi.limit, fi.limitErr = n, err
+	}
+}
+
+// FailOffsetRange makes a FaultWriterAt fail every WriteAt overlapping
+// [start, end) with err, without reaching the underlying io.WriterAt. It has no
+// effect on a FaultReader or FaultWriter.
+func FailOffsetRange(start, end int64, err error) FaultOption {
+	return func(fi *faultInjector) {
+		fi.ranges = append(fi.ranges, faultRange{start: start, end: end, err: err})
+	}
+}
+
+type faultRange struct {
+	start, end int64
+	err        error
+}
+
+// faultInjector decides which calls fail. It is shared by the Fault wrappers.
+type faultInjector struct {
+	m        sync.Mutex
+	n        int // Calls so far.
+	calls    map[int]error
+	bytes    int64
+	limit    int64
+	limitErr error
+	ranges   []faultRange
+}
+
+func newFaultInjector(opts []FaultOption) *faultInjector {
+	fi := &faultInjector{calls: make(map[int]error), limit: -1}
+
+	for _, opt := range opts {
+		opt(fi)
+	}
+
+	return fi
+}
+
+// begin counts a call to transfer n bytes at off (off is ignored if ranged is
+// false). It returns how many of the n bytes may be transferred, and the error
+// to fail with if that is fewer than n or the call should fail outright.
+func (fi *faultInjector) begin(n int, off int64, ranged bool) (int, error) {
+	fi.m.Lock()
+	defer fi.m.Unlock()
+
+	fi.n++
+
+	if err, ok := fi.calls[fi.n]; ok {
+		return 0, err
+	}
+
+	if ranged {
+		for _, r := range fi.ranges {
+			if off < r.end && off+int64(n) > r.start {
+				return 0, r.err
+			}
+		}
+	}
+
+	if fi.limit >= 0 {
+		if remaining := fi.limit - fi.bytes; int64(n) > remaining {
+			return int(remaining), fi.limitErr
+		}
+	}
+
+	return n, nil
+}
+
+// end records that n bytes were transferred.
+func (fi *faultInjector) end(n int) {
+	fi.m.Lock()
+	defer fi.m.Unlock()
+
+	fi.bytes += int64(n)
+}
+
+// do runs op on the allowed part of p, returning the injected error only if op
+// transferred everything it was allowed to without failing itself.
+func (fi *faultInjector) do(p []byte, off int64, ranged bool, op func(p []byte) (int, error)) (int, error) {
+	allowed, injected := fi.begin(len(p), off, ranged)
+	if allowed == 0 && injected != nil {
+		return 0, injected
+	}
+
+	n, err := op(p[:allowed])
+	fi.end(n)
+
+	if err == nil && n == allowed {
+		err = injected
+	}
+
+	return n, err
+}
+
+func (fi *faultInjector) count() int {
+	fi.m.Lock()
+	defer fi.m.Unlock()
+
+	return fi.n
+}
+
+// FaultReader wraps an io.Reader, injecting errors deterministically according
+// to its FaultOptions, to exercise the error handling of code reading from it.
+// It is safe for concurrent use if the underlying reader is.
+type FaultReader struct {
+	r  io.Reader
+	fi *faultInjector
+}
+
+var _ io.Reader = (*FaultReader)(nil)
+
+// NewFaultReader returns a FaultReader reading from r.
+func NewFaultReader(r io.Reader, opts ...FaultOption) *FaultReader {
+	return &FaultReader{r: r, fi: newFaultInjector(opts)}
+}
+
+// Read implements io.Reader for FaultReader.
+func (fr *FaultReader) Read(p []byte) (int, error) {
+	return fr.fi.do(p, 0, false, fr.r.Read)
+}
+
+// Calls returns the number of calls to Read so far, including failed ones.
+func (fr *FaultReader) Calls() int {
+	return fr.fi.count()
+}
+
+// FaultWriter wraps an io.Writer, injecting errors deterministically according
+// to its FaultOptions. It is safe for concurrent use if the underlying writer
+// is.
+type FaultWriter struct {
+	w  io.Writer
+	fi *faultInjector
+}
+
+var (
+	_ io.Writer = (*FaultWriter)(nil)
+	_ Flusher   = (*FaultWriter)(nil)
+)
+
+// NewFaultWriter returns a FaultWriter writing to w.
+func NewFaultWriter(w io.Writer, opts ...FaultOption) *FaultWriter {
+	return &FaultWriter{w: w, fi: newFaultInjector(opts)}
+}
+
+// Write implements io.Writer for FaultWriter.
+func (fw *FaultWriter) Write(p []byte) (int, error) {
+	return fw.fi.do(p, 0, false, fw.w.Write)
+}
+
+// Flush implements Flusher for FaultWriter by flushing the underlying writer.
+func (fw *FaultWriter) Flush() error {
+	return flushNext(fw.w)
+}
+
+// Calls returns the number of calls to Write so far, including failed ones.
+func (fw *FaultWriter) Calls() int {
+	return fw.fi.count()
+}
+
+// FaultWriterAt wraps an io.WriterAt, injecting errors deterministically
+// according to its FaultOptions, including FailOffsetRange. FailAfterBytes
+// counts bytes across all calls, whatever their offsets. It is safe for
+// concurrent use if the underlying io.WriterAt is.
+type FaultWriterAt struct {
+	w  io.WriterAt
+	fi *faultInjector
+}
+
+var _ io.WriterAt = (*FaultWriterAt)(nil)
+
+// NewFaultWriterAt returns a FaultWriterAt writing to w.
+func NewFaultWriterAt(w io.WriterAt, opts ...FaultOption) *FaultWriterAt {
+	return &FaultWriterAt{w: w, fi: newFaultInjector(opts)}
+}
+
+// WriteAt implements io.WriterAt for FaultWriterAt.
+func (fw *FaultWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return fw.fi.do(p, off, true, func(p []byte) (int, error) {
+		return fw.w.WriteAt(p, off)
+	})
+}
+
+// Calls returns the number of calls to WriteAt so far, including failed ones.
+func (fw *FaultWriterAt) Calls() int {
+	return fw.fi.count()
+}
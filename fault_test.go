@@ -0,0 +1,90 @@
+package miscio
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestFaultReader(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	fr := NewFaultReader(strings.NewReader("0123456789"), FailOnCall(2, errBoom))
+	buf := make([]byte, 3)
+
+	for i, expected := range []struct {
+		data string
+		err  error
+	}{
+		{"012", nil},
+		{"", errBoom},
+		{"345", nil},
+	} {
+		n, err := fr.Read(buf)
+		if string(buf[:n]) != expected.data || err != expected.err {
+			t.Errorf("call %d: expected (%q, %v), got (%q, %v)", i+1, expected.data, expected.err, buf[:n], err)
+		}
+	}
+
+	if fr.Calls() != 3 {
+		t.Errorf("expected 3 calls, got %d", fr.Calls())
+	}
+
+	got, err := ioutil.ReadAll(NewFaultReader(strings.NewReader("0123456789"), FailAfterBytes(4, errBoom)))
+	if string(got) != "0123" || err != errBoom {
+		t.Errorf("expected 4 bytes then errBoom, got (%q, %v)", got, err)
+	}
+}
+
+func TestFaultWriter(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	var buf bytes.Buffer
+
+	fw := NewFaultWriter(&buf, FailAfterBytes(5, errBoom))
+
+	if n, err := fw.Write([]byte("abc")); n != 3 || err != nil {
+		t.Errorf("Write got (%d, %v)", n, err)
+	}
+
+	if n, err := fw.Write([]byte("defg")); n != 2 || err != errBoom {
+		t.Errorf("expected a short write and errBoom at the limit, got (%d, %v)", n, err)
+	}
+
+	if n, err := fw.Write([]byte("h")); n != 0 || err != errBoom {
+		t.Errorf("expected writes past the limit to fail, got (%d, %v)", n, err)
+	}
+
+	if buf.String() != "abcde" {
+		t.Errorf("expected %q written, got %q", "abcde", buf.String())
+	}
+}
+
+func TestFaultWriterAt(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	ws := NewWriterAtReadSeeker(0)
+	fw := NewFaultWriterAt(ws, FailOffsetRange(10, 20, errBoom))
+
+	for _, tc := range []struct {
+		off int64
+		err error
+	}{
+		{0, nil},
+		{8, errBoom},
+		{15, errBoom},
+		{20, nil},
+	} {
+		if _, err := fw.WriteAt(make([]byte, 5), tc.off); err != tc.err {
+			t.Errorf("WriteAt at %d: expected %v, got %v", tc.off, tc.err, err)
+		}
+	}
+
+	ws.Close()
+
+	if _, err := ws.ReadAt(make([]byte, 1), 8); err != ErrNotWritten {
+		t.Errorf("expected the failed write not to reach the destination, got %v", err)
+	}
+}
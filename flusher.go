@@ -0,0 +1,39 @@
+package miscio
+
+// Flusher is implemented by writers that may hold data back, such as
+// *bufio.Writer, and by this package's writer wrappers. Flush writes out
+// whatever is held back, then flushes the next writer in the chain, so that
+// flushing the outermost writer of a composed chain flushes all of it.
+type Flusher interface {
+	Flush() error
+}
+
+// flushNext flushes w if it is a Flusher, or has an error-less Flush method
+// like http.Flusher, and does nothing otherwise.
+func flushNext(w interface{}) error {
+	switch f := w.(type) {
+	case Flusher:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+
+	return nil
+}
+
+// FlushAll flushes each of writers that is a Flusher (or has an error-less
+// Flush method, like http.Flusher), in order, e.g. at shutdown to write out
+// partial lines stranded in a set of unrelated writers. Writers without a
+// Flush method are skipped. Every writer is flushed even if an earlier one
+// fails; FlushAll returns the first error.
+func FlushAll(writers ...interface{}) error {
+	var first error
+
+	for _, w := range writers {
+		if err := flushNext(w); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
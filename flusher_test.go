@@ -0,0 +1,128 @@
+package miscio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestFlushPropagates(t *testing.T) {
+	var out bytes.Buffer
+
+	bw := bufio.NewWriter(&out)
+	rw := NewRedactingWriter(bw, "***", DefaultRedactMaxMatch, regexp.MustCompile(`secret`))
+	dw := NewDedupWriter(rw, DefaultRepeatFormat)
+	iw := NewIndentWriter(dw, "  ", 1)
+
+	fmt.Fprint(iw, "the secret is out\nthe secret is out\nand a partial line")
+
+	if out.Len() != 0 {
+		t.Fatalf("expected everything to be held back before Flush, got %q", out.String())
+	}
+
+	if err := iw.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	expected := "  the *** is out\n" + fmt.Sprintf(DefaultRepeatFormat, 1) + "  and a partial line"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestFlushPropagatesThroughWrappers(t *testing.T) {
+	wrappers := map[string]func(w io.Writer) io.Writer{
+		"RateLimitedWriter": func(w io.Writer) io.Writer {
+			return NewRateLimitedWriter(context.Background(), w, NewRateLimiter(1<<20, 1<<20))
+		},
+		"TimeoutWriter":           func(w io.Writer) io.Writer { return NewTimeoutWriter(w, time.Minute) },
+		"ReportingWriter":         func(w io.Writer) io.Writer { return NewReportingWriter(w, time.Minute) },
+		"MeteredWriter":           func(w io.Writer) io.Writer { return NewMeteredWriter(w, 0) },
+		"NormalizeNewlinesWriter": NormalizeNewlinesWriter,
+		"FaultWriter":             func(w io.Writer) io.Writer { return NewFaultWriter(w) },
+		"RecordingWriter":         func(w io.Writer) io.Writer { return NewRecordingWriter(w) },
+		"ShortWriter":             func(w io.Writer) io.Writer { return NewShortWriter(w, ShortByMax(64)) },
+		"SlowWriter": func(w io.Writer) io.Writer {
+			return NewSlowWriter(context.Background(), w, Latency{})
+		},
+		"ContextWriter": func(w io.Writer) io.Writer { return ContextWriter(context.Background(), w) },
+	}
+
+	for name, wrap := range wrappers {
+		var out bytes.Buffer
+
+		bw := bufio.NewWriter(&out)
+		pw := NewPrefixWriter(wrap(bw), "> ")
+
+		fmt.Fprint(pw, "held back\n")
+
+		if out.Len() != 0 {
+			t.Fatalf("%s: expected the bufio.Writer to hold everything back, got %q", name, out.String())
+		}
+
+		if err := pw.Flush(); err != nil {
+			t.Fatalf("%s: Flush failed: %v", name, err)
+		}
+
+		if out.String() != "> held back\n" {
+			t.Errorf("%s: expected Flush to reach the bufio.Writer, got %q", name, out.String())
+		}
+	}
+}
+
+func TestFlushPropagatesThroughSectionWriter(t *testing.T) {
+	dw := NewDryRunWriter(true)
+	sw := NewSectionWriter(NewBufferedWriterAt(dw, 1<<20), 4, 8)
+
+	sw.Write([]byte("abc"))
+
+	if plan := dw.Plan(); len(plan) != 0 {
+		t.Fatalf("expected the write to be buffered, got %v", plan)
+	}
+
+	if err := sw.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	plan := dw.Plan()
+	if len(plan) != 2 || plan[0].Op != PlanWriteAt || plan[0].Offset != 4 || plan[1].Op != PlanFlush {
+		t.Errorf("expected a WriteAt at 4 then a flush, got %v", plan)
+	}
+}
+
+// noErrFlusher has a Flush method like http.Flusher's.
+type noErrFlusher struct{ flushed int }
+
+func (f *noErrFlusher) Write(p []byte) (int, error) { return len(p), nil }
+func (f *noErrFlusher) Flush()                      { f.flushed++ }
+
+func TestFlushAll(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	nf := &noErrFlusher{}
+	sw := NewStickyErrorWriter(failingWriter{errBoom})
+	sw.Write([]byte("x"))
+
+	var out bytes.Buffer
+
+	bw := bufio.NewWriter(&out)
+	bw.WriteString("buffered")
+
+	if err := FlushAll(nf, sw, &out, bw); err != errBoom {
+		t.Errorf("expected the first error, got %v", err)
+	}
+
+	if nf.flushed != 1 {
+		t.Errorf("expected an error-less Flush to be called once, got %d", nf.flushed)
+	}
+
+	if out.String() != "buffered" {
+		t.Errorf("expected later writers to be flushed despite the error, got %q", out.String())
+	}
+}
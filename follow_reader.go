@@ -0,0 +1,234 @@
+package miscio
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultFollowPollInterval is the poll interval used by a FollowReader whose
+// PollInterval is not set.
+const DefaultFollowPollInterval = 250 * time.Millisecond
+
+// RotationReason describes why a FollowReader switched to a new file.
+type RotationReason int
+
+const (
+	// RotationTruncated means the followed file shrank below the current read
+	// offset (e.g. logrotate's copytruncate), and reading restarted at offset 0.
+	RotationTruncated RotationReason = iota
+	// RotationReplaced means the path now refers to a different file than the
+	// one being read (e.g. the old file was renamed and a new one created).
+	RotationReplaced
+)
+
+// String implements fmt.Stringer for RotationReason.
+func (r RotationReason) String() string {
+	switch r {
+	case RotationTruncated:
+		return "truncated"
+	case RotationReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+// RotationEvent is passed to a FollowReader's OnRotate callback whenever the
+// reader detects that the followed file was rotated.
+type RotationEvent struct {
+	Path   string
+	Reason RotationReason
+	// Offset is the read offset in the previous file at the time of rotation.
+	Offset int64
+}
+
+// FollowReader implements io.ReadCloser over a file that is still being
+// appended to, like `tail -F`. When Read reaches the end of the file it polls
+// for new data instead of returning io.EOF, and it survives log rotation: the
+// current file is always drained before switching, so lines are neither
+// missed nor duplicated.
+//
+// Read is not safe for concurrent use, but Close may be called from another
+// goroutine to unblock a pending Read, which then returns io.EOF.
+type FollowReader struct {
+	path   string
+	m      sync.Mutex
+	f      *os.File
+	offset int64
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	leak      *leakTracker
+
+	// PollInterval is how long Read waits between checks for new data. If
+	// zero, DefaultFollowPollInterval is used.
+	PollInterval time.Duration
+	// OnRotate, if set, is called from Read each time a rotation is detected.
+	OnRotate func(RotationEvent)
+}
+
+var _ io.ReadCloser = (*FollowReader)(nil)
+
+// NewFollowReader opens path and returns a FollowReader positioned at the
+// start of the file.
+func NewFollowReader(path string) (*FollowReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FollowReader{
+		path:   path,
+		f:      f,
+		closed: make(chan struct{}),
+		leak:   trackLeak("*miscio.FollowReader"),
+	}, nil
+}
+
+// Read implements io.Reader for FollowReader. It blocks until at least one
+// byte is available, an error occurs, or Close is called.
+func (fr *FollowReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for {
+		n, err := fr.readOnce(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+
+		interval := fr.PollInterval
+		if interval <= 0 {
+			interval = DefaultFollowPollInterval
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-fr.closed:
+			timer.Stop()
+
+			return 0, io.EOF
+		case <-timer.C:
+		}
+	}
+}
+
+// readOnce attempts a single read, checking for rotation if the current file
+// is exhausted. It returns (0, nil) when the caller should wait and retry.
+func (fr *FollowReader) readOnce(p []byte) (int, error) {
+	fr.m.Lock()
+	defer fr.m.Unlock()
+
+	select {
+	case <-fr.closed:
+		return 0, io.EOF
+	default:
+	}
+
+	n, err := fr.f.Read(p)
+	fr.offset += int64(n)
+
+	if n > 0 {
+		return n, nil
+	}
+
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	event, rotated, err := fr.checkRotation()
+	if err != nil {
+		return 0, err
+	}
+
+	if rotated && fr.OnRotate != nil {
+		fr.OnRotate(event)
+	}
+
+	return 0, nil
+}
+
+// checkRotation must be called with fr.m held, and only once the current file
+// has been read to EOF.
+func (fr *FollowReader) checkRotation() (RotationEvent, bool, error) {
+	event := RotationEvent{Path: fr.path, Offset: fr.offset}
+
+	current, err := fr.f.Stat()
+	if err != nil {
+		return event, false, err
+	}
+
+	latest, err := os.Stat(fr.path)
+	if err != nil {
+		// The path may briefly not exist between a rename and the creation of
+		// the replacement file; keep the current file until it reappears.
+		if os.IsNotExist(err) {
+			return event, false, nil
+		}
+
+		return event, false, err
+	}
+
+	if !os.SameFile(current, latest) {
+		// Lines may have been appended to the old file between the read that
+		// hit EOF and the rename. Stat it again, now that the rename has been
+		// seen, and leave them for the next Read to drain before switching.
+		if current, err = fr.f.Stat(); err != nil {
+			return event, false, err
+		}
+
+		if current.Size() > fr.offset {
+			return event, false, nil
+		}
+
+		f, err := os.Open(fr.path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return event, false, nil
+			}
+
+			return event, false, err
+		}
+
+		fr.f.Close()
+		fr.f = f
+		fr.offset = 0
+		event.Reason = RotationReplaced
+
+		return event, true, nil
+	}
+
+	if current.Size() < fr.offset {
+		if _, err := fr.f.Seek(0, io.SeekStart); err != nil {
+			return event, false, err
+		}
+
+		fr.offset = 0
+		event.Reason = RotationTruncated
+
+		return event, true, nil
+	}
+
+	return event, false, nil
+}
+
+// Close stops following the file and closes it. Pending and subsequent calls
+// to Read return io.EOF.
+func (fr *FollowReader) Close() error {
+	var err error
+
+	fr.closeOnce.Do(func() {
+		close(fr.closed)
+		fr.leak.markClosed()
+
+		fr.m.Lock()
+		defer fr.m.Unlock()
+
+		err = fr.f.Close()
+	})
+
+	return err
+}
@@ -0,0 +1,167 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFollowed(t *testing.T, fr *FollowReader, want string) {
+	t.Helper()
+
+	buf := make([]byte, len(want))
+	got := 0
+
+	for got < len(want) {
+		n, err := fr.Read(buf[got:])
+		if err != nil {
+			t.Fatalf("Read failed with %s after %d bytes", err, got)
+		}
+
+		got += n
+	}
+
+	if string(buf) != want {
+		t.Errorf("Read mismatch, have %q want %q", buf, want)
+	}
+}
+
+func TestFollowReaderRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := ioutil.WriteFile(path, []byte("one\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fr, err := NewFollowReader(path)
+	if err != nil {
+		t.Fatalf("NewFollowReader failed with %s", err)
+	}
+	defer fr.Close()
+
+	fr.PollInterval = time.Millisecond
+
+	var events []RotationEvent
+	fr.OnRotate = func(e RotationEvent) { events = append(events, e) }
+
+	readFollowed(t, fr, "one\n")
+
+	// Data appended to the old file before rotation must still be read.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString("two\n")
+	f.Close()
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("three\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	readFollowed(t, fr, "two\nthree\n")
+
+	if len(events) != 1 || events[0].Reason != RotationReplaced {
+		t.Errorf("expected one replaced rotation event, got %v", events)
+	}
+}
+
+func TestFollowReaderRotationAfterEOF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := ioutil.WriteFile(path, []byte("one\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fr, err := NewFollowReader(path)
+	if err != nil {
+		t.Fatalf("NewFollowReader failed with %s", err)
+	}
+	defer fr.Close()
+
+	fr.PollInterval = time.Millisecond
+
+	readFollowed(t, fr, "one\n")
+
+	// Simulate a line appended, and the file rotated, after a Read hit EOF but
+	// before it checked for rotation.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString("two\n")
+	f.Close()
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("three\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fr.m.Lock()
+	_, rotated, err := fr.checkRotation()
+	fr.m.Unlock()
+
+	if rotated || err != nil {
+		t.Fatalf("expected the old file to be drained before switching, got %v, %v", rotated, err)
+	}
+
+	readFollowed(t, fr, "two\nthree\n")
+}
+
+func TestFollowReaderTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := ioutil.WriteFile(path, []byte("hello world\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fr, err := NewFollowReader(path)
+	if err != nil {
+		t.Fatalf("NewFollowReader failed with %s", err)
+	}
+	defer fr.Close()
+
+	fr.PollInterval = time.Millisecond
+
+	var events []RotationEvent
+	fr.OnRotate = func(e RotationEvent) { events = append(events, e) }
+
+	readFollowed(t, fr, "hello world\n")
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString("hi\n")
+	f.Close()
+
+	readFollowed(t, fr, "hi\n")
+
+	if len(events) != 1 || events[0].Reason != RotationTruncated || events[0].Offset != 12 {
+		t.Errorf("expected one truncation event at offset 12, got %v", events)
+	}
+}
+
+func TestFollowReaderClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := ioutil.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fr, err := NewFollowReader(path)
+	if err != nil {
+		t.Fatalf("NewFollowReader failed with %s", err)
+	}
+
+	fr.PollInterval = time.Millisecond
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		fr.Close()
+	}()
+
+	if _, err := fr.Read(make([]byte, 8)); err != io.EOF {
+		t.Errorf("expected io.EOF after Close, got %v", err)
+	}
+}
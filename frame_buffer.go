@@ -0,0 +1,252 @@
+package miscio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// frameHeaderSize is the size of the big-endian length prefix FrameBuffer's
+// Write expects before each frame.
+const frameHeaderSize = 4
+
+// FrameBuffer is the binary sibling of RollingLineBuffer: it stores the N most
+// recent frames written to it, where a frame is an opaque byte slice that may
+// contain any bytes, including '\n'. Frames are added either whole, with
+// WriteFrame, or through Write as a stream of frames each preceded by its
+// length as a 4-byte big-endian integer. Reads return whole frames only, one
+// per call, and are done forward-only; unread frames that are evicted are
+// skipped.
+//
+// All methods are safe for concurrent use.
+type FrameBuffer struct {
+	m        sync.RWMutex
+	frames   [][]byte
+	capacity int
+	readpos  int
+	closed   bool
+
+	maxFrameSize int
+	partial      []byte // The incomplete tail of the stream given to Write.
+	werr         error  // Sticky error from Write, once the stream is corrupt.
+	dropped      uint64
+}
+
+var (
+	_ io.ReadCloser = (*FrameBuffer)(nil)
+	_ io.Writer     = (*FrameBuffer)(nil)
+)
+
+// FrameBufferOption configures a FrameBuffer at construction.
+type FrameBufferOption func(fb *FrameBuffer)
+
+// WithMaxFrameSize caps the size of a frame at n bytes. A larger frame passed
+// to WriteFrame is rejected; a larger length prefix seen by Write means the
+// stream is corrupt, and fails that Write and every later one. By default
+// frames may be any size.
+func WithMaxFrameSize(n int) FrameBufferOption {
+	return func(fb *FrameBuffer) {
+		fb.maxFrameSize = n
+	}
+}
+
+// NewFrameBuffer returns a new FrameBuffer that holds the `capacity` most
+// recently-written frames.
+func NewFrameBuffer(capacity int, opts ...FrameBufferOption) *FrameBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	fb := &FrameBuffer{
+		frames:   make([][]byte, 0, capacity),
+		capacity: capacity,
+	}
+
+	for _, opt := range opts {
+		opt(fb)
+	}
+
+	return fb
+}
+
+// WriteFrame adds a copy of frame to the buffer as a single frame, evicting the
+// oldest frame if the buffer is full. It returns os.ErrClosed after Close.
+func (fb *FrameBuffer) WriteFrame(frame []byte) error {
+	if err := fb.checkSize(len(frame)); err != nil {
+		return err
+	}
+
+	frame = append([]byte(nil), frame...)
+
+	fb.m.Lock()
+	defer fb.m.Unlock()
+
+	if fb.closed {
+		return os.ErrClosed
+	}
+
+	fb.push(frame)
+
+	return nil
+}
+
+// Write implements io.Writer for FrameBuffer. p is part of a stream of
+// length-prefixed frames: each frame is preceded by its length as a 4-byte
+// big-endian integer. Frames may be split across any number of Writes; each is
+// added to the buffer once it is complete. Write returns os.ErrClosed after
+// Close.
+func (fb *FrameBuffer) Write(p []byte) (int, error) {
+	fb.m.Lock()
+	defer fb.m.Unlock()
+
+	switch {
+	case fb.closed:
+		return 0, os.ErrClosed
+	case fb.werr != nil:
+		return 0, fb.werr
+	}
+
+	fb.partial = append(fb.partial, p...)
+	stream := fb.partial
+
+	for len(stream) >= frameHeaderSize {
+		size := binary.BigEndian.Uint32(stream)
+		if err := fb.checkSize(int(size)); err != nil {
+			fb.werr = err
+			fb.partial = nil
+
+			return 0, err
+		}
+
+		if len(stream) < frameHeaderSize+int(size) {
+			break
+		}
+
+		fb.push(append([]byte(nil), stream[frameHeaderSize:frameHeaderSize+int(size)]...))
+		stream = stream[frameHeaderSize+int(size):]
+	}
+
+	fb.partial = append(fb.partial[:0], stream...)
+
+	return len(p), nil
+}
+
+// checkSize returns an error if a frame of n bytes exceeds the maximum size.
+func (fb *FrameBuffer) checkSize(n int) error {
+	if fb.maxFrameSize > 0 && n > fb.maxFrameSize {
+		return fmt.Errorf("%w: %d bytes exceeds maximum of %d", ErrFrameTooLarge, n, fb.maxFrameSize)
+	}
+
+	return nil
+}
+
+// push appends frame, evicting the oldest frame if the buffer is full. It must
+// be called with fb.m held.
+func (fb *FrameBuffer) push(frame []byte) {
+	if len(fb.frames) == fb.capacity {
+		fb.frames[0] = nil
+		fb.frames = fb.frames[1:]
+		fb.dropped++
+
+		if fb.readpos > 0 {
+			fb.readpos--
+		}
+	}
+
+	fb.frames = append(fb.frames, frame)
+}
+
+// ReadFrame returns the next unread frame. The frame is not copied, and must
+// not be modified. When no unread frames remain, ReadFrame returns (nil, nil),
+// or io.EOF once the buffer is closed.
+func (fb *FrameBuffer) ReadFrame() ([]byte, error) {
+	fb.m.Lock()
+	defer fb.m.Unlock()
+
+	if fb.readpos >= len(fb.frames) {
+		if fb.closed {
+			return nil, io.EOF
+		}
+
+		return nil, nil
+	}
+
+	frame := fb.frames[fb.readpos]
+	fb.readpos++
+
+	return frame, nil
+}
+
+// Read implements io.Reader for FrameBuffer. Each Read copies exactly one
+// frame into p, so frame boundaries are preserved. If p is too small to hold
+// the next frame, Read returns ErrShortBuffer to signal to the caller they need
+// a bigger buffer, and the frame remains unread. When no unread frames remain,
+// Read returns (0, nil), or io.EOF once the buffer is closed.
+func (fb *FrameBuffer) Read(p []byte) (int, error) {
+	fb.m.Lock()
+	defer fb.m.Unlock()
+
+	if fb.readpos >= len(fb.frames) {
+		if fb.closed {
+			return 0, io.EOF
+		}
+
+		return 0, nil
+	}
+
+	frame := fb.frames[fb.readpos]
+	if len(frame) > len(p) {
+		return 0, NewErrShortBuffer(len(frame))
+	}
+
+	fb.readpos++
+
+	return copy(p, frame), nil
+}
+
+// Close closes the buffer for writing. Frames already written remain readable;
+// once they are drained, Read returns io.EOF. An incomplete frame passed to
+// Write is discarded. Subsequent writes return os.ErrClosed.
+func (fb *FrameBuffer) Close() error {
+	fb.m.Lock()
+	defer fb.m.Unlock()
+
+	fb.closed = true
+	fb.partial = nil
+
+	return nil
+}
+
+// Len returns the number of frames currently retained by the buffer.
+func (fb *FrameBuffer) Len() int {
+	fb.m.RLock()
+	defer fb.m.RUnlock()
+
+	return len(fb.frames)
+}
+
+// Dropped returns the total number of frames evicted to make room for newer
+// ones, whether or not they had been read.
+func (fb *FrameBuffer) Dropped() uint64 {
+	fb.m.RLock()
+	defer fb.m.RUnlock()
+
+	return fb.dropped
+}
+
+// Snapshot returns a copy of every frame currently retained by the buffer,
+// oldest first, regardless of how much has been consumed by Read. It does not
+// affect the read position.
+func (fb *FrameBuffer) Snapshot() [][]byte {
+	fb.m.RLock()
+	defer fb.m.RUnlock()
+
+	frames := make([][]byte, len(fb.frames))
+	for i, frame := range fb.frames {
+		frames[i] = append([]byte(nil), frame...)
+	}
+
+	return frames
+}
@@ -0,0 +1,117 @@
+package miscio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func lengthPrefixed(frames ...string) []byte {
+	var stream []byte
+
+	for _, frame := range frames {
+		var header [4]byte
+		binary.BigEndian.PutUint32(header[:], uint32(len(frame)))
+		stream = append(append(stream, header[:]...), frame...)
+	}
+
+	return stream
+}
+
+func TestFrameBufferWrite(t *testing.T) {
+	fb := NewFrameBuffer(3)
+	stream := lengthPrefixed("a\nb", "", "\x00\n\xff", "last")
+
+	// Split the stream mid-header and mid-frame.
+	for _, chunk := range [][]byte{stream[:2], stream[2:6], stream[6:20], stream[20:]} {
+		if n, err := fb.Write(chunk); n != len(chunk) || err != nil {
+			t.Fatalf("Write returned (%d, %v)", n, err)
+		}
+	}
+
+	var got []string
+	for _, frame := range fb.Snapshot() {
+		got = append(got, string(frame))
+	}
+
+	if expected := []string{"", "\x00\n\xff", "last"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	if fb.Dropped() != 1 {
+		t.Errorf("expected 1 dropped frame, got %d", fb.Dropped())
+	}
+}
+
+func TestFrameBufferRead(t *testing.T) {
+	fb := NewFrameBuffer(10)
+	fb.WriteFrame([]byte("hello\nworld"))
+	fb.WriteFrame([]byte("bye"))
+
+	buf := make([]byte, 4)
+
+	var sb *ErrShortBuffer
+	if n, err := fb.Read(buf); n != 0 || !errors.As(err, &sb) || sb.SizeNeeded() != 11 {
+		t.Fatalf("expected ErrShortBuffer for 11 bytes, got (%d, %v)", n, err)
+	}
+
+	buf = make([]byte, 32)
+
+	if n, err := fb.Read(buf); string(buf[:n]) != "hello\nworld" || err != nil {
+		t.Fatalf("expected the first frame alone, got (%q, %v)", buf[:n], err)
+	}
+
+	if frame, err := fb.ReadFrame(); string(frame) != "bye" || err != nil {
+		t.Fatalf("expected (bye, nil), got (%q, %v)", frame, err)
+	}
+
+	if n, err := fb.Read(buf); n != 0 || err != nil {
+		t.Fatalf("expected (0, nil) when drained, got (%d, %v)", n, err)
+	}
+
+	fb.Close()
+
+	if _, err := fb.ReadFrame(); err != io.EOF {
+		t.Errorf("expected io.EOF after Close, got %v", err)
+	}
+
+	if err := fb.WriteFrame(nil); err != os.ErrClosed {
+		t.Errorf("expected os.ErrClosed, got %v", err)
+	}
+}
+
+func TestFrameBufferEvictUnread(t *testing.T) {
+	fb := NewFrameBuffer(2)
+	fb.WriteFrame([]byte("1"))
+	fb.ReadFrame()
+	fb.WriteFrame([]byte("2"))
+	fb.WriteFrame([]byte("3"))
+	fb.WriteFrame([]byte("4"))
+
+	if frame, _ := fb.ReadFrame(); string(frame) != "3" {
+		t.Errorf("expected reading to resume at the oldest retained frame, got %q", frame)
+	}
+}
+
+func TestFrameBufferMaxFrameSize(t *testing.T) {
+	fb := NewFrameBuffer(2, WithMaxFrameSize(4))
+
+	if err := fb.WriteFrame([]byte("12345")); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge from WriteFrame, got %v", err)
+	}
+
+	if _, err := fb.Write(lengthPrefixed("ok", "too long")); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge from Write, got %v", err)
+	}
+
+	if _, err := fb.Write(lengthPrefixed("ok")); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected the error to be sticky, got %v", err)
+	}
+
+	if got := fb.Snapshot(); !reflect.DeepEqual(got, [][]byte{[]byte("ok")}) {
+		t.Errorf("expected the frame before the corruption to be kept, got %q", got)
+	}
+}
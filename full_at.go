@@ -0,0 +1,72 @@
+package miscio
+
+import "io"
+
+// maxConsecutiveEmptyAt bounds how many calls in a row ReadFullAt and
+// WriteFullAt tolerate that make no progress without returning an error.
+const maxConsecutiveEmptyAt = 100
+
+// ReadFullAt reads exactly len(p) bytes from r at off into p, the io.ReaderAt
+// counterpart of io.ReadFull. Many io.ReaderAt implementations in the wild
+// return short reads despite the interface's contract; ReadFullAt keeps
+// reading from where the previous call left off until p is full.
+//
+// It returns the number of bytes read, and an error if fewer than len(p) bytes
+// were read: io.EOF if no bytes could be read at all, io.ErrUnexpectedEOF if
+// the end of r was reached part way, io.ErrNoProgress if r repeatedly returned
+// no data and no error, or the error returned by r. If len(p) bytes were read,
+// the error is nil, even if r returned io.EOF with them.
+func ReadFullAt(r io.ReaderAt, p []byte, off int64) (int, error) {
+	n, empty := 0, 0
+
+	for n < len(p) {
+		m, err := r.ReadAt(p[n:], off+int64(n))
+		n += m
+
+		switch {
+		case n == len(p):
+			return n, nil
+		case err == io.EOF && n > 0:
+			return n, io.ErrUnexpectedEOF
+		case err != nil:
+			return n, err
+		case m > 0:
+			empty = 0
+		default:
+			empty++
+			if empty >= maxConsecutiveEmptyAt {
+				return n, io.ErrNoProgress
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// WriteFullAt writes all of p to w at off, the io.WriterAt counterpart of
+// io.Writer's promise to write everything or fail. Like ReadFullAt, it keeps
+// writing from where a short WriteAt left off. It returns the number of bytes
+// written, and the error from w if it stopped early, or io.ErrShortWrite if w
+// repeatedly wrote nothing without an error.
+func WriteFullAt(w io.WriterAt, p []byte, off int64) (int, error) {
+	n, empty := 0, 0
+
+	for n < len(p) {
+		m, err := w.WriteAt(p[n:], off+int64(n))
+		n += m
+
+		switch {
+		case err != nil:
+			return n, err
+		case m > 0:
+			empty = 0
+		default:
+			empty++
+			if empty >= maxConsecutiveEmptyAt {
+				return n, io.ErrShortWrite
+			}
+		}
+	}
+
+	return n, nil
+}
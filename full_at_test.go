@@ -0,0 +1,59 @@
+package miscio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadFullAt(t *testing.T) {
+	sr := NewShortReaderAt(strings.NewReader("0123456789"), ShortByMax(3))
+
+	buf := make([]byte, 7)
+	if n, err := ReadFullAt(sr, buf, 2); n != 7 || err != nil || string(buf) != "2345678" {
+		t.Errorf("expected a full read despite short ReadAts, got (%q, %v)", buf[:n], err)
+	}
+
+	if n, err := ReadFullAt(sr, buf, 6); n != 4 || err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF reading past the end, got (%d, %v)", n, err)
+	}
+
+	if n, err := ReadFullAt(sr, buf, 10); n != 0 || err != io.EOF {
+		t.Errorf("expected io.EOF at the end, got (%d, %v)", n, err)
+	}
+
+	// Ending exactly at the end of r, with io.EOF, is a full read.
+	if n, err := ReadFullAt(strings.NewReader("0123456789"), buf[:3], 7); n != 3 || err != nil {
+		t.Errorf("expected a full read at the end, got (%d, %v)", n, err)
+	}
+
+	stuck := NewShortReaderAt(strings.NewReader("0123"), ShortByMax(0))
+	if _, err := ReadFullAt(stuck, buf, 0); err != io.ErrNoProgress {
+		t.Errorf("expected io.ErrNoProgress, got %v", err)
+	}
+}
+
+func TestWriteFullAt(t *testing.T) {
+	ws := NewWriterAtReadSeeker(0)
+	short := WriterAtFunc(func(p []byte, off int64) (int, error) {
+		if len(p) > 2 {
+			p = p[:2]
+		}
+
+		return ws.WriteAt(p, off)
+	})
+
+	if n, err := WriteFullAt(short, []byte("abcde"), 3); n != 5 || err != nil {
+		t.Errorf("WriteFullAt got (%d, %v)", n, err)
+	}
+
+	buf := make([]byte, 5)
+	if _, err := ws.ReadAt(buf, 3); err != nil || string(buf) != "abcde" {
+		t.Errorf("expected %q written at 3, got (%q, %v)", "abcde", buf, err)
+	}
+
+	stuck := WriterAtFunc(func(p []byte, off int64) (int, error) { return 0, nil })
+	if _, err := WriteFullAt(stuck, buf, 0); err != io.ErrShortWrite {
+		t.Errorf("expected io.ErrShortWrite, got %v", err)
+	}
+}
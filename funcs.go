@@ -0,0 +1,41 @@
+package miscio
+
+import "io"
+
+// ReaderFunc adapts an ordinary function to io.Reader, in the manner of
+// http.HandlerFunc, so that one-off readers can be written inline.
+type ReaderFunc func(p []byte) (int, error)
+
+// Read implements io.Reader for ReaderFunc by calling f(p).
+func (f ReaderFunc) Read(p []byte) (int, error) { return f(p) }
+
+// WriterFunc adapts an ordinary function to io.Writer.
+type WriterFunc func(p []byte) (int, error)
+
+// Write implements io.Writer for WriterFunc by calling f(p).
+func (f WriterFunc) Write(p []byte) (int, error) { return f(p) }
+
+// WriterAtFunc adapts an ordinary function to io.WriterAt.
+type WriterAtFunc func(p []byte, off int64) (int, error)
+
+// WriteAt implements io.WriterAt for WriterAtFunc by calling f(p, off).
+func (f WriterAtFunc) WriteAt(p []byte, off int64) (int, error) { return f(p, off) }
+
+// CloserFunc adapts an ordinary function to io.Closer, e.g. to pair a reader
+// with custom cleanup:
+//
+//	rc := struct {
+//		io.Reader
+//		io.Closer
+//	}{r, miscio.CloserFunc(cleanup)}
+type CloserFunc func() error
+
+// Close implements io.Closer for CloserFunc by calling f().
+func (f CloserFunc) Close() error { return f() }
+
+var (
+	_ io.Reader   = ReaderFunc(nil)
+	_ io.Writer   = WriterFunc(nil)
+	_ io.WriterAt = WriterAtFunc(nil)
+	_ io.Closer   = CloserFunc(nil)
+)
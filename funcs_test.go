@@ -0,0 +1,63 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestFuncAdapters(t *testing.T) {
+	src := strings.NewReader("inline")
+	calls := 0
+
+	r := ReaderFunc(func(p []byte) (int, error) {
+		calls++
+
+		return src.Read(p)
+	})
+
+	var got []byte
+
+	w := WriterFunc(func(p []byte) (int, error) {
+		got = append(got, p...)
+
+		return len(p), nil
+	})
+
+	if _, err := io.Copy(w, r); err != nil || string(got) != "inline" || calls == 0 {
+		t.Errorf("Copy got %q, %v after %d reads", got, err, calls)
+	}
+
+	var offsets []int64
+
+	wa := WriterAtFunc(func(p []byte, off int64) (int, error) {
+		offsets = append(offsets, off)
+
+		return len(p), nil
+	})
+	NewSectionWriter(wa, 10, 4).Write([]byte("ab"))
+
+	if len(offsets) != 1 || offsets[0] != 10 {
+		t.Errorf("expected a WriteAt at offset 10, got %v", offsets)
+	}
+
+	closed := false
+	cleanup := CloserFunc(func() error {
+		closed = true
+
+		return nil
+	})
+
+	rc := struct {
+		io.Reader
+		io.Closer
+	}{strings.NewReader(""), cleanup}
+
+	ioutil.ReadAll(rc)
+	rc.Close()
+
+	if !closed {
+		t.Error("expected CloserFunc to be called")
+	}
+}
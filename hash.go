@@ -0,0 +1,162 @@
+package miscio
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+)
+
+// HashReader wraps an io.Reader, feeding every byte read through it into a
+// hash.Hash, so a digest can be verified once the stream has been consumed.
+type HashReader struct {
+	r io.Reader
+	h hash.Hash
+}
+
+var _ io.Reader = (*HashReader)(nil)
+
+// NewHashReader returns a HashReader reading from r and hashing with h.
+func NewHashReader(r io.Reader, h hash.Hash) *HashReader {
+	return &HashReader{r: r, h: h}
+}
+
+// Read implements io.Reader for HashReader.
+func (hr *HashReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	hr.h.Write(p[:n])
+
+	return n, err
+}
+
+// Sum returns the hash of everything read so far.
+func (hr *HashReader) Sum() []byte {
+	return hr.h.Sum(nil)
+}
+
+// HashWriter wraps an io.Writer, feeding every byte successfully written
+// through it into a hash.Hash.
+type HashWriter struct {
+	w io.Writer
+	h hash.Hash
+}
+
+var (
+	_ io.Writer = (*HashWriter)(nil)
+	_ Flusher   = (*HashWriter)(nil)
+)
+
+// NewHashWriter returns a HashWriter writing to w and hashing with h.
+func NewHashWriter(w io.Writer, h hash.Hash) *HashWriter {
+	return &HashWriter{w: w, h: h}
+}
+
+// Write implements io.Writer for HashWriter.
+func (hw *HashWriter) Write(p []byte) (int, error) {
+	n, err := hw.w.Write(p)
+	hw.h.Write(p[:n])
+
+	return n, err
+}
+
+// Flush implements Flusher for HashWriter by flushing the underlying writer.
+func (hw *HashWriter) Flush() error {
+	return flushNext(hw.w)
+}
+
+// Sum returns the hash of everything written so far.
+func (hw *HashWriter) Sum() []byte {
+	return hw.h.Sum(nil)
+}
+
+// HashWriterAt wraps an io.WriterAt, hashing the data written through it in
+// stream order even when it is written out of order, as by a parallel
+// download. Writes pass straight through to the underlying io.WriterAt; a copy
+// of any data written ahead of the contiguous prefix is held in memory until
+// the gap before it is filled, and then hashed.
+//
+// Bytes are hashed once, the first time they become part of the contiguous
+// prefix, so the digest does not reflect later overwrites of that prefix. All
+// methods are safe for concurrent use.
+type HashWriterAt struct {
+	m      sync.Mutex
+	w      io.WriterAt
+	h      hash.Hash
+	hashed int64
+	chunks []bufChunk // Written past hashed, in ascending order.
+}
+
+var _ io.WriterAt = (*HashWriterAt)(nil)
+
+// NewHashWriterAt returns a HashWriterAt writing to w and hashing with h.
+func NewHashWriterAt(w io.WriterAt, h hash.Hash) *HashWriterAt {
+	return &HashWriterAt{w: w, h: h}
+}
+
+// WriteAt implements io.WriterAt for HashWriterAt. Only the bytes the
+// underlying io.WriterAt reports as written are hashed.
+func (hw *HashWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrNegativeOffset, off)
+	}
+
+	n, err := hw.w.WriteAt(p, off)
+
+	hw.m.Lock()
+	defer hw.m.Unlock()
+
+	written := p[:n]
+	if skip := hw.hashed - off; skip > 0 {
+		if skip >= int64(len(written)) {
+			return n, err
+		}
+
+		written, off = written[skip:], hw.hashed
+	}
+
+	hw.chunks = insertChunk(hw.chunks, written, off)
+
+	for len(hw.chunks) > 0 && hw.chunks[0].off <= hw.hashed {
+		c := hw.chunks[0]
+		if c.end() > hw.hashed {
+			hw.h.Write(c.data[hw.hashed-c.off:])
+			hw.hashed = c.end()
+		}
+
+		hw.chunks = hw.chunks[1:]
+	}
+
+	return n, err
+}
+
+// Sum returns the hash of the contiguous prefix written so far, i.e. of the
+// first Hashed bytes.
+func (hw *HashWriterAt) Sum() []byte {
+	hw.m.Lock()
+	defer hw.m.Unlock()
+
+	return hw.h.Sum(nil)
+}
+
+// Hashed returns the length of the contiguous prefix hashed so far. Once every
+// byte of the stream has been written, it equals the stream's size.
+func (hw *HashWriterAt) Hashed() int64 {
+	hw.m.Lock()
+	defer hw.m.Unlock()
+
+	return hw.hashed
+}
+
+// Pending returns the number of bytes held in memory, written beyond a gap in
+// the stream.
+func (hw *HashWriterAt) Pending() int {
+	hw.m.Lock()
+	defer hw.m.Unlock()
+
+	pending := 0
+	for _, c := range hw.chunks {
+		pending += len(c.data)
+	}
+
+	return pending
+}
@@ -0,0 +1,79 @@
+package miscio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestHashReaderWriter(t *testing.T) {
+	const data = "checksum me while streaming"
+
+	expected := sha256.Sum256([]byte(data))
+
+	hr := NewHashReader(strings.NewReader(data), sha256.New())
+	hw := NewHashWriter(ioutil.Discard, sha256.New())
+
+	if _, err := io.Copy(hw, hr); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(hr.Sum(), expected[:]) {
+		t.Errorf("HashReader sum mismatch")
+	}
+
+	if !bytes.Equal(hw.Sum(), expected[:]) {
+		t.Errorf("HashWriter sum mismatch")
+	}
+}
+
+func TestHashWriterAt(t *testing.T) {
+	src := make([]byte, 10000)
+	rand.New(rand.NewSource(1)).Read(src)
+
+	expected := sha256.Sum256(src)
+
+	hw := NewHashWriterAt(NewWriterAtReadSeeker(0), sha256.New())
+
+	// Write 1000-byte chunks in a shuffled order, with some overlaps.
+	order := rand.New(rand.NewSource(2)).Perm(10)
+	for i, chunk := range order {
+		off := int64(chunk * 1000)
+		end := off + 1000
+
+		if i%3 == 0 && end+100 <= int64(len(src)) {
+			end += 100
+		}
+
+		hw.WriteAt(src[off:end], off)
+	}
+
+	if hw.Hashed() != int64(len(src)) || hw.Pending() != 0 {
+		t.Fatalf("expected everything hashed, got %d hashed and %d pending", hw.Hashed(), hw.Pending())
+	}
+
+	if !bytes.Equal(hw.Sum(), expected[:]) {
+		t.Errorf("HashWriterAt sum mismatch")
+	}
+}
+
+func TestHashWriterAtGap(t *testing.T) {
+	hw := NewHashWriterAt(DiscardWriterAt, sha256.New())
+	hw.WriteAt([]byte("world"), 6)
+	hw.WriteAt([]byte("hello"), 0)
+
+	if hw.Hashed() != 5 || hw.Pending() != 5 {
+		t.Errorf("expected the gap to hold back hashing, got %d hashed and %d pending", hw.Hashed(), hw.Pending())
+	}
+
+	hw.WriteAt([]byte(" "), 5)
+
+	expected := sha256.Sum256([]byte("hello world"))
+	if !bytes.Equal(hw.Sum(), expected[:]) {
+		t.Errorf("sum mismatch once the gap was filled")
+	}
+}
@@ -0,0 +1,53 @@
+package miscio
+
+import (
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// IndentWriter wraps an io.Writer, indenting every line written through it by
+// the current depth, e.g. to render nested status trees. The depth may be
+// changed at any time with Push, Pop and SetDepth; a change takes effect from
+// the next line started. It is safe for concurrent use.
+type IndentWriter struct {
+	*PrefixWriter
+	depth int32
+}
+
+// NewIndentWriter returns an IndentWriter that indents lines by depth copies
+// of indent. A negative depth is treated as zero.
+func NewIndentWriter(w io.Writer, indent string, depth int) *IndentWriter {
+	iw := &IndentWriter{}
+	iw.SetDepth(depth)
+	iw.PrefixWriter = NewPrefixFuncWriter(w, func() string {
+		return strings.Repeat(indent, iw.Depth())
+	})
+
+	return iw
+}
+
+// Push increases the depth by one.
+func (iw *IndentWriter) Push() { atomic.AddInt32(&iw.depth, 1) }
+
+// Pop decreases the depth by one, to no less than zero.
+func (iw *IndentWriter) Pop() {
+	for {
+		depth := atomic.LoadInt32(&iw.depth)
+		if depth == 0 || atomic.CompareAndSwapInt32(&iw.depth, depth, depth-1) {
+			return
+		}
+	}
+}
+
+// SetDepth sets the depth. Negative values are treated as zero.
+func (iw *IndentWriter) SetDepth(depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+
+	atomic.StoreInt32(&iw.depth, int32(depth))
+}
+
+// Depth returns the current depth.
+func (iw *IndentWriter) Depth() int { return int(atomic.LoadInt32(&iw.depth)) }
@@ -0,0 +1,48 @@
+package miscio
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestIndentWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	iw := NewIndentWriter(&dst, "  ", 0)
+
+	fmt.Fprint(iw, "root\n")
+	iw.Push()
+	fmt.Fprint(iw, "child 1\nchild 2 ")
+	iw.Push()
+	fmt.Fprint(iw, "(partial)\ngrandchild\n")
+	iw.Pop()
+	iw.Pop()
+	iw.Pop()
+	fmt.Fprint(iw, "done\n")
+
+	expected := "root\n  child 1\n  child 2 (partial)\n    grandchild\ndone\n"
+	if dst.String() != expected {
+		t.Errorf("got %q, want %q", dst.String(), expected)
+	}
+
+	if iw.Depth() != 0 {
+		t.Errorf("expected Pop not to go below zero, depth is %d", iw.Depth())
+	}
+}
+
+func TestIndentWriterNegativeDepth(t *testing.T) {
+	var dst bytes.Buffer
+
+	iw := NewIndentWriter(&dst, "  ", -2)
+	if iw.Depth() != 0 {
+		t.Fatalf("expected a negative depth to be clamped to zero, got %d", iw.Depth())
+	}
+
+	iw.Push()
+	fmt.Fprint(iw, "child\n")
+
+	if dst.String() != "  child\n" {
+		t.Errorf("expected Push to indent by one level, got %q", dst.String())
+	}
+}
@@ -0,0 +1,184 @@
+package miscio
+
+import "sort"
+
+// Range is a half-open range of byte offsets, [Start, End).
+type Range struct {
+	Start int64
+	End   int64
+}
+
+// Len returns the number of bytes in the range.
+func (r Range) Len() int64 { return r.End - r.Start }
+
+// IntervalSet is a set of int64 values, stored as a sorted list of disjoint,
+// non-abutting half-open ranges. It is suited to tracking which parts of a
+// stream or file have been downloaded, written or modified. Operations take
+// time logarithmic in the number of ranges to find their place, plus linear in
+// the number of ranges they merge or split. The zero value is an empty set.
+//
+// An IntervalSet is not safe for concurrent use.
+type IntervalSet struct {
+	ivs     []Range
+	covered int64
+}
+
+// Add adds all values in [a, b) to the set. It does nothing if a >= b.
+func (s *IntervalSet) Add(a, b int64) {
+	if a >= b {
+		return
+	}
+
+	// Find the ranges that overlap or abut [a, b), and replace them with a
+	// single range covering their union.
+	lo := sort.Search(len(s.ivs), func(i int) bool { return s.ivs[i].End >= a })
+	hi := lo
+
+	for ; hi < len(s.ivs) && s.ivs[hi].Start <= b; hi++ {
+		s.covered -= s.ivs[hi].Len()
+
+		if s.ivs[hi].Start < a {
+			a = s.ivs[hi].Start
+		}
+
+		if s.ivs[hi].End > b {
+			b = s.ivs[hi].End
+		}
+	}
+
+	merged := Range{Start: a, End: b}
+	s.covered += merged.Len()
+
+	switch {
+	case hi == lo:
+		s.ivs = append(s.ivs, Range{})
+		copy(s.ivs[lo+1:], s.ivs[lo:])
+		s.ivs[lo] = merged
+	default:
+		s.ivs[lo] = merged
+		s.ivs = append(s.ivs[:lo+1], s.ivs[hi:]...)
+	}
+}
+
+// Remove removes all values in [a, b) from the set. It does nothing if a >= b.
+func (s *IntervalSet) Remove(a, b int64) {
+	if a >= b {
+		return
+	}
+
+	lo := sort.Search(len(s.ivs), func(i int) bool { return s.ivs[i].End > a })
+	hi := lo
+
+	for hi < len(s.ivs) && s.ivs[hi].Start < b {
+		hi++
+	}
+
+	if lo == hi {
+		return
+	}
+
+	// At most two pieces survive: the part of the first range before a, and
+	// the part of the last range after b.
+	var keep []Range
+
+	if first := s.ivs[lo]; first.Start < a {
+		keep = append(keep, Range{Start: first.Start, End: a})
+	}
+
+	if last := s.ivs[hi-1]; last.End > b {
+		keep = append(keep, Range{Start: b, End: last.End})
+	}
+
+	for _, iv := range s.ivs[lo:hi] {
+		s.covered -= iv.Len()
+	}
+
+	for _, iv := range keep {
+		s.covered += iv.Len()
+	}
+
+	if lo == 0 && len(keep) == 0 {
+		// Removing from the front, as a reader consuming a stream does, just
+		// reslices.
+		s.ivs = s.ivs[hi:]
+
+		return
+	}
+
+	s.ivs = append(s.ivs[:lo], append(keep, s.ivs[hi:]...)...)
+}
+
+// Contains reports whether x is in the set.
+func (s *IntervalSet) Contains(x int64) bool {
+	i := sort.Search(len(s.ivs), func(i int) bool { return s.ivs[i].End > x })
+
+	return i < len(s.ivs) && s.ivs[i].Start <= x
+}
+
+// NextCap returns the highest value N for which [a, N) is in the set, i.e. the
+// end of the run of values starting at a. It returns a if a is not in the set.
+func (s *IntervalSet) NextCap(a int64) int64 {
+	i := sort.Search(len(s.ivs), func(i int) bool { return s.ivs[i].End > a })
+	if i < len(s.ivs) && s.ivs[i].Start <= a {
+		return s.ivs[i].End
+	}
+
+	return a
+}
+
+// Len returns the number of values in the set.
+func (s *IntervalSet) Len() int64 {
+	return s.covered
+}
+
+// Ranges returns the maximal ranges making up the set, in ascending order.
+func (s *IntervalSet) Ranges() []Range {
+	return append([]Range(nil), s.ivs...)
+}
+
+// Covered returns the maximal ranges within [a, b) that are in the set, in
+// ascending order.
+func (s *IntervalSet) Covered(a, b int64) []Range {
+	var covered []Range
+
+	for i := sort.Search(len(s.ivs), func(i int) bool { return s.ivs[i].End > a }); i < len(s.ivs); i++ {
+		iv := s.ivs[i]
+		if iv.Start >= b {
+			break
+		}
+
+		if iv.Start < a {
+			iv.Start = a
+		}
+
+		if iv.End > b {
+			iv.End = b
+		}
+
+		covered = append(covered, iv)
+	}
+
+	return covered
+}
+
+// Gaps returns the maximal ranges within [a, b) that are not in the set, in
+// ascending order.
+func (s *IntervalSet) Gaps(a, b int64) []Range {
+	var gaps []Range
+
+	next := a
+
+	for _, iv := range s.Covered(a, b) {
+		if iv.Start > next {
+			gaps = append(gaps, Range{Start: next, End: iv.Start})
+		}
+
+		next = iv.End
+	}
+
+	if next < b {
+		gaps = append(gaps, Range{Start: next, End: b})
+	}
+
+	return gaps
+}
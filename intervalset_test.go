@@ -0,0 +1,64 @@
+package miscio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIntervalSet(t *testing.T) {
+	var s IntervalSet
+
+	s.Add(10, 20)
+	s.Add(30, 40)
+	s.Add(20, 25)
+
+	if expected := []Range{{10, 25}, {30, 40}}; !reflect.DeepEqual(s.Ranges(), expected) {
+		t.Fatalf("Ranges got %v, want %v", s.Ranges(), expected)
+	}
+
+	if s.Len() != 25 {
+		t.Errorf("Len got %d, want 25", s.Len())
+	}
+
+	if !s.Contains(10) || s.Contains(25) || !s.Contains(39) || s.Contains(40) {
+		t.Errorf("Contains gave wrong answers at range boundaries")
+	}
+
+	if s.NextCap(12) != 25 || s.NextCap(27) != 27 {
+		t.Errorf("NextCap got %d and %d, want 25 and 27", s.NextCap(12), s.NextCap(27))
+	}
+
+	if expected := []Range{{5, 10}, {25, 30}, {40, 45}}; !reflect.DeepEqual(s.Gaps(5, 45), expected) {
+		t.Errorf("Gaps got %v, want %v", s.Gaps(5, 45), expected)
+	}
+
+	if expected := []Range{{20, 25}, {30, 35}}; !reflect.DeepEqual(s.Covered(20, 35), expected) {
+		t.Errorf("Covered got %v, want %v", s.Covered(20, 35), expected)
+	}
+}
+
+func TestIntervalSetRemove(t *testing.T) {
+	var s IntervalSet
+
+	s.Add(0, 10)
+	s.Add(20, 30)
+
+	s.Remove(5, 25)
+
+	if expected := []Range{{0, 5}, {25, 30}}; !reflect.DeepEqual(s.Ranges(), expected) || s.Len() != 10 {
+		t.Errorf("after Remove got %v (Len %d), want %v", s.Ranges(), s.Len(), expected)
+	}
+
+	s.Remove(2, 3)
+
+	if expected := []Range{{0, 2}, {3, 5}, {25, 30}}; !reflect.DeepEqual(s.Ranges(), expected) || s.Len() != 9 {
+		t.Errorf("after splitting Remove got %v (Len %d), want %v", s.Ranges(), s.Len(), expected)
+	}
+
+	s.Remove(0, 5)
+	s.Remove(100, 200)
+
+	if expected := []Range{{25, 30}}; !reflect.DeepEqual(s.Ranges(), expected) || s.Len() != 5 {
+		t.Errorf("after front Remove got %v (Len %d), want %v", s.Ranges(), s.Len(), expected)
+	}
+}
@@ -0,0 +1,102 @@
+package miscio
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// JSONLinesWriter writes structured records to an io.Writer in JSON Lines
+// format: each record marshaled to JSON, followed by a delimiter. Each record
+// is written with a single Write, so records from concurrent callers never
+// interleave. It is safe for concurrent use.
+type JSONLinesWriter struct {
+	m       sync.Mutex
+	w       io.Writer
+	delim   []byte
+	marshal func(v interface{}) ([]byte, error)
+	flush   bool
+	buf     []byte
+}
+
+// JSONLinesWriterOption configures a JSONLinesWriter at construction.
+type JSONLinesWriterOption func(jw *JSONLinesWriter)
+
+// WithRecordDelimiter sets the delimiter written after each record. The
+// default is "\n".
+func WithRecordDelimiter(delim string) JSONLinesWriterOption {
+	return func(jw *JSONLinesWriter) {
+		jw.delim = []byte(delim)
+	}
+}
+
+// WithMarshalFunc replaces json.Marshal as the encoder for records, e.g. to
+// use a faster JSON library, or to apply custom indentation or escaping. The
+// encoding must not contain the record delimiter.
+func WithMarshalFunc(marshal func(v interface{}) ([]byte, error)) JSONLinesWriterOption {
+	return func(jw *JSONLinesWriter) {
+		jw.marshal = marshal
+	}
+}
+
+// WithFlushAfterRecord makes WriteRecord flush the underlying writer after
+// every record, if it is a Flusher (as *bufio.Writer is), so that records are
+// not left sitting in a buffer.
+func WithFlushAfterRecord() JSONLinesWriterOption {
+	return func(jw *JSONLinesWriter) {
+		jw.flush = true
+	}
+}
+
+// NewJSONLinesWriter returns a JSONLinesWriter writing to w.
+func NewJSONLinesWriter(w io.Writer, opts ...JSONLinesWriterOption) *JSONLinesWriter {
+	jw := &JSONLinesWriter{
+		w:       w,
+		delim:   []byte{'\n'},
+		marshal: json.Marshal,
+	}
+
+	for _, opt := range opts {
+		opt(jw)
+	}
+
+	return jw
+}
+
+// WriteRecord marshals v and writes it, followed by the delimiter. If
+// marshaling fails, nothing is written.
+func (jw *JSONLinesWriter) WriteRecord(v interface{}) error {
+	data, err := jw.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	jw.m.Lock()
+	defer jw.m.Unlock()
+
+	jw.buf = append(append(jw.buf[:0], data...), jw.delim...)
+
+	n, err := jw.w.Write(jw.buf)
+	if err == nil && n < len(jw.buf) {
+		err = io.ErrShortWrite
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if jw.flush {
+		return flushNext(jw.w)
+	}
+
+	return nil
+}
+
+// Flush implements Flusher for JSONLinesWriter. Records are never held back,
+// so it only flushes the underlying writer.
+func (jw *JSONLinesWriter) Flush() error {
+	jw.m.Lock()
+	defer jw.m.Unlock()
+
+	return flushNext(jw.w)
+}
@@ -0,0 +1,46 @@
+package miscio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+type event struct {
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	jw := NewJSONLinesWriter(&dst)
+	jw.WriteRecord(event{"info", "started"})
+	jw.WriteRecord(map[string]int{"n": 1})
+
+	if expected := "{\"level\":\"info\",\"msg\":\"started\"}\n{\"n\":1}\n"; dst.String() != expected {
+		t.Errorf("got %q, want %q", dst.String(), expected)
+	}
+
+	if err := jw.WriteRecord(make(chan int)); err == nil {
+		t.Errorf("expected an error marshaling a channel")
+	}
+}
+
+func TestJSONLinesWriterOptions(t *testing.T) {
+	var dst bytes.Buffer
+
+	bw := bufio.NewWriter(&dst)
+	jw := NewJSONLinesWriter(bw,
+		WithRecordDelimiter("\x1e"),
+		WithMarshalFunc(func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "") }),
+		WithFlushAfterRecord(),
+	)
+
+	jw.WriteRecord([]int{1, 2})
+
+	if expected := "[\n1,\n2\n]\x1e"; dst.String() != expected {
+		t.Errorf("got %q, want %q", dst.String(), expected)
+	}
+}
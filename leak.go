@@ -0,0 +1,115 @@
+package miscio
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// LeakReport describes an instance of one of the package's Closer types that
+// was garbage collected without Close having been called.
+type LeakReport struct {
+	// Type is the name of the leaked type, e.g. "*miscio.WriterAtReadCloser".
+	Type string
+	// Stack is the goroutine stack trace captured when the instance was
+	// created.
+	Stack string
+}
+
+// leakDetector holds the currently installed report function, if any.
+var leakDetector struct { // nolint:gochecknoglobals
+	m      sync.Mutex
+	report func(LeakReport)
+}
+
+// EnableLeakDetection turns on leak detection for instances created from now
+// on, until the returned function is called. Whenever such an instance is
+// garbage collected without having been closed, report is called with the
+// stack that created it. report runs on the runtime's finalizer goroutine, so
+// it should not block.
+//
+// The types tracked are those for which a missing Close leaves something
+// behind: an open file or source (FollowReader, RetryReader, and
+// AtomicFileWriter, whose temporary file is left on disk), or readers blocked
+// forever waiting for more data (WriterAtReadCloser, WriterAtReadSeeker and
+// RollingLineBuffer). Types whose Close only flushes or marks them as done are
+// not tracked.
+//
+// Leak detection captures a stack trace per instance and is intended for
+// tests, e.g. enabled from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//	    disable := miscio.EnableLeakDetection(func(r miscio.LeakReport) {
+//	        log.Printf("leaked %s created at:\n%s", r.Type, r.Stack)
+//	    })
+//	    code := m.Run()
+//	    disable()
+//	    os.Exit(code)
+//	}
+//
+// Detection is best-effort: the garbage collector gives no guarantee about
+// when, or whether, an unreachable instance is collected.
+func EnableLeakDetection(report func(LeakReport)) (disable func()) {
+	leakDetector.m.Lock()
+	defer leakDetector.m.Unlock()
+
+	leakDetector.report = report
+
+	return func() {
+		leakDetector.m.Lock()
+		defer leakDetector.m.Unlock()
+
+		leakDetector.report = nil
+	}
+}
+
+// leakTracker is owned by exactly one tracked instance. The finalizer is set on
+// the tracker rather than the instance itself, since the instances may contain
+// reference cycles (e.g. a sync.Cond pointing back at its owner's mutex) that
+// prevent finalizers from running.
+type leakTracker struct {
+	typ    string
+	stack  []byte
+	closed int32
+}
+
+// trackLeak returns a tracker for a newly created instance of typ, or nil if
+// leak detection is not enabled. The returned tracker must be stored in the
+// instance, and markClosed called on it from Close.
+func trackLeak(typ string) *leakTracker {
+	leakDetector.m.Lock()
+	enabled := leakDetector.report != nil
+	leakDetector.m.Unlock()
+
+	if !enabled {
+		return nil
+	}
+
+	lt := &leakTracker{typ: typ, stack: debug.Stack()}
+	runtime.SetFinalizer(lt, reportLeak)
+
+	return lt
+}
+
+// markClosed records that the tracked instance was closed. It is safe to call
+// on a nil tracker.
+func (lt *leakTracker) markClosed() {
+	if lt != nil {
+		atomic.StoreInt32(&lt.closed, 1)
+	}
+}
+
+func reportLeak(lt *leakTracker) {
+	if atomic.LoadInt32(&lt.closed) == 1 {
+		return
+	}
+
+	leakDetector.m.Lock()
+	report := leakDetector.report
+	leakDetector.m.Unlock()
+
+	if report != nil {
+		report(LeakReport{Type: lt.typ, Stack: string(lt.stack)})
+	}
+}
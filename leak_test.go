@@ -0,0 +1,129 @@
+package miscio
+
+import (
+	"io"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLeakDetection(t *testing.T) {
+	reports := make(chan LeakReport, 2)
+	disable := EnableLeakDetection(func(r LeakReport) { reports <- r })
+	defer disable()
+
+	func() {
+		NewWriterAtReadCloser(0)
+		NewWriterAtReadCloser(0).Close()
+	}()
+
+	deadline := time.After(5 * time.Second)
+
+	for {
+		runtime.GC()
+
+		select {
+		case r := <-reports:
+			if r.Type != "*miscio.WriterAtReadCloser" {
+				t.Errorf("unexpected leaked type %s", r.Type)
+			}
+
+			// Give the closed instance's finalizer a chance to (wrongly) report.
+			runtime.GC()
+			time.Sleep(10 * time.Millisecond)
+
+			if len(reports) != 0 {
+				t.Errorf("closed instance was reported as leaked")
+			}
+
+			return
+		case <-deadline:
+			t.Fatal("leaked instance was never reported")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestLeakDetectionReset(t *testing.T) {
+	reports := make(chan LeakReport, 1)
+	disable := EnableLeakDetection(func(r LeakReport) { reports <- r })
+	defer disable()
+
+	func() {
+		wr := NewWriterAtReadCloser(0)
+		wr.Reset()
+		wr.Close()
+	}()
+
+	for i := 0; i < 5; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case r := <-reports:
+		t.Errorf("instance closed after Reset was reported as leaked, created at:\n%s", r.Stack)
+	default:
+	}
+}
+
+func TestLeakDetectionTypes(t *testing.T) {
+	reports := make(chan LeakReport, 16)
+	disable := EnableLeakDetection(func(r LeakReport) {
+		select {
+		case reports <- r:
+		default:
+		}
+	})
+	defer disable()
+
+	dir := t.TempDir()
+	open := func(int64) (io.ReadCloser, error) { return nil, io.EOF }
+
+	func() {
+		NewRollingLineBuffer(1)
+		NewWriterAtReadSeeker(0)
+		NewRetryReader(open)
+		NewAtomicFileWriter(filepath.Join(dir, "leaked"))
+
+		NewRollingLineBuffer(1).Close()
+		NewWriterAtReadSeeker(0).Close()
+		NewRetryReader(open).Close()
+
+		aw, _ := NewAtomicFileWriter(filepath.Join(dir, "aborted"))
+		aw.Abort()
+	}()
+
+	missing := map[string]bool{
+		"*miscio.RollingLineBuffer":  true,
+		"*miscio.WriterAtReadSeeker": true,
+		"*miscio.RetryReader":        true,
+		"*miscio.AtomicFileWriter":   true,
+	}
+	deadline := time.After(5 * time.Second)
+
+	for len(missing) > 0 {
+		runtime.GC()
+
+		select {
+		case r := <-reports:
+			if !missing[r.Type] {
+				t.Fatalf("unexpected or duplicate leak report for %s", r.Type)
+			}
+
+			delete(missing, r.Type)
+		case <-deadline:
+			t.Fatalf("leaks were never reported for %v", missing)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	// Give the closed instances' finalizers a chance to (wrongly) report.
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+
+	if len(reports) != 0 {
+		t.Errorf("closed instance of %s was reported as leaked", (<-reports).Type)
+	}
+}
@@ -0,0 +1,66 @@
+package miscio
+
+import "io"
+
+// LimitedWriter is the write-side counterpart of io.LimitedReader: it writes
+// to an underlying io.Writer until a limit is reached, after which writes fail
+// with *ErrWriteLimitExceeded. Use it to cap how much of an untrusted stream is
+// captured.
+type LimitedWriter struct {
+	w       io.Writer
+	limit   int64
+	written int64
+}
+
+var (
+	_ io.Writer = (*LimitedWriter)(nil)
+	_ Flusher   = (*LimitedWriter)(nil)
+)
+
+// NewLimitedWriter returns a LimitedWriter that writes at most n bytes to w.
+func NewLimitedWriter(w io.Writer, n int64) *LimitedWriter {
+	return &LimitedWriter{w: w, limit: n}
+}
+
+// Write implements io.Writer for LimitedWriter. A write that would exceed the
+// limit writes as much of p as fits, then returns *ErrWriteLimitExceeded.
+func (lw *LimitedWriter) Write(p []byte) (int, error) {
+	remaining := lw.limit - lw.written
+
+	over := int64(len(p)) > remaining
+	if over {
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		p = p[:remaining]
+	}
+
+	n, err := lw.w.Write(p)
+	lw.written += int64(n)
+
+	if err == nil && over {
+		err = &ErrWriteLimitExceeded{Limit: lw.limit, Written: lw.written}
+	}
+
+	return n, err
+}
+
+// Flush implements Flusher for LimitedWriter by flushing the underlying writer.
+func (lw *LimitedWriter) Flush() error {
+	return flushNext(lw.w)
+}
+
+// Written returns the number of bytes written to the underlying writer.
+func (lw *LimitedWriter) Written() int64 {
+	return lw.written
+}
+
+// Remaining returns the number of bytes that may still be written.
+func (lw *LimitedWriter) Remaining() int64 {
+	if remaining := lw.limit - lw.written; remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
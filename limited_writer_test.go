@@ -0,0 +1,45 @@
+package miscio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLimitedWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	lw := NewLimitedWriter(&buf, 8)
+
+	if n, err := lw.Write([]byte("hello")); n != 5 || err != nil {
+		t.Errorf("Write got (%d, %v)", n, err)
+	}
+
+	n, err := fmt.Fprint(lw, ", world")
+
+	var limitErr *ErrWriteLimitExceeded
+	if !errors.As(err, &limitErr) || n != 3 {
+		t.Fatalf("expected a short write and ErrWriteLimitExceeded, got (%d, %v)", n, err)
+	}
+
+	if limitErr.Limit != 8 || limitErr.Written != 8 {
+		t.Errorf("unexpected error fields %+v", limitErr)
+	}
+
+	if buf.String() != "hello, w" {
+		t.Errorf("expected the output to be cut at the limit, got %q", buf.String())
+	}
+
+	if n, err := lw.Write([]byte("more")); n != 0 || err == nil {
+		t.Errorf("expected writes past the limit to fail, got (%d, %v)", n, err)
+	}
+
+	if n, err := lw.Write(nil); n != 0 || err != nil {
+		t.Errorf("expected an empty write at the limit to succeed, got (%d, %v)", n, err)
+	}
+
+	if lw.Written() != 8 || lw.Remaining() != 0 {
+		t.Errorf("expected 8 written and 0 remaining, got %d and %d", lw.Written(), lw.Remaining())
+	}
+}
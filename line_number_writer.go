@@ -0,0 +1,27 @@
+package miscio
+
+import (
+	"fmt"
+	"io"
+)
+
+// DefaultLineNumberFormat is the format used by NewLineNumberWriter if none is
+// given. It matches the output of `cat -n`.
+const DefaultLineNumberFormat = "%6d\t"
+
+// NewLineNumberWriter returns a PrefixWriter that starts every line with its
+// line number, counting from 1, formatted with format (a fmt format string
+// taking a single integer; DefaultLineNumberFormat if empty).
+func NewLineNumberWriter(w io.Writer, format string) *PrefixWriter {
+	if format == "" {
+		format = DefaultLineNumberFormat
+	}
+
+	line := 0
+
+	return NewPrefixFuncWriter(w, func() string {
+		line++
+
+		return fmt.Sprintf(format, line)
+	})
+}
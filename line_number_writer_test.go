@@ -0,0 +1,25 @@
+package miscio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLineNumberWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	lw := NewLineNumberWriter(&dst, "%d: ")
+	lw.Write([]byte("main.go:3: undefined: x\nmain.go"))
+	lw.Write([]byte(":7: missing return\n"))
+
+	if expected := "1: main.go:3: undefined: x\n2: main.go:7: missing return\n"; dst.String() != expected {
+		t.Errorf("got %q, want %q", dst.String(), expected)
+	}
+
+	dst.Reset()
+	NewLineNumberWriter(&dst, "").Write([]byte("a\n"))
+
+	if dst.String() != "     1\ta\n" {
+		t.Errorf("default format got %q", dst.String())
+	}
+}
@@ -0,0 +1,216 @@
+package miscio
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// linePipe is the state shared by the two ends of a LinePipe.
+type linePipe struct {
+	wrMu sync.Mutex // Serializes Writes, so their lines are never interleaved.
+	m    sync.Mutex
+	cond *sync.Cond
+
+	lines   [][]byte // Complete lines handed to the reader, not yet read.
+	partial []byte   // The unterminated tail of what has been written.
+
+	rerr error // Set once the reader is closed.
+	werr error // Set once the writer is closed.
+}
+
+// LinePipe creates a synchronous in-memory pipe like io.Pipe, except that the
+// reader only ever observes complete lines. The writer holds back a trailing
+// partial line until the rest of it is written, or until the writer is closed,
+// at which point it is delivered as the final (unterminated) line. It is the
+// lossless, blocking sibling of RollingLineBuffer.
+//
+// Each Write blocks until the reader has consumed every complete line it
+// contained. Each Read returns one or more whole lines, newline included; if p
+// is too small to hold the next line, Read returns ErrShortBuffer, whose
+// SizeNeeded says how large a buffer to retry with.
+//
+// As with io.Pipe, it is safe to call Read and Write in parallel with each
+// other or with Close, and parallel calls to Read or to Write are sequentially
+// gated.
+func LinePipe() (*LinePipeReader, *LinePipeWriter) {
+	p := &linePipe{}
+	p.cond = sync.NewCond(&p.m)
+
+	return &LinePipeReader{p}, &LinePipeWriter{p}
+}
+
+func (p *linePipe) read(b []byte) (int, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for len(p.lines) == 0 && p.rerr == nil && p.werr == nil {
+		p.cond.Wait()
+	}
+
+	switch {
+	case p.rerr != nil:
+		return 0, io.ErrClosedPipe
+	case len(p.lines) == 0:
+		return 0, p.werr
+	case len(p.lines[0]) > len(b):
+		return 0, NewErrShortBuffer(len(p.lines[0]))
+	}
+
+	read := 0
+
+	for len(p.lines) > 0 && read+len(p.lines[0]) <= len(b) {
+		read += copy(b[read:], p.lines[0])
+		p.lines = p.lines[1:]
+	}
+
+	if len(p.lines) == 0 {
+		p.lines = nil
+	}
+
+	p.cond.Broadcast()
+
+	return read, nil
+}
+
+func (p *linePipe) write(b []byte) (int, error) {
+	p.wrMu.Lock()
+	defer p.wrMu.Unlock()
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	switch {
+	case p.werr != nil:
+		return 0, io.ErrClosedPipe
+	case p.rerr != nil:
+		return 0, p.rerr
+	}
+
+	data := append(p.partial, b...)
+	p.partial = nil
+
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+
+		p.lines = append(p.lines, data[:i+1:i+1])
+		data = data[i+1:]
+	}
+
+	if len(data) > 0 {
+		p.partial = append([]byte(nil), data...)
+	}
+
+	p.cond.Broadcast()
+
+	for len(p.lines) > 0 && p.rerr == nil {
+		p.cond.Wait()
+	}
+
+	if p.rerr != nil {
+		return 0, p.rerr
+	}
+
+	return len(b), nil
+}
+
+func (p *linePipe) closeRead(err error) {
+	if err == nil {
+		err = io.ErrClosedPipe
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.rerr == nil {
+		p.rerr = err
+	}
+
+	p.cond.Broadcast()
+}
+
+func (p *linePipe) closeWrite(err error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.werr != nil {
+		return
+	}
+
+	if err == nil {
+		err = io.EOF
+
+		if len(p.partial) > 0 {
+			p.lines = append(p.lines, p.partial)
+		}
+	}
+
+	p.partial = nil
+	p.werr = err
+	p.cond.Broadcast()
+}
+
+// LinePipeReader is the read half of a LinePipe.
+type LinePipeReader struct {
+	p *linePipe
+}
+
+var _ io.ReadCloser = (*LinePipeReader)(nil)
+
+// Read implements io.Reader for LinePipeReader. It waits for at least one
+// complete line, then returns as many whole lines as fit in p. Once the writer
+// is closed, Read returns the remaining lines and then the error the writer was
+// closed with (io.EOF for Close).
+func (r *LinePipeReader) Read(p []byte) (int, error) {
+	return r.p.read(p)
+}
+
+// Close closes the reader; subsequent writes to the write half of the pipe
+// return io.ErrClosedPipe.
+func (r *LinePipeReader) Close() error {
+	return r.CloseWithError(nil)
+}
+
+// CloseWithError closes the reader; subsequent writes to the write half of the
+// pipe return err, or io.ErrClosedPipe if err is nil. CloseWithError never
+// overwrites an earlier error, and always returns nil.
+func (r *LinePipeReader) CloseWithError(err error) error {
+	r.p.closeRead(err)
+
+	return nil
+}
+
+// LinePipeWriter is the write half of a LinePipe.
+type LinePipeWriter struct {
+	p *linePipe
+}
+
+var _ io.WriteCloser = (*LinePipeWriter)(nil)
+
+// Write implements io.Writer for LinePipeWriter. It passes every complete line
+// in p (together with any partial line held back from earlier Writes) to the
+// reader, and waits until the reader has consumed them. A trailing partial
+// line is held back without waiting.
+func (w *LinePipeWriter) Write(p []byte) (int, error) {
+	return w.p.write(p)
+}
+
+// Close closes the writer, delivering any held-back partial line to the reader
+// as the final line. Once the reader has consumed it, subsequent reads return
+// io.EOF.
+func (w *LinePipeWriter) Close() error {
+	return w.CloseWithError(nil)
+}
+
+// CloseWithError closes the writer. If err is nil it behaves like Close;
+// otherwise any held-back partial line is discarded, and once the reader has
+// consumed the complete lines, subsequent reads return err. CloseWithError
+// never overwrites an earlier error, and always returns nil.
+func (w *LinePipeWriter) CloseWithError(err error) error {
+	w.p.closeWrite(err)
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLinePipe(t *testing.T) {
+	r, w := LinePipe()
+
+	go func() {
+		w.Write([]byte("first li"))
+		w.Write([]byte("ne\nsecond line\nthi"))
+		w.Write([]byte("rd, unterminated"))
+		w.Close()
+	}()
+
+	var got []string
+
+	buf := make([]byte, 4)
+
+	for {
+		n, err := r.Read(buf)
+		if errors.Is(err, io.ErrShortBuffer) {
+			var sbe *ErrShortBuffer
+			errors.As(err, &sbe)
+			buf = make([]byte, sbe.SizeNeeded())
+
+			continue
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got = append(got, string(buf[:n]))
+	}
+
+	expected := []string{"first line\n", "second line\n", "third, unterminated"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected reads %q, got %q", expected, got)
+	}
+
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("read %d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestLinePipeWriteBlocks(t *testing.T) {
+	r, w := LinePipe()
+
+	// A partial line is held back without waiting for the reader.
+	if n, err := w.Write([]byte("partial")); n != 7 || err != nil {
+		t.Fatalf("Write got (%d, %v)", n, err)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		w.Write([]byte(" line\nnext\n"))
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected Write to wait for the reader")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	buf := make([]byte, 64)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "partial line\nnext\n" {
+		t.Errorf("Read got (%q, %v)", buf[:n], err)
+	}
+
+	<-done
+}
+
+func TestLinePipeCloseWithError(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	r, w := LinePipe()
+
+	go func() {
+		w.Write([]byte("complete\nincomplete"))
+		w.CloseWithError(errBoom)
+	}()
+
+	buf := make([]byte, 64)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "complete\n" {
+		t.Errorf("Read got (%q, %v)", buf[:n], err)
+	}
+
+	if _, err := r.Read(buf); err != errBoom {
+		t.Errorf("expected the partial line to be discarded and errBoom returned, got %v", err)
+	}
+
+	r.Close()
+
+	if _, err := w.Write([]byte("x\n")); err != io.ErrClosedPipe {
+		t.Errorf("expected io.ErrClosedPipe, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package miscio
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// LineReader reads complete lines from an io.Reader, like bufio.Scanner, but
+// with a bounded line length that is reported rather than fatal. A line longer
+// than the maximum is returned truncated, with ErrLineTooLong, and reading
+// resumes at the line after it; a final line with no trailing newline is
+// returned with ErrUnterminatedLine. Lines are split on '\n' and truncated at a
+// rune boundary, exactly as a RollingLineBuffer does.
+//
+// A LineReader is not safe for concurrent use.
+type LineReader struct {
+	br     *bufio.Reader
+	maxLen int
+	err    error // Sticky error from the underlying reader.
+
+	// The line returned by the last call to next that Read could not fit.
+	pending    []byte
+	pendingErr error
+	hasPending bool
+}
+
+var _ io.Reader = (*LineReader)(nil)
+
+// NewLineReader returns a LineReader reading lines of up to maxLen bytes, not
+// counting the newline, from r. If maxLen is not positive,
+// bufio.MaxScanTokenSize is used.
+func NewLineReader(r io.Reader, maxLen int) *LineReader {
+	if maxLen <= 0 {
+		maxLen = bufio.MaxScanTokenSize
+	}
+
+	return &LineReader{
+		br:     bufio.NewReaderSize(r, maxLen+1),
+		maxLen: maxLen,
+	}
+}
+
+// ReadLine returns the next line, without its newline, in newly allocated
+// memory. Along with the line it may return ErrLineTooLong, if the line was
+// truncated, or ErrUnterminatedLine, if it is the last line and had no newline;
+// both leave the LineReader ready to read on. A final line that is both too
+// long and unterminated is reported as ErrLineTooLong. After the last line,
+// ReadLine returns io.EOF; any other error from the underlying reader is
+// returned along with whatever was read of the current line, and by every
+// later call.
+func (lr *LineReader) ReadLine() ([]byte, error) {
+	if lr.hasPending {
+		line, err := lr.pending, lr.pendingErr
+		lr.pending, lr.pendingErr, lr.hasPending = nil, nil, false
+
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			line = line[:len(line)-1]
+		}
+
+		return line, err
+	}
+
+	line, terminated, err := lr.next()
+	if terminated {
+		line = line[:len(line)-1]
+	}
+
+	return line, err
+}
+
+// Read implements io.Reader for LineReader. Read reads one or more whole lines,
+// newline included, into p. If p is too small to hold the next line, Read
+// returns ErrShortBuffer to signal to the caller they need a bigger buffer.
+// Read stops after a line that ReadLine would return an error with, returning
+// the same error.
+func (lr *LineReader) Read(p []byte) (int, error) {
+	read := 0
+
+	for {
+		if !lr.hasPending {
+			lr.pending, _, lr.pendingErr = lr.next()
+			lr.hasPending = true
+		}
+
+		line, err := lr.pending, lr.pendingErr
+
+		if len(line) > len(p)-read {
+			if read > 0 {
+				return read, nil
+			}
+
+			return 0, NewErrShortBuffer(len(line))
+		}
+
+		read += copy(p[read:], line)
+		lr.pending, lr.pendingErr, lr.hasPending = nil, nil, false
+
+		if err != nil || read == len(p) {
+			return read, err
+		}
+
+		if !lr.lineBuffered() {
+			// Don't wait on the underlying reader for more lines once we
+			// have some.
+			return read, nil
+		}
+	}
+}
+
+// lineBuffered reports whether the next line can be read without reading from
+// the underlying reader.
+func (lr *LineReader) lineBuffered() bool {
+	if lr.err != nil {
+		return true
+	}
+
+	buffered, _ := lr.br.Peek(lr.br.Buffered())
+
+	return bytes.IndexByte(buffered, '\n') >= 0 || len(buffered) > lr.maxLen
+}
+
+// next reads the next line, including its newline if terminated is true.
+func (lr *LineReader) next() (line []byte, terminated bool, err error) {
+	if lr.err != nil {
+		return nil, false, lr.err
+	}
+
+	chunk, err := lr.br.ReadSlice('\n')
+
+	switch err {
+	case nil:
+		if len(chunk)-1 > lr.maxLen {
+			return lr.truncate(chunk[:len(chunk)-1], true), true, ErrLineTooLong
+		}
+
+		return append([]byte(nil), chunk...), true, nil
+	case bufio.ErrBufferFull:
+		line = lr.truncate(chunk, false)
+
+		// Discard the rest of the line.
+		for err == bufio.ErrBufferFull {
+			_, err = lr.br.ReadSlice('\n')
+		}
+
+		if err != nil && err != io.EOF {
+			lr.err = err
+		}
+
+		if err == nil {
+			line = append(line, '\n')
+		}
+
+		return line, err == nil, ErrLineTooLong
+	}
+
+	lr.err = err
+
+	switch {
+	case err != io.EOF:
+		return append([]byte(nil), chunk...), false, err
+	case len(chunk) == 0:
+		return nil, false, io.EOF
+	case len(chunk) > lr.maxLen:
+		return lr.truncate(chunk, false), false, ErrLineTooLong
+	default:
+		return append([]byte(nil), chunk...), false, ErrUnterminatedLine
+	}
+}
+
+// truncate returns a copy of line clipped to the maximum line length, with a
+// newline appended if terminated is true.
+func (lr *LineReader) truncate(line []byte, terminated bool) []byte {
+	line = append([]byte(nil), truncateUTF8(line, lr.maxLen)...)
+	if terminated {
+		line = append(line, '\n')
+	}
+
+	return line
+}
@@ -0,0 +1,111 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLineReaderReadLine(t *testing.T) {
+	input := "short\n" + strings.Repeat("x", 40) + "\n\nhéllo wörld\nlast"
+	lr := NewLineReader(iotest.OneByteReader(strings.NewReader(input)), 8)
+
+	tests := []struct {
+		line string
+		err  error
+	}{
+		{"short", nil},
+		{strings.Repeat("x", 8), ErrLineTooLong},
+		{"", nil},
+		{"héllo w", ErrLineTooLong}, // Not split inside the 'ö'.
+		{"last", ErrUnterminatedLine},
+		{"", io.EOF},
+		{"", io.EOF},
+	}
+
+	for i, tt := range tests {
+		line, err := lr.ReadLine()
+		if string(line) != tt.line || err != tt.err {
+			t.Errorf("line %d: expected (%q, %v), got (%q, %v)", i, tt.line, tt.err, line, err)
+		}
+	}
+}
+
+func TestLineReaderUnterminatedTooLong(t *testing.T) {
+	lr := NewLineReader(strings.NewReader("ok\n"+strings.Repeat("y", 100)), 20)
+
+	if line, err := lr.ReadLine(); string(line) != "ok" || err != nil {
+		t.Fatalf("expected (ok, nil), got (%q, %v)", line, err)
+	}
+
+	if line, err := lr.ReadLine(); len(line) != 20 || err != ErrLineTooLong {
+		t.Fatalf("expected a truncated line with ErrLineTooLong, got (%q, %v)", line, err)
+	}
+
+	if _, err := lr.ReadLine(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestLineReaderRead(t *testing.T) {
+	lr := NewLineReader(iotest.HalfReader(strings.NewReader("one\ntwo\nthree\n"+strings.Repeat("z", 30)+"\nend")), 16)
+
+	buf := make([]byte, 4)
+
+	n, err := lr.Read(buf)
+	if string(buf[:n]) != "one\n" || err != nil {
+		t.Fatalf("expected (one, nil), got (%q, %v)", buf[:n], err)
+	}
+
+	n, err = lr.Read(buf)
+	if string(buf[:n]) != "two\n" || err != nil {
+		t.Fatalf("expected (two, nil), got (%q, %v)", buf[:n], err)
+	}
+
+	var sb *ErrShortBuffer
+
+	n, err = lr.Read(buf)
+	if n != 0 || !errors.As(err, &sb) || sb.SizeNeeded() != len("three\n") {
+		t.Fatalf("expected ErrShortBuffer for 6 bytes, got (%d, %v)", n, err)
+	}
+
+	buf = make([]byte, 64)
+
+	n, err = lr.Read(buf)
+	if string(buf[:n]) != "three\n" || err != nil {
+		t.Fatalf("expected (three, nil), got (%q, %v)", buf[:n], err)
+	}
+
+	n, err = lr.Read(buf)
+	if expected := strings.Repeat("z", 16) + "\n"; string(buf[:n]) != expected || err != ErrLineTooLong {
+		t.Fatalf("expected (%q, ErrLineTooLong), got (%q, %v)", expected, buf[:n], err)
+	}
+
+	n, err = lr.Read(buf)
+	if string(buf[:n]) != "end" || err != ErrUnterminatedLine {
+		t.Fatalf("expected (end, ErrUnterminatedLine), got (%q, %v)", buf[:n], err)
+	}
+
+	if n, err = lr.Read(buf); n != 0 || err != io.EOF {
+		t.Fatalf("expected io.EOF, got (%d, %v)", n, err)
+	}
+}
+
+func TestLineReaderError(t *testing.T) {
+	errBoom := errors.New("boom")
+	lr := NewLineReader(io.MultiReader(strings.NewReader("fine\npart"), errReader{errBoom}), 0)
+
+	if line, err := lr.ReadLine(); string(line) != "fine" || err != nil {
+		t.Fatalf("expected (fine, nil), got (%q, %v)", line, err)
+	}
+
+	if line, err := lr.ReadLine(); string(line) != "part" || err != errBoom {
+		t.Fatalf("expected (part, boom), got (%q, %v)", line, err)
+	}
+
+	if _, err := lr.ReadLine(); err != errBoom {
+		t.Fatalf("expected the error to be sticky, got %v", err)
+	}
+}
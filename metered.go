@@ -0,0 +1,133 @@
+package miscio
+
+import (
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultMeterHalfLife is the half-life of a meter's moving average if none
+// is given.
+const DefaultMeterHalfLife = 5 * time.Second
+
+// meter maintains a byte total and an exponentially weighted moving average of
+// the byte rate. The average is updated lazily, so it costs nothing between
+// calls.
+type meter struct {
+	m     sync.Mutex
+	tau   float64 // time constant, in seconds
+	total int64
+	// weighted is the sum of all byte counts seen, each decayed by
+	// exp(-age/tau). Divided by tau, it is the moving average rate.
+	weighted float64
+	last     time.Time
+	now      func() time.Time
+}
+
+func newMeter(halfLife time.Duration) *meter {
+	if halfLife <= 0 {
+		halfLife = DefaultMeterHalfLife
+	}
+
+	m := &meter{tau: halfLife.Seconds() / math.Ln2, now: time.Now}
+	m.last = m.now()
+
+	return m
+}
+
+// decay brings weighted up to date with now. It must be called with mt.m held.
+func (mt *meter) decay(now time.Time) {
+	if dt := now.Sub(mt.last).Seconds(); dt > 0 {
+		mt.weighted *= math.Exp(-dt / mt.tau)
+		mt.last = now
+	}
+}
+
+func (mt *meter) add(n int) {
+	now := mt.now()
+
+	mt.m.Lock()
+	defer mt.m.Unlock()
+
+	mt.decay(now)
+	mt.total += int64(n)
+	mt.weighted += float64(n)
+}
+
+// Rate returns the moving average throughput in bytes per second. Recent
+// bytes count the most; the weight of a byte halves every half-life.
+func (mt *meter) Rate() float64 {
+	now := mt.now()
+
+	mt.m.Lock()
+	defer mt.m.Unlock()
+
+	mt.decay(now)
+
+	return mt.weighted / mt.tau
+}
+
+// Total returns the total number of bytes seen.
+func (mt *meter) Total() int64 {
+	mt.m.Lock()
+	defer mt.m.Unlock()
+
+	return mt.total
+}
+
+// MeteredReader wraps an io.Reader, keeping a moving average of its throughput
+// that can be queried at any time with Rate, e.g. to adapt concurrency to the
+// bandwidth actually achieved. Unlike a ReportingReader's windowed Stats, the
+// average changes smoothly, and costs constant time to update and query.
+// It is safe to call Rate and Total while other goroutines are reading.
+type MeteredReader struct {
+	*meter
+	r io.Reader
+}
+
+// NewMeteredReader returns a MeteredReader over r whose moving average has the
+// given half-life (DefaultMeterHalfLife if not positive).
+func NewMeteredReader(r io.Reader, halfLife time.Duration) *MeteredReader {
+	return &MeteredReader{meter: newMeter(halfLife), r: r}
+}
+
+// Read implements io.Reader for MeteredReader.
+func (mr *MeteredReader) Read(p []byte) (int, error) {
+	n, err := mr.r.Read(p)
+	mr.add(n)
+
+	return n, err
+}
+
+// MeteredWriter wraps an io.Writer, keeping a moving average of its throughput
+// that can be queried at any time with Rate. It is safe to call Rate and Total
+// while other goroutines are writing.
+type MeteredWriter struct {
+	*meter
+	w io.Writer
+}
+
+var (
+	_ io.Writer = (*MeteredWriter)(nil)
+	_ Flusher   = (*MeteredWriter)(nil)
+)
+
+// NewMeteredWriter returns a MeteredWriter over w whose moving average has the
+// given half-life (DefaultMeterHalfLife if not positive).
+func NewMeteredWriter(w io.Writer, halfLife time.Duration) *MeteredWriter {
+	return &MeteredWriter{meter: newMeter(halfLife), w: w}
+}
+
+// Write implements io.Writer for MeteredWriter.
+func (mw *MeteredWriter) Write(p []byte) (int, error) {
+	n, err := mw.w.Write(p)
+	mw.add(n)
+
+	return n, err
+}
+
+// Flush implements Flusher for MeteredWriter by flushing the underlying writer.
+func (mw *MeteredWriter) Flush() error {
+	return flushNext(mw.w)
+}
@@ -0,0 +1,48 @@
+package miscio
+
+import (
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMeteredWriter(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	mw := NewMeteredWriter(ioutil.Discard, time.Second)
+	mw.now = clock.Now
+	mw.last = clock.t
+
+	// Write 1000 bytes every 10ms, i.e. 100KB/s, for long enough that the
+	// average has settled.
+	for i := 0; i < 1000; i++ {
+		clock.t = clock.t.Add(10 * time.Millisecond)
+		mw.Write(make([]byte, 1000))
+	}
+
+	if rate := mw.Rate(); math.Abs(rate-100000) > 5000 {
+		t.Errorf("expected a rate of about 100000B/s, got %.0f", rate)
+	}
+
+	if mw.Total() != 1000000 {
+		t.Errorf("Total got %d, want 1000000", mw.Total())
+	}
+
+	// After one half-life of silence, the rate should have halved.
+	before := mw.Rate()
+	clock.t = clock.t.Add(time.Second)
+
+	if rate := mw.Rate(); math.Abs(rate-before/2) > 1 {
+		t.Errorf("expected the rate to halve after a half-life, went from %.0f to %.0f", before, rate)
+	}
+}
+
+func TestMeteredReader(t *testing.T) {
+	mr := NewMeteredReader(strings.NewReader("hello world"), 0)
+	ioutil.ReadAll(mr)
+
+	if mr.Total() != 11 || mr.Rate() <= 0 {
+		t.Errorf("got Total %d and Rate %f", mr.Total(), mr.Rate())
+	}
+}
@@ -0,0 +1,174 @@
+package miscio
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+type multiWriterAt struct {
+	writers []io.WriterAt
+}
+
+// WriteAt writes p at off to each destination in turn, stopping at the first
+// error or short write.
+func (mw *multiWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	for _, w := range mw.writers {
+		n, err := w.WriteAt(p, off)
+		if err != nil {
+			return n, err
+		}
+
+		if n != len(p) {
+			return n, io.ErrShortWrite
+		}
+	}
+
+	return len(p), nil
+}
+
+// MultiWriterAt returns an io.WriterAt that duplicates each WriteAt to all the
+// provided writers at the same offset, like io.MultiWriter does for Write. The
+// writers are called sequentially; if any returns an error (or writes short,
+// reported as io.ErrShortWrite), the WriteAt stops and returns it without
+// calling the remaining writers.
+func MultiWriterAt(writers ...io.WriterAt) io.WriterAt {
+	all := make([]io.WriterAt, 0, len(writers))
+
+	for _, w := range writers {
+		if mw, ok := w.(*multiWriterAt); ok {
+			all = append(all, mw.writers...)
+		} else {
+			all = append(all, w)
+		}
+	}
+
+	return &multiWriterAt{all}
+}
+
+// SizedReaderAt is an io.ReaderAt along with the number of bytes it holds, one
+// of the parts passed to MultiReaderAt.
+type SizedReaderAt struct {
+	R    io.ReaderAt
+	Size int64
+}
+
+type multiReaderAt struct {
+	parts []SizedReaderAt
+	// starts holds the offset at which each part begins.
+	starts []int64
+	size   int64
+}
+
+// MultiReaderAt returns the logical concatenation of the given parts, e.g. the
+// chunk files a large object was stored as. A ReadAt spanning a boundary
+// between parts reads from each in turn; a part that holds fewer bytes than
+// its declared Size is reported as io.ErrUnexpectedEOF. The result is an
+// io.SectionReader, so it also supports Read, Seek and Size.
+func MultiReaderAt(parts ...SizedReaderAt) *io.SectionReader {
+	mr := &multiReaderAt{
+		parts:  append([]SizedReaderAt(nil), parts...),
+		starts: make([]int64, len(parts)),
+	}
+
+	for i, part := range parts {
+		mr.starts[i] = mr.size
+		mr.size += part.Size
+	}
+
+	return io.NewSectionReader(mr, 0, mr.size)
+}
+
+// ReadAt reads from the parts covering [off, off+len(p)).
+func (mr *multiReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrNegativeOffset, off)
+	}
+
+	if off >= mr.size {
+		return 0, io.EOF
+	}
+
+	// Find the last part starting at or before off.
+	i := sort.Search(len(mr.starts), func(i int) bool { return mr.starts[i] > off }) - 1
+
+	n := 0
+
+	for ; i < len(mr.parts) && n < len(p); i++ {
+		part := mr.parts[i]
+		rel := off + int64(n) - mr.starts[i]
+
+		want := p[n:]
+		if remaining := part.Size - rel; int64(len(want)) > remaining {
+			want = want[:remaining]
+		}
+
+		if len(want) == 0 {
+			continue
+		}
+
+		read, err := part.R.ReadAt(want, rel)
+		n += read
+
+		if read < len(want) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return n, err
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+type multiCloser struct {
+	closers []io.Closer
+	reverse bool
+	once    sync.Once
+	err     error
+}
+
+// MultiCloser returns an io.Closer that closes each of closers in order,
+// continuing past failures, and returns their errors combined as a MultiError
+// (or nil if all succeeded). Only the first call to Close closes anything;
+// later calls return the same result, so it is safe to both defer a Close and
+// call it explicitly to check the error.
+func MultiCloser(closers ...io.Closer) io.Closer {
+	return &multiCloser{closers: closers}
+}
+
+// ReverseMultiCloser is like MultiCloser, but closes closers last to first, as
+// deferred Close calls would. Pass a pipeline's layers in the order they were
+// built, innermost first, and each wrapper is closed before what it wraps.
+func ReverseMultiCloser(closers ...io.Closer) io.Closer {
+	return &multiCloser{closers: closers, reverse: true}
+}
+
+// Close implements io.Closer for the values returned by MultiCloser and
+// ReverseMultiCloser.
+func (mc *multiCloser) Close() error {
+	mc.once.Do(func() {
+		var errs []error
+
+		for i := range mc.closers {
+			c := mc.closers[i]
+			if mc.reverse {
+				c = mc.closers[len(mc.closers)-1-i]
+			}
+
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		mc.err = joinErrors(errs)
+	})
+
+	return mc.err
+}
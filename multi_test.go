@@ -0,0 +1,127 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+type errWriterAt struct{ err error }
+
+func (w errWriterAt) WriteAt(p []byte, off int64) (int, error) { return 0, w.err }
+
+func TestMultiWriterAt(t *testing.T) {
+	a := NewWriterAtReadSeeker(0)
+	b := NewWriterAtReadCloser(0)
+	mw := MultiWriterAt(a, b)
+
+	mw.WriteAt([]byte("world"), 6)
+	mw.WriteAt([]byte("hello "), 0)
+	a.Close()
+	b.CloseWrite()
+
+	for name, r := range map[string]io.Reader{"WriterAtReadSeeker": a, "WriterAtReadCloser": b} {
+		buf := make([]byte, 11)
+		if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "hello world" {
+			t.Errorf("%s got (%q, %v)", name, buf, err)
+		}
+	}
+
+	cause := errors.New("disk full")
+	c := NewWriterAtReadSeeker(0)
+
+	if _, err := MultiWriterAt(errWriterAt{cause}, c).WriteAt([]byte("x"), 0); err != cause {
+		t.Errorf("expected the first writer's error, got %v", err)
+	}
+
+	if n, _ := c.ReadAt(make([]byte, 1), 0); n != 0 {
+		t.Errorf("expected writers after a failure not to be called")
+	}
+}
+
+func TestMultiReaderAt(t *testing.T) {
+	mr := MultiReaderAt(
+		SizedReaderAt{strings.NewReader("hello"), 5},
+		SizedReaderAt{strings.NewReader(""), 0},
+		SizedReaderAt{strings.NewReader(" wor"), 4},
+		SizedReaderAt{strings.NewReader("ld"), 2},
+	)
+
+	if mr.Size() != 11 {
+		t.Errorf("Size got %d, want 11", mr.Size())
+	}
+
+	all, err := ioutil.ReadAll(mr)
+	if err != nil || string(all) != "hello world" {
+		t.Errorf("reading everything got (%q, %v)", all, err)
+	}
+
+	buf := make([]byte, 6)
+	if n, err := mr.ReadAt(buf, 3); err != nil || string(buf[:n]) != "lo wor" {
+		t.Errorf("ReadAt spanning parts got (%q, %v)", buf[:n], err)
+	}
+
+	if n, err := mr.ReadAt(buf, 8); n != 3 || err != io.EOF {
+		t.Errorf("ReadAt past the end got (%d, %v), want (3, io.EOF)", n, err)
+	}
+
+	short := MultiReaderAt(SizedReaderAt{strings.NewReader("abc"), 5}, SizedReaderAt{strings.NewReader("de"), 2})
+	if _, err := short.ReadAt(make([]byte, 7), 0); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF for a short part, got %v", err)
+	}
+}
+
+// closeRecorder records the order its members are closed in.
+type closeRecorder struct {
+	order []string
+}
+
+func (cr *closeRecorder) closer(name string, err error) io.Closer {
+	return CloserFunc(func() error {
+		cr.order = append(cr.order, name)
+
+		return err
+	})
+}
+
+func TestMultiCloser(t *testing.T) {
+	errA, errC := errors.New("a failed"), os.ErrClosed
+
+	var cr closeRecorder
+
+	mc := MultiCloser(cr.closer("a", errA), cr.closer("b", nil), cr.closer("c", errC))
+
+	err := mc.Close()
+	if !errors.Is(err, errA) || !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected both errors to be reported, got %v", err)
+	}
+
+	if err.Error() != "a failed\n"+os.ErrClosed.Error() {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+
+	if again := mc.Close(); again == nil || again.Error() != err.Error() {
+		t.Errorf("expected a second Close to return the same error, got %v", again)
+	}
+
+	if strings.Join(cr.order, "") != "abc" {
+		t.Errorf("expected each closer to be closed once in order, got %v", cr.order)
+	}
+
+	cr.order = nil
+
+	if err := ReverseMultiCloser(cr.closer("a", nil), cr.closer("b", errA)).Close(); err != errA {
+		t.Errorf("expected a single error to be returned as is, got %v", err)
+	}
+
+	if strings.Join(cr.order, "") != "ba" {
+		t.Errorf("expected reverse order, got %v", cr.order)
+	}
+
+	if err := MultiCloser().Close(); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
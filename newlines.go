@@ -0,0 +1,101 @@
+package miscio
+
+import "io"
+
+// newlineNormalizer converts CRLF and lone CR line endings to LF. A CR is
+// converted as soon as it is seen, and an LF directly following it dropped,
+// so no lookahead across chunk boundaries is needed.
+type newlineNormalizer struct {
+	afterCR bool
+}
+
+// normalize converts src into dst, which must be at least as long, and
+// returns the number of bytes written to dst. dst and src may be the same
+// slice.
+func (nn *newlineNormalizer) normalize(dst, src []byte) int {
+	n := 0
+
+	for _, b := range src {
+		switch {
+		case b == '\n' && nn.afterCR:
+			nn.afterCR = false
+
+			continue
+		case b == '\r':
+			b = '\n'
+			nn.afterCR = true
+		default:
+			nn.afterCR = false
+		}
+
+		dst[n] = b
+		n++
+	}
+
+	return n
+}
+
+type normalizeNewlinesReader struct {
+	r  io.Reader
+	nn newlineNormalizer
+}
+
+// NormalizeNewlinesReader returns an io.Reader that reads from r, converting
+// CRLF and lone CR line endings to LF, including a CRLF split across two
+// Reads of r.
+func NormalizeNewlinesReader(r io.Reader) io.Reader {
+	return &normalizeNewlinesReader{r: r}
+}
+
+func (nr *normalizeNewlinesReader) Read(p []byte) (int, error) {
+	for {
+		n, err := nr.r.Read(p)
+		n = nr.nn.normalize(p, p[:n])
+
+		// Don't report (0, nil) just because the chunk was the LF of a CRLF.
+		if n > 0 || err != nil || len(p) == 0 {
+			return n, err
+		}
+	}
+}
+
+type normalizeNewlinesWriter struct {
+	w   io.Writer
+	nn  newlineNormalizer
+	out []byte
+}
+
+// NormalizeNewlinesWriter returns an io.Writer that writes to w, converting
+// CRLF and lone CR line endings to LF, including a CRLF split across two
+// Writes. The returned writer is a Flusher, flushing w if w is one. It is not
+// safe for concurrent use.
+func NormalizeNewlinesWriter(w io.Writer) io.Writer {
+	return &normalizeNewlinesWriter{w: w}
+}
+
+func (nw *normalizeNewlinesWriter) Write(p []byte) (int, error) {
+	if cap(nw.out) < len(p) {
+		nw.out = make([]byte, len(p))
+	}
+
+	out := nw.out[:nw.nn.normalize(nw.out[:len(p)], p)]
+	if len(out) == 0 {
+		return len(p), nil
+	}
+
+	n, err := nw.w.Write(out)
+	if err == nil && n < len(out) {
+		err = io.ErrShortWrite
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush implements Flusher by flushing the underlying writer.
+func (nw *normalizeNewlinesWriter) Flush() error {
+	return flushNext(nw.w)
+}
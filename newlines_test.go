@@ -0,0 +1,37 @@
+package miscio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const crlfInput = "dos line\r\nmac line\rblank\r\n\r\nunix line\n\r"
+
+const crlfExpected = "dos line\nmac line\nblank\n\nunix line\n\n"
+
+func TestNormalizeNewlinesReader(t *testing.T) {
+	// OneByteReader splits every CRLF across two Reads.
+	got, err := ioutil.ReadAll(NormalizeNewlinesReader(iotest.OneByteReader(strings.NewReader(crlfInput))))
+	if err != nil || string(got) != crlfExpected {
+		t.Errorf("got (%q, %v), want %q", got, err, crlfExpected)
+	}
+}
+
+func TestNormalizeNewlinesWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	nw := NormalizeNewlinesWriter(&dst)
+
+	for _, chunk := range []string{"dos line\r", "\nmac line\rblank\r", "\n\r\nunix line\n\r"} {
+		if n, err := nw.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) got (%d, %v)", chunk, n, err)
+		}
+	}
+
+	if dst.String() != crlfExpected {
+		t.Errorf("got %q, want %q", dst.String(), crlfExpected)
+	}
+}
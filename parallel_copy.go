@@ -0,0 +1,147 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// errParallelCopyDone stops ParallelCopy's workers once the copy has ended.
+var errParallelCopyDone = errors.New("miscio: parallel copy finished")
+
+const (
+	defaultParallelCopyConcurrency = 4
+	defaultParallelCopyChunkSize   = 1 << 20
+)
+
+type parallelCopyConfig struct {
+	concurrency int
+	chunkSize   int
+}
+
+// ParallelCopyOption configures ParallelCopy.
+type ParallelCopyOption func(cfg *parallelCopyConfig)
+
+// WithConcurrency sets how many goroutines ParallelCopy reads from src with.
+// The default is 4.
+func WithConcurrency(n int) ParallelCopyOption {
+	return func(cfg *parallelCopyConfig) {
+		cfg.concurrency = n
+	}
+}
+
+// WithChunkSize sets the size of each ReadAt ParallelCopy issues against src.
+// The default is 1MiB.
+func WithChunkSize(n int) ParallelCopyOption {
+	return func(cfg *parallelCopyConfig) {
+		cfg.chunkSize = n
+	}
+}
+
+// ParallelCopy copies size bytes from src to dst, reading chunks of src
+// concurrently and writing them to dst strictly in order. The chunks are
+// reassembled through a WriterAtReadCloser whose memory use is bounded to
+// roughly twice concurrency*chunkSize: readers that get too far ahead of dst
+// wait for it to catch up.
+//
+// ParallelCopy returns the number of bytes written to dst, and the first error
+// encountered reading src or writing dst. A src that ends before size bytes is
+// reported as io.ErrUnexpectedEOF.
+func ParallelCopy(dst io.Writer, src io.ReaderAt, size int64, opts ...ParallelCopyOption) (int64, error) {
+	cfg := parallelCopyConfig{
+		concurrency: defaultParallelCopyConcurrency,
+		chunkSize:   defaultParallelCopyChunkSize,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+
+	if cfg.chunkSize < 1 {
+		cfg.chunkSize = defaultParallelCopyChunkSize
+	}
+
+	wr := NewWriterAtReadCloser(0,
+		WithBlockingRead(),
+		WithSize(size),
+		WithMaxBuffered(2*int64(cfg.concurrency)*int64(cfg.chunkSize)),
+	)
+	defer wr.Close()
+
+	offsets := make(chan int64)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < cfg.concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			copyChunks(wr, src, size, cfg.chunkSize, offsets)
+		}()
+	}
+
+	go func() {
+		defer close(offsets)
+
+		for off := int64(0); off < size; off += int64(cfg.chunkSize) {
+			offsets <- off
+		}
+	}()
+
+	n, err := wr.WriteTo(dst)
+	if err == nil && n < size {
+		// Only possible if the WriterAtReadCloser was closed early, which
+		// copyChunks always does with an error.
+		err = io.ErrUnexpectedEOF
+	}
+
+	// Unblock any workers still writing, and let the dispatcher drain.
+	wr.CloseWithError(errParallelCopyDone)
+
+	go func() {
+		for range offsets { // nolint:revive
+			// Discard chunks that will never be read.
+		}
+	}()
+
+	wg.Wait()
+
+	return n, err
+}
+
+// copyChunks reads each chunk of src named by offsets, writing it to wr. On
+// failure it aborts wr with the error and stops.
+func copyChunks(wr *WriterAtReadCloser, src io.ReaderAt, size int64, chunkSize int, offsets <-chan int64) {
+	buf := make([]byte, chunkSize)
+
+	for off := range offsets {
+		chunk := buf
+		if remaining := size - off; remaining < int64(len(chunk)) {
+			chunk = chunk[:remaining]
+		}
+
+		n, err := src.ReadAt(chunk, off)
+		if n == len(chunk) {
+			err = nil
+		} else if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			wr.CloseWithError(err)
+
+			return
+		}
+
+		if _, err := wr.WriteAt(chunk, off); err != nil {
+			wr.CloseWithError(err)
+
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package miscio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestParallelCopy(t *testing.T) {
+	src := make([]byte, 1<<20+123)
+	rand.New(rand.NewSource(1)).Read(src)
+
+	var dst bytes.Buffer
+
+	n, err := ParallelCopy(&dst, bytes.NewReader(src), int64(len(src)), WithConcurrency(8), WithChunkSize(4096))
+	if err != nil || n != int64(len(src)) {
+		t.Fatalf("ParallelCopy got (%d, %v)", n, err)
+	}
+
+	if !bytes.Equal(dst.Bytes(), src) {
+		t.Errorf("ParallelCopy output does not match source")
+	}
+}
+
+type failingReaderAt struct {
+	r      io.ReaderAt
+	failAt int64
+	err    error
+}
+
+func (f *failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.failAt {
+		return 0, f.err
+	}
+
+	return f.r.ReadAt(p, off)
+}
+
+func TestParallelCopyErrors(t *testing.T) {
+	src := bytes.Repeat([]byte("x"), 64<<10)
+	cause := errors.New("range request failed")
+
+	var dst bytes.Buffer
+
+	_, err := ParallelCopy(&dst, &failingReaderAt{bytes.NewReader(src), 32 << 10, cause}, int64(len(src)),
+		WithChunkSize(1024))
+	if err != cause {
+		t.Errorf("expected the source error, got %v", err)
+	}
+
+	_, err = ParallelCopy(&dst, bytes.NewReader(src), int64(len(src))+10, WithChunkSize(1024))
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF for a short source, got %v", err)
+	}
+}
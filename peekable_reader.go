@@ -0,0 +1,155 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// defaultPeekableReaderSize is the initial buffer size of a PeekableReader
+// created with a non-positive size.
+const defaultPeekableReaderSize = 512
+
+// maxConsecutiveEmptyReads bounds how many calls in a row a PeekableReader
+// accepts returning no data and no error before reporting io.ErrNoProgress.
+const maxConsecutiveEmptyReads = 100
+
+// PeekableReader wraps an io.Reader, adding Peek and Discard, for sniffing a
+// stream's format before deciding how to read it. Unlike bufio.Reader, its
+// buffer grows as needed, so a Peek of any size succeeds, and it has none of
+// bufio.Reader's other methods. Reads are served from the buffer first; once
+// it is empty they go straight to the underlying reader.
+//
+// A PeekableReader is not safe for concurrent use.
+type PeekableReader struct {
+	r    io.Reader
+	buf  []byte
+	rpos int // buf[rpos:wpos] is unread.
+	wpos int
+	err  error // Sticky error from r, returned once the buffer is drained.
+}
+
+var _ io.Reader = (*PeekableReader)(nil)
+
+// NewPeekableReader returns a PeekableReader reading from r, whose buffer
+// starts at size bytes. If size is not positive, a small default is used.
+func NewPeekableReader(r io.Reader, size int) *PeekableReader {
+	if size <= 0 {
+		size = defaultPeekableReaderSize
+	}
+
+	return &PeekableReader{r: r, buf: make([]byte, size)}
+}
+
+// Peek returns the next n bytes without consuming them, reading from the
+// underlying reader as needed. The returned slice is only valid until the next
+// call to a PeekableReader method. If fewer than n bytes are returned, the
+// error says why: io.EOF if the stream ended first.
+func (pr *PeekableReader) Peek(n int) ([]byte, error) {
+	if n < 0 {
+		n = 0
+	}
+
+	pr.fill(n)
+
+	if avail := pr.wpos - pr.rpos; avail < n {
+		return pr.buf[pr.rpos:pr.wpos], pr.err
+	}
+
+	return pr.buf[pr.rpos : pr.rpos+n], nil
+}
+
+// fill reads from r until at least n bytes are buffered, or r fails, growing
+// the buffer if it can't hold n bytes.
+func (pr *PeekableReader) fill(n int) {
+	if pr.wpos-pr.rpos >= n || pr.err != nil {
+		return
+	}
+
+	if len(pr.buf)-pr.rpos < n {
+		buf := pr.buf
+		if len(buf) < n {
+			buf = make([]byte, n)
+		}
+
+		pr.wpos = copy(buf, pr.buf[pr.rpos:pr.wpos])
+		pr.rpos = 0
+		pr.buf = buf
+	}
+
+	for empty := 0; pr.wpos-pr.rpos < n; {
+		m, err := pr.r.Read(pr.buf[pr.wpos:])
+		pr.wpos += m
+
+		if err != nil {
+			pr.err = err
+
+			return
+		}
+
+		if m > 0 {
+			empty = 0
+
+			continue
+		}
+
+		if empty++; empty >= maxConsecutiveEmptyReads {
+			pr.err = io.ErrNoProgress
+
+			return
+		}
+	}
+}
+
+// Discard skips the next n bytes, returning how many were skipped. If fewer
+// than n were, the error says why.
+func (pr *PeekableReader) Discard(n int) (int, error) {
+	if n <= 0 {
+		return 0, nil
+	}
+
+	skipped := pr.wpos - pr.rpos
+	if skipped >= n {
+		pr.rpos += n
+
+		return n, nil
+	}
+
+	pr.rpos, pr.wpos = 0, 0
+
+	if pr.err != nil {
+		return skipped, pr.err
+	}
+
+	m, err := io.CopyN(ioutil.Discard, pr.r, int64(n-skipped))
+	if err != nil {
+		pr.err = err
+	}
+
+	return skipped + int(m), err
+}
+
+// Read implements io.Reader for PeekableReader.
+func (pr *PeekableReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if pr.rpos < pr.wpos {
+		n := copy(p, pr.buf[pr.rpos:pr.wpos])
+		pr.rpos += n
+
+		return n, nil
+	}
+
+	if pr.err != nil {
+		return 0, pr.err
+	}
+
+	return pr.r.Read(p)
+}
+
+// Buffered returns the number of bytes that can be read from the buffer
+// without reading from the underlying reader.
+func (pr *PeekableReader) Buffered() int {
+	return pr.wpos - pr.rpos
+}
@@ -0,0 +1,70 @@
+package miscio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPeekableReader(t *testing.T) {
+	data := "\x1f\x8b" + strings.Repeat("payload ", 100)
+	pr := NewPeekableReader(iotest.OneByteReader(strings.NewReader(data)), 4)
+
+	magic, err := pr.Peek(2)
+	if err != nil || !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		t.Fatalf("expected the gzip magic, got (%q, %v)", magic, err)
+	}
+
+	// A Peek larger than the initial buffer grows it.
+	big, err := pr.Peek(500)
+	if err != nil || string(big) != data[:500] {
+		t.Fatalf("expected a 500-byte peek, got %d bytes and %v", len(big), err)
+	}
+
+	if n, err := pr.Discard(2); n != 2 || err != nil {
+		t.Fatalf("Discard returned (%d, %v)", n, err)
+	}
+
+	rest, err := ioutil.ReadAll(pr)
+	if err != nil || string(rest) != data[2:] {
+		t.Fatalf("expected the rest of the data, got %d bytes and %v", len(rest), err)
+	}
+
+	if p, err := pr.Peek(1); len(p) != 0 || err != io.EOF {
+		t.Errorf("expected io.EOF at the end, got (%q, %v)", p, err)
+	}
+}
+
+func TestPeekableReaderShort(t *testing.T) {
+	pr := NewPeekableReader(strings.NewReader("short"), 0)
+
+	p, err := pr.Peek(10)
+	if string(p) != "short" || err != io.EOF {
+		t.Fatalf("expected (short, EOF), got (%q, %v)", p, err)
+	}
+
+	buf := make([]byte, 3)
+	if n, err := pr.Read(buf); string(buf[:n]) != "sho" || err != nil {
+		t.Fatalf("expected (sho, nil), got (%q, %v)", buf[:n], err)
+	}
+
+	if n, err := pr.Discard(5); n != 2 || err != io.EOF {
+		t.Errorf("expected (2, EOF) discarding past the end, got (%d, %v)", n, err)
+	}
+}
+
+func TestPeekableReaderDiscardUnbuffered(t *testing.T) {
+	pr := NewPeekableReader(strings.NewReader("0123456789"), 2)
+	pr.Peek(2)
+
+	if n, err := pr.Discard(7); n != 7 || err != nil {
+		t.Fatalf("Discard returned (%d, %v)", n, err)
+	}
+
+	if rest, _ := ioutil.ReadAll(pr); string(rest) != "789" {
+		t.Errorf("expected 789, got %q", rest)
+	}
+}
@@ -0,0 +1,254 @@
+package miscio
+
+import (
+	"context"
+	"hash"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// WriterStage is one layer of a WriterPipeline: a function wrapping an
+// io.Writer in another, optionally under a name by which the pipeline exposes
+// the wrapper it creates.
+type WriterStage struct {
+	name string
+	wrap func(w io.Writer) io.Writer
+}
+
+// NewWriterStage returns a WriterStage applying wrap. If name is not empty, the
+// wrapper is available from the pipeline's Stage method under that name.
+func NewWriterStage(name string, wrap func(w io.Writer) io.Writer) WriterStage {
+	return WriterStage{name: name, wrap: wrap}
+}
+
+// CountWrites is a WriterStage adding a CountingWriter, named name.
+func CountWrites(name string) WriterStage {
+	return NewWriterStage(name, func(w io.Writer) io.Writer { return NewCountingWriter(w) })
+}
+
+// HashWrites is a WriterStage adding a HashWriter with hash h, named name.
+func HashWrites(name string, h hash.Hash) WriterStage {
+	return NewWriterStage(name, func(w io.Writer) io.Writer { return NewHashWriter(w, h) })
+}
+
+// PrefixWrites is a WriterStage adding a PrefixWriter with the given prefix.
+func PrefixWrites(prefix string) WriterStage {
+	return NewWriterStage("", func(w io.Writer) io.Writer { return NewPrefixWriter(w, prefix) })
+}
+
+// RedactWrites is a WriterStage adding a RedactingWriter with the given
+// replacement and patterns, and DefaultRedactMaxMatch.
+func RedactWrites(replacement string, patterns ...*regexp.Regexp) WriterStage {
+	return NewWriterStage("", func(w io.Writer) io.Writer {
+		return NewRedactingWriter(w, replacement, DefaultRedactMaxMatch, patterns...)
+	})
+}
+
+// RateLimitWrites is a WriterStage adding a RateLimitedWriter drawing on l.
+func RateLimitWrites(ctx context.Context, l *RateLimiter) WriterStage {
+	return NewWriterStage("", func(w io.Writer) io.Writer { return NewRateLimitedWriter(ctx, w, l) })
+}
+
+// WriterPipeline is an io.Writer composed of a destination and a chain of
+// wrappers, as built by WrapWriter. Flush and Close apply to every layer, so a
+// composed chain can be shut down with one call.
+type WriterPipeline struct {
+	io.Writer
+
+	dst    io.Writer
+	layers []io.Writer // Outermost first.
+	named  map[string]io.Writer
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var (
+	_ io.WriteCloser = (*WriterPipeline)(nil)
+	_ Flusher        = (*WriterPipeline)(nil)
+)
+
+// WrapWriter wraps dst in each of stages, which are listed in the order data
+// passes through them: writes to the pipeline go to the first stage, which
+// writes to the second, and so on, until the last writes to dst.
+//
+//	p := miscio.WrapWriter(f,
+//		miscio.RedactWrites("***", secrets...),
+//		miscio.PrefixWrites("[job] "),
+//		miscio.CountWrites("bytes"),
+//	)
+//	defer p.Close()
+//	...
+//	n := p.Stage("bytes").(*miscio.CountingWriter).Count()
+func WrapWriter(dst io.Writer, stages ...WriterStage) *WriterPipeline {
+	p := &WriterPipeline{
+		dst:    dst,
+		layers: make([]io.Writer, len(stages)),
+		named:  make(map[string]io.Writer),
+	}
+
+	w := dst
+
+	for i := len(stages) - 1; i >= 0; i-- {
+		w = stages[i].wrap(w)
+		p.layers[i] = w
+
+		if stages[i].name != "" {
+			p.named[stages[i].name] = w
+		}
+	}
+
+	p.Writer = w
+
+	return p
+}
+
+// Stage returns the wrapper created by the stage with the given name, or nil
+// if there is none. Type-assert it to reach its instruments.
+func (p *WriterPipeline) Stage(name string) io.Writer {
+	return p.named[name]
+}
+
+// Flush implements Flusher for WriterPipeline by flushing every layer that can
+// be flushed, outermost first, then dst. This way data held back is pushed all
+// the way through, even by wrappers that don't propagate Flush themselves.
+// Every layer is flushed even if one fails; the errors are combined.
+func (p *WriterPipeline) Flush() error {
+	var errs []error
+
+	for _, w := range append(p.layers, p.dst) {
+		if err := flushNext(w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+// Close closes the pipeline, outermost layer first, then dst: layers that are
+// io.Closers are closed, and other layers are flushed. dst is closed if it is
+// an io.Closer. Every layer is closed even if one fails; the errors are
+// combined. Only the first call does anything; later calls return the same
+// result.
+func (p *WriterPipeline) Close() error {
+	p.closeOnce.Do(func() {
+		var errs []error
+
+		for _, w := range append(p.layers, p.dst) {
+			var err error
+
+			if c, ok := w.(io.Closer); ok {
+				err = c.Close()
+			} else {
+				err = flushNext(w)
+			}
+
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		p.closeErr = joinErrors(errs)
+	})
+
+	return p.closeErr
+}
+
+// ReaderStage is one layer of a ReaderPipeline: a function wrapping an
+// io.Reader in another, optionally under a name by which the pipeline exposes
+// the wrapper it creates.
+type ReaderStage struct {
+	name string
+	wrap func(r io.Reader) io.Reader
+}
+
+// NewReaderStage returns a ReaderStage applying wrap. If name is not empty, the
+// wrapper is available from the pipeline's Stage method under that name.
+func NewReaderStage(name string, wrap func(r io.Reader) io.Reader) ReaderStage {
+	return ReaderStage{name: name, wrap: wrap}
+}
+
+// CountReads is a ReaderStage adding a CountingReader, named name.
+func CountReads(name string) ReaderStage {
+	return NewReaderStage(name, func(r io.Reader) io.Reader { return NewCountingReader(r) })
+}
+
+// HashReads is a ReaderStage adding a HashReader with hash h, named name.
+func HashReads(name string, h hash.Hash) ReaderStage {
+	return NewReaderStage(name, func(r io.Reader) io.Reader { return NewHashReader(r, h) })
+}
+
+// RateLimitReads is a ReaderStage adding a RateLimitedReader drawing on l.
+func RateLimitReads(ctx context.Context, l *RateLimiter) ReaderStage {
+	return NewReaderStage("", func(r io.Reader) io.Reader { return NewRateLimitedReader(ctx, r, l) })
+}
+
+// ReaderPipeline is an io.Reader composed of a source and a chain of wrappers,
+// as built by WrapReader.
+type ReaderPipeline struct {
+	io.Reader
+
+	src    io.Reader
+	layers []io.Reader // Outermost first.
+	named  map[string]io.Reader
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var _ io.ReadCloser = (*ReaderPipeline)(nil)
+
+// WrapReader wraps src in each of stages, which are listed in the order data
+// passes through them: the first stage reads from src, the second from the
+// first, and so on, and reads from the pipeline come from the last.
+func WrapReader(src io.Reader, stages ...ReaderStage) *ReaderPipeline {
+	p := &ReaderPipeline{
+		src:    src,
+		layers: make([]io.Reader, len(stages)),
+		named:  make(map[string]io.Reader),
+	}
+
+	r := src
+
+	for i, stage := range stages {
+		r = stage.wrap(r)
+		p.layers[len(stages)-1-i] = r
+
+		if stage.name != "" {
+			p.named[stage.name] = r
+		}
+	}
+
+	p.Reader = r
+
+	return p
+}
+
+// Stage returns the wrapper created by the stage with the given name, or nil
+// if there is none. Type-assert it to reach its instruments.
+func (p *ReaderPipeline) Stage(name string) io.Reader {
+	return p.named[name]
+}
+
+// Close closes every layer that is an io.Closer, outermost first, then src if
+// it is an io.Closer. Every layer is closed even if one fails; the errors are
+// combined. Only the first call does anything; later calls return the same
+// result.
+func (p *ReaderPipeline) Close() error {
+	p.closeOnce.Do(func() {
+		var errs []error
+
+		for _, r := range append(p.layers, p.src) {
+			if c, ok := r.(io.Closer); ok {
+				if err := c.Close(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+
+		p.closeErr = joinErrors(errs)
+	})
+
+	return p.closeErr
+}
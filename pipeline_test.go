@@ -0,0 +1,130 @@
+package miscio
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWrapWriter(t *testing.T) {
+	var out bytes.Buffer
+
+	bw := bufio.NewWriter(&out)
+	p := WrapWriter(bw,
+		RedactWrites("***", regexp.MustCompile(`hunter2`)),
+		CountWrites("raw"),
+		PrefixWrites("> "),
+		HashWrites("sum", sha256.New()),
+		CountWrites("out"),
+	)
+
+	fmt.Fprint(p, "password: hunter2\nno newline")
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	expected := "> password: ***\n> no newline"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+
+	if n := p.Stage("raw").(*CountingWriter).Count(); n != int64(len("password: ***\nno newline")) {
+		t.Errorf("unexpected count after redaction: %d", n)
+	}
+
+	if n := p.Stage("out").(*CountingWriter).Count(); n != int64(len(expected)) {
+		t.Errorf("unexpected count of output: %d", n)
+	}
+
+	sum := sha256.Sum256([]byte(expected))
+	if got := p.Stage("sum").(*HashWriter).Sum(); !bytes.Equal(got, sum[:]) {
+		t.Errorf("expected hash %x, got %x", sum, got)
+	}
+
+	if p.Stage("nope") != nil {
+		t.Error("expected no stage for an unknown name")
+	}
+}
+
+func TestWriterPipelineClose(t *testing.T) {
+	errA := errors.New("a failed")
+
+	var cr closeRecorder
+
+	stage := func(name string, err error) WriterStage {
+		return NewWriterStage("", func(w io.Writer) io.Writer {
+			return struct {
+				io.Writer
+				io.Closer
+			}{w, cr.closer(name, err)}
+		})
+	}
+
+	dst := struct {
+		io.Writer
+		io.Closer
+	}{ioutil.Discard, cr.closer("dst", os.ErrClosed)}
+
+	p := WrapWriter(dst, stage("a", errA), stage("b", nil))
+
+	err := p.Close()
+	if !errors.Is(err, errA) || !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected both errors to be reported, got %v", err)
+	}
+
+	if got := strings.Join(cr.order, ","); got != "a,b,dst" {
+		t.Errorf("expected outermost first, got %s", got)
+	}
+
+	if err2 := p.Close(); !errors.Is(err2, errA) || len(cr.order) != 3 {
+		t.Errorf("expected a second Close to do nothing, got %v after %v", err2, cr.order)
+	}
+}
+
+func TestWrapReader(t *testing.T) {
+	const data = "read me through a pipeline"
+
+	var cr closeRecorder
+
+	src := struct {
+		io.Reader
+		io.Closer
+	}{strings.NewReader(data), cr.closer("src", nil)}
+
+	p := WrapReader(src,
+		CountReads("in"),
+		HashReads("sum", sha256.New()),
+		NewReaderStage("", func(r io.Reader) io.Reader { return io.LimitReader(r, 4) }),
+		CountReads("out"),
+	)
+
+	got, err := ioutil.ReadAll(p)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+
+	if string(got) != data[:4] {
+		t.Errorf("expected %q, got %q", data[:4], got)
+	}
+
+	if n := p.Stage("out").(*CountingReader).Count(); n != 4 {
+		t.Errorf("expected 4 bytes out, got %d", n)
+	}
+
+	if n := p.Stage("in").(*CountingReader).Count(); n < 4 {
+		t.Errorf("expected at least 4 bytes read from src, got %d", n)
+	}
+
+	if err := p.Close(); err != nil || len(cr.order) != 1 {
+		t.Errorf("expected Close to close src, got %v after %v", err, cr.order)
+	}
+}
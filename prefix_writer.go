@@ -0,0 +1,113 @@
+package miscio
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// PrefixWriter wraps an io.Writer, inserting a prefix at the start of every
+// line written through it, e.g. to tag the interleaved output of several
+// subprocesses. Lines may be split across any number of Writes: the prefix is
+// inserted once, before the first byte of each line. Each Write results in a
+// single Write to the underlying writer. It is safe for concurrent use, though
+// concurrent writers' partial lines will of course interleave.
+type PrefixWriter struct {
+	m       sync.Mutex
+	w       io.Writer
+	prefix  func() string
+	midLine bool
+	out     []byte
+	spans   []prefixSpan
+}
+
+// prefixSpan records where a prefix was inserted into a PrefixWriter's output.
+type prefixSpan struct {
+	at, n int
+}
+
+var (
+	_ io.Writer = (*PrefixWriter)(nil)
+	_ Flusher   = (*PrefixWriter)(nil)
+)
+
+// NewPrefixWriter returns a PrefixWriter that starts every line with prefix.
+func NewPrefixWriter(w io.Writer, prefix string) *PrefixWriter {
+	return NewPrefixFuncWriter(w, func() string { return prefix })
+}
+
+// NewPrefixFuncWriter returns a PrefixWriter that starts every line with the
+// result of calling prefix as the line's first byte is written. Calls to
+// prefix are serialized.
+func NewPrefixFuncWriter(w io.Writer, prefix func() string) *PrefixWriter {
+	return &PrefixWriter{w: w, prefix: prefix}
+}
+
+// Write implements io.Writer for PrefixWriter. The returned count covers only
+// bytes of p, not inserted prefixes.
+func (pw *PrefixWriter) Write(p []byte) (int, error) {
+	pw.m.Lock()
+	defer pw.m.Unlock()
+
+	pw.out = pw.out[:0]
+	pw.spans = pw.spans[:0]
+
+	for rest := p; len(rest) > 0; {
+		if !pw.midLine {
+			prefix := pw.prefix()
+			pw.spans = append(pw.spans, prefixSpan{at: len(pw.out), n: len(prefix)})
+			pw.out = append(pw.out, prefix...)
+			pw.midLine = true
+		}
+
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i+1]
+			pw.midLine = false
+		}
+
+		pw.out = append(pw.out, line...)
+		rest = rest[len(line):]
+	}
+
+	n, err := pw.w.Write(pw.out)
+	if err == nil && n < len(pw.out) {
+		err = io.ErrShortWrite
+	}
+
+	if err != nil {
+		return pw.inputBytes(n), err
+	}
+
+	return len(p), nil
+}
+
+// Flush implements Flusher for PrefixWriter. A PrefixWriter holds nothing back
+// itself, so it only flushes the underlying writer.
+func (pw *PrefixWriter) Flush() error {
+	pw.m.Lock()
+	defer pw.m.Unlock()
+
+	return flushNext(pw.w)
+}
+
+// inputBytes converts a count of output bytes written into a count of bytes of
+// the input, by discounting the prefixes among them. It must be called with
+// pw.m held.
+func (pw *PrefixWriter) inputBytes(n int) int {
+	in := n
+
+	for _, span := range pw.spans {
+		if span.at >= n {
+			break
+		}
+
+		if covered := n - span.at; covered < span.n {
+			in -= covered
+		} else {
+			in -= span.n
+		}
+	}
+
+	return in
+}
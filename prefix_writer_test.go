@@ -0,0 +1,60 @@
+package miscio
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	pw := NewPrefixWriter(&dst, "[worker-3] ")
+
+	for _, chunk := range []string{"hel", "lo\nwor", "ld\n", "\n", "partial"} {
+		if n, err := pw.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) got (%d, %v)", chunk, n, err)
+		}
+	}
+
+	expected := "[worker-3] hello\n[worker-3] world\n[worker-3] \n[worker-3] partial"
+	if dst.String() != expected {
+		t.Errorf("got %q, want %q", dst.String(), expected)
+	}
+}
+
+func TestPrefixFuncWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	i := 0
+	pw := NewPrefixFuncWriter(&dst, func() string {
+		i++
+
+		return fmt.Sprintf("%d: ", i)
+	})
+
+	pw.Write([]byte("a\nb\nc"))
+
+	if dst.String() != "1: a\n2: b\n3: c" {
+		t.Errorf("got %q", dst.String())
+	}
+}
+
+type shortWriter struct{ max int }
+
+func (sw shortWriter) Write(p []byte) (int, error) {
+	if len(p) > sw.max {
+		return sw.max, nil
+	}
+
+	return len(p), nil
+}
+
+func TestPrefixWriterShortWrite(t *testing.T) {
+	pw := NewPrefixWriter(shortWriter{max: 6}, ">> ")
+
+	// ">> ab\n>> cd": 6 output bytes cover ">> ab\n", i.e. 3 input bytes.
+	if n, err := pw.Write([]byte("ab\ncd")); n != 3 || err == nil {
+		t.Errorf("short write got (%d, %v), want (3, io.ErrShortWrite)", n, err)
+	}
+}
@@ -0,0 +1,70 @@
+package miscio
+
+import "io"
+
+// RangeFuncReaderAt adapts a function that fetches byte ranges, such as an HTTP
+// Range request or an object store's ranged GET, to io.ReaderAt, so it can be
+// used with io.SectionReader, CachingReaderAt, ParallelCopy and so on.
+//
+// RangeFuncReaderAt is safe for concurrent use if its fetch function is.
+type RangeFuncReaderAt struct {
+	size  int64
+	fetch func(off, length int64) ([]byte, error)
+}
+
+var _ io.ReaderAt = (*RangeFuncReaderAt)(nil)
+
+// NewRangeFuncReaderAt returns a RangeFuncReaderAt over a source of size bytes,
+// calling fetch for each range read. fetch may return fewer bytes than asked
+// for; the rest is fetched with further calls. Data returned beyond length is
+// ignored.
+func NewRangeFuncReaderAt(size int64, fetch func(off, length int64) ([]byte, error)) *RangeFuncReaderAt {
+	return &RangeFuncReaderAt{size: size, fetch: fetch}
+}
+
+// ReadAt implements io.ReaderAt for RangeFuncReaderAt. Reads are clipped to the
+// size, returning io.EOF if they extend past it, without fetching beyond it. If
+// fetch returns no data (or io.EOF) before the size is reached, ReadAt returns
+// io.ErrUnexpectedEOF.
+func (rf *RangeFuncReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	if off >= rf.size {
+		return 0, io.EOF
+	}
+
+	want := len(p)
+	if remaining := rf.size - off; remaining < int64(want) {
+		want = int(remaining)
+	}
+
+	n := 0
+
+	for n < want {
+		data, err := rf.fetch(off+int64(n), int64(want-n))
+		c := copy(p[n:want], data)
+		n += c
+
+		switch {
+		case err == io.EOF || (err == nil && c == 0):
+			if n < want {
+				return n, io.ErrUnexpectedEOF
+			}
+		case err != nil:
+			return n, err
+		}
+	}
+
+	if want < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Size returns the size of the source.
+func (rf *RangeFuncReaderAt) Size() int64 {
+	return rf.size
+}
@@ -0,0 +1,72 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRangeFuncReaderAt(t *testing.T) {
+	data := []byte("0123456789")
+
+	var calls [][2]int64
+
+	rf := NewRangeFuncReaderAt(int64(len(data)), func(off, length int64) ([]byte, error) {
+		calls = append(calls, [2]int64{off, length})
+
+		// Serve at most 3 bytes at a time, like a stingy server.
+		if length > 3 {
+			length = 3
+		}
+
+		return data[off : off+length], nil
+	})
+
+	buf := make([]byte, 8)
+	if n, err := rf.ReadAt(buf, 1); err != nil || string(buf[:n]) != "12345678" {
+		t.Errorf("ReadAt got (%q, %v)", buf[:n], err)
+	}
+
+	if len(calls) != 3 {
+		t.Errorf("expected short fetches to be retried for the rest, got calls %v", calls)
+	}
+
+	calls = nil
+
+	if n, err := rf.ReadAt(buf, 8); err != io.EOF || string(buf[:n]) != "89" {
+		t.Errorf("expected a clipped read with io.EOF, got (%q, %v)", buf[:n], err)
+	}
+
+	if len(calls) != 1 || calls[0] != [2]int64{8, 2} {
+		t.Errorf("expected a single fetch clipped to the size, got %v", calls)
+	}
+
+	if n, err := rf.ReadAt(buf, 10); n != 0 || err != io.EOF {
+		t.Errorf("expected (0, io.EOF) at the size, got (%d, %v)", n, err)
+	}
+
+	if n := io.NewSectionReader(rf, 0, rf.Size()).Size(); n != 10 {
+		t.Errorf("expected a SectionReader of size 10, got %d", n)
+	}
+}
+
+func TestRangeFuncReaderAtErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	truncated := NewRangeFuncReaderAt(10, func(off, length int64) ([]byte, error) {
+		return nil, io.EOF
+	})
+
+	if _, err := truncated.ReadAt(make([]byte, 4), 0); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF from a source shorter than its size, got %v", err)
+	}
+
+	failing := NewRangeFuncReaderAt(10, func(off, length int64) ([]byte, error) {
+		return []byte("ab"), errBoom
+	})
+
+	buf := make([]byte, 4)
+	if n, err := failing.ReadAt(buf, 0); err != errBoom || string(buf[:n]) != "ab" {
+		t.Errorf("expected the partial data and errBoom, got (%q, %v)", buf[:n], err)
+	}
+}
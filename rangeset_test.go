@@ -0,0 +1,79 @@
+package miscio
+
+import "testing"
+
+func TestRangeSet(t *testing.T) {
+	rs := newRangeSet()
+	rs.Add(0, 5)
+	rs.Add(6, 8)
+
+	if rs.NextCap() != 5 || rs.Len() != 7 {
+		t.Fatalf("expected NextCap 5 and Len 7, have %d and %d", rs.NextCap(), rs.Len())
+	}
+
+	rs.Consume(4)
+
+	expected := []Range{{1, 2}, {4, 10}}
+	gaps := rs.Gaps(10)
+
+	if len(gaps) != len(expected) || gaps[0] != expected[0] || gaps[1] != expected[1] {
+		t.Errorf("Gaps mismatch after Consume, have %v want %v", gaps, expected)
+	}
+
+	rs.Add(1, 2)
+
+	if rs.NextCap() != 4 || rs.Len() != 4 || len(rs.set.ivs) != 1 {
+		t.Errorf("expected filling the gap to merge intervals; NextCap %d Len %d intervals %v",
+			rs.NextCap(), rs.Len(), rs.set.ivs)
+	}
+
+	rs.Add(2, 3)
+
+	if rs.Len() != 4 {
+		t.Errorf("re-adding covered values should not change Len, have %d", rs.Len())
+	}
+}
+
+func BenchmarkRangeSetAdd(b *testing.B) {
+	const (
+		chunk = 1 << 20
+		total = 16 * chunk
+	)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		rs := newRangeSet()
+
+		// Add chunks out of order, then drain from the front.
+		for off := int64(total - chunk); off >= 0; off -= chunk {
+			rs.Add(off, off+chunk)
+		}
+
+		rs.Consume(rs.NextCap())
+	}
+}
+
+func BenchmarkWriterAtReadCloserMultiMB(b *testing.B) {
+	const chunk = 256 << 10
+
+	data := make([]byte, 4<<20)
+	buf := make([]byte, 64<<10)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		w := NewWriterAtReadCloser(0, WithSize(int64(len(data))))
+
+		for off := len(data) - chunk; off >= 0; off -= chunk {
+			w.WriteAt(data[off:off+chunk], int64(off))
+		}
+
+		for {
+			if _, err := w.Read(buf); err != nil {
+				break
+			}
+		}
+	}
+}
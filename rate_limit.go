@@ -0,0 +1,176 @@
+package miscio
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting throughput to a number of bytes per
+// second, with bursts of up to a configured number of bytes. A single
+// RateLimiter may be shared by several RateLimitedReaders and
+// RateLimitedWriters to cap their combined throughput. It is safe for
+// concurrent use.
+type RateLimiter struct {
+	m      sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing bytesPerSec bytes per second on
+// average, and bursts of up to burst bytes. The bucket starts full. If burst
+// is not positive, it defaults to one second's worth of bytes.
+func NewRateLimiter(bytesPerSec float64, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = int(bytesPerSec)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+
+	return &RateLimiter{
+		rate:   bytesPerSec,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Burst returns the largest number of bytes the RateLimiter allows at once.
+func (l *RateLimiter) Burst() int { return l.burst }
+
+// WaitN blocks until n bytes may pass, or ctx is done, in which case it returns
+// ctx.Err() and the bytes are not counted. Requests larger than the burst size
+// are allowed, but delay later ones correspondingly.
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	l.m.Lock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	l.last = now
+
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+
+	// Take the tokens up front, going into debt if need be, so concurrent
+	// waiters queue up behind each other.
+	l.tokens -= float64(n)
+	debt := -l.tokens
+
+	l.m.Unlock()
+
+	if debt <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(debt / l.rate * float64(time.Second)))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.m.Lock()
+		l.tokens += float64(n)
+		l.m.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+// RateLimitedReader wraps an io.Reader, limiting how fast it can be read with a
+// RateLimiter.
+type RateLimitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *RateLimiter
+}
+
+var _ io.Reader = (*RateLimitedReader)(nil)
+
+// NewRateLimitedReader returns a RateLimitedReader reading from r at the rate
+// allowed by l. Waits for l are abandoned, and Read returns ctx.Err(), once ctx
+// is done.
+func NewRateLimitedReader(ctx context.Context, r io.Reader, l *RateLimiter) *RateLimitedReader {
+	return &RateLimitedReader{ctx: ctx, r: r, l: l}
+}
+
+// Read implements io.Reader for RateLimitedReader. It reads at most the
+// limiter's burst size at once, then waits until the bytes read are allowed
+// before returning them.
+func (rr *RateLimitedReader) Read(p []byte) (int, error) {
+	if err := rr.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(p) > rr.l.Burst() {
+		p = p[:rr.l.Burst()]
+	}
+
+	n, err := rr.r.Read(p)
+	if n > 0 {
+		if werr := rr.l.WaitN(rr.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
+
+// RateLimitedWriter wraps an io.Writer, limiting how fast it can be written to
+// with a RateLimiter.
+type RateLimitedWriter struct {
+	ctx context.Context
+	w   io.Writer
+	l   *RateLimiter
+}
+
+var (
+	_ io.Writer = (*RateLimitedWriter)(nil)
+	_ Flusher   = (*RateLimitedWriter)(nil)
+)
+
+// NewRateLimitedWriter returns a RateLimitedWriter writing to w at the rate
+// allowed by l. Waits for l are abandoned, and Write returns ctx.Err(), once
+// ctx is done.
+func NewRateLimitedWriter(ctx context.Context, w io.Writer, l *RateLimiter) *RateLimitedWriter {
+	return &RateLimitedWriter{ctx: ctx, w: w, l: l}
+}
+
+// Write implements io.Writer for RateLimitedWriter. p is written in pieces of
+// at most the limiter's burst size, each waiting until it is allowed.
+func (rw *RateLimitedWriter) Write(p []byte) (int, error) {
+	written := 0
+
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > rw.l.Burst() {
+			chunk = chunk[:rw.l.Burst()]
+		}
+
+		if err := rw.l.WaitN(rw.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+
+		n, err := rw.w.Write(chunk)
+		written += n
+
+		if err != nil {
+			return written, err
+		}
+
+		p = p[len(chunk):]
+	}
+
+	return written, nil
+}
+
+// Flush implements Flusher for RateLimitedWriter by flushing the underlying
+// writer. It does not wait for the limiter.
+func (rw *RateLimitedWriter) Flush() error {
+	return flushNext(rw.w)
+}
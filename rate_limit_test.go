@@ -0,0 +1,56 @@
+package miscio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	l := NewRateLimiter(10000, 100)
+	w := NewRateLimitedWriter(context.Background(), &dst, l)
+
+	start := time.Now()
+
+	// The first 100 bytes are the initial burst; the rest take 100ms.
+	if n, err := w.Write(make([]byte, 1100)); n != 1100 || err != nil {
+		t.Fatalf("Write got (%d, %v)", n, err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected the write to be throttled to about 100ms, took %s", elapsed)
+	}
+}
+
+func TestRateLimitedReader(t *testing.T) {
+	l := NewRateLimiter(10000, 100)
+	r := NewRateLimitedReader(context.Background(), bytes.NewReader(make([]byte, 1100)), l)
+
+	start := time.Now()
+
+	if n, err := io.Copy(ioutil.Discard, r); n != 1100 || err != nil {
+		t.Fatalf("io.Copy got (%d, %v)", n, err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected the read to be throttled to about 100ms, took %s", elapsed)
+	}
+}
+
+func TestRateLimiterContext(t *testing.T) {
+	l := NewRateLimiter(1, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	w := NewRateLimitedWriter(ctx, ioutil.Discard, l)
+
+	if n, err := w.Write([]byte("slow")); n != 1 || err != context.DeadlineExceeded {
+		t.Errorf("Write got (%d, %v), want (1, context.DeadlineExceeded)", n, err)
+	}
+}
@@ -0,0 +1,151 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// WriteCall is one call recorded by a RecordingWriter.
+type WriteCall struct {
+	Time time.Time
+	// Data is a copy of the bytes passed to Write.
+	Data []byte
+	// N and Err are what Write returned.
+	N   int
+	Err error
+}
+
+// RecordingWriter records every Write made to it, for assertions in tests,
+// e.g. about how a writer chunks its output, or how the writes of several
+// goroutines interleave. Writes pass through to an underlying writer, if one
+// is set. It is safe for concurrent use; calls are recorded in the order they
+// complete.
+type RecordingWriter struct {
+	m     sync.Mutex
+	w     io.Writer
+	now   func() time.Time
+	calls []WriteCall
+}
+
+var (
+	_ io.Writer = (*RecordingWriter)(nil)
+	_ Flusher   = (*RecordingWriter)(nil)
+)
+
+// NewRecordingWriter returns a RecordingWriter writing to w. If w is nil,
+// every Write succeeds without writing anywhere.
+func NewRecordingWriter(w io.Writer) *RecordingWriter {
+	if w == nil {
+		w = ioutil.Discard
+	}
+
+	return &RecordingWriter{w: w, now: time.Now}
+}
+
+// Write implements io.Writer for RecordingWriter.
+func (rw *RecordingWriter) Write(p []byte) (int, error) {
+	n, err := rw.w.Write(p)
+
+	rw.m.Lock()
+	defer rw.m.Unlock()
+
+	rw.calls = append(rw.calls, WriteCall{
+		Time: rw.now(),
+		Data: append([]byte(nil), p...),
+		N:    n,
+		Err:  err,
+	})
+
+	return n, err
+}
+
+// Flush implements Flusher for RecordingWriter by flushing the underlying
+// writer.
+func (rw *RecordingWriter) Flush() error {
+	return flushNext(rw.w)
+}
+
+// Calls returns the calls recorded so far.
+func (rw *RecordingWriter) Calls() []WriteCall {
+	rw.m.Lock()
+	defer rw.m.Unlock()
+
+	return append([]WriteCall(nil), rw.calls...)
+}
+
+// Reset discards the calls recorded so far.
+func (rw *RecordingWriter) Reset() {
+	rw.m.Lock()
+	defer rw.m.Unlock()
+
+	rw.calls = nil
+}
+
+// WriteAtCall is one call recorded by a RecordingWriterAt.
+type WriteAtCall struct {
+	Time time.Time
+	Off  int64
+	Len  int
+	// N and Err are what WriteAt returned.
+	N   int
+	Err error
+}
+
+// RecordingWriterAt records the offset and length of every WriteAt made to it,
+// like RecordingWriter does for Write. It does not keep the data. Writes pass
+// through to an underlying io.WriterAt, if one is set. It is safe for
+// concurrent use.
+type RecordingWriterAt struct {
+	m     sync.Mutex
+	w     io.WriterAt
+	now   func() time.Time
+	calls []WriteAtCall
+}
+
+var _ io.WriterAt = (*RecordingWriterAt)(nil)
+
+// NewRecordingWriterAt returns a RecordingWriterAt writing to w. If w is nil,
+// writes go to DiscardWriterAt.
+func NewRecordingWriterAt(w io.WriterAt) *RecordingWriterAt {
+	if w == nil {
+		w = DiscardWriterAt
+	}
+
+	return &RecordingWriterAt{w: w, now: time.Now}
+}
+
+// WriteAt implements io.WriterAt for RecordingWriterAt.
+func (rw *RecordingWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := rw.w.WriteAt(p, off)
+
+	rw.m.Lock()
+	defer rw.m.Unlock()
+
+	rw.calls = append(rw.calls, WriteAtCall{
+		Time: rw.now(),
+		Off:  off,
+		Len:  len(p),
+		N:    n,
+		Err:  err,
+	})
+
+	return n, err
+}
+
+// Calls returns the calls recorded so far.
+func (rw *RecordingWriterAt) Calls() []WriteAtCall {
+	rw.m.Lock()
+	defer rw.m.Unlock()
+
+	return append([]WriteAtCall(nil), rw.calls...)
+}
+
+// Reset discards the calls recorded so far.
+func (rw *RecordingWriterAt) Reset() {
+	rw.m.Lock()
+	defer rw.m.Unlock()
+
+	rw.calls = nil
+}
@@ -0,0 +1,74 @@
+package miscio
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRecordingWriter(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0), step: time.Second}
+
+	var dst bytes.Buffer
+
+	rw := NewRecordingWriter(&dst)
+	rw.now = clock.Now
+
+	// Check that a bufio.Writer coalesces small writes.
+	bw := bufio.NewWriterSize(rw, 16)
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(bw, "%d,", i)
+	}
+
+	bw.Flush()
+
+	calls := rw.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 writes, got %d", len(calls))
+	}
+
+	if string(calls[0].Data) != "0,1,2,3,4,5,6,7," || calls[0].N != 16 {
+		t.Errorf("unexpected first call %+v", calls[0])
+	}
+
+	if !calls[1].Time.Equal(time.Unix(1001, 0)) {
+		t.Errorf("expected the second call at the second tick, got %v", calls[1].Time)
+	}
+
+	if dst.String() != "0,1,2,3,4,5,6,7,8,9," {
+		t.Errorf("expected writes to pass through, got %q", dst.String())
+	}
+
+	rw.Reset()
+
+	if len(rw.Calls()) != 0 {
+		t.Error("expected Reset to discard the calls")
+	}
+}
+
+func TestRecordingWriterAt(t *testing.T) {
+	rw := NewRecordingWriterAt(nil)
+
+	bw := NewBufferedWriterAt(rw, 0)
+	bw.WriteAt([]byte("cd"), 2)
+	bw.WriteAt([]byte("ab"), 0)
+	bw.WriteAt([]byte("xy"), 10)
+	bw.Flush()
+
+	// The BufferedWriterAt coalesced the adjacent writes.
+	expected := []WriteAtCall{{Off: 0, Len: 4, N: 4}, {Off: 10, Len: 2, N: 2}}
+
+	calls := rw.Calls()
+	if len(calls) != len(expected) {
+		t.Fatalf("expected %d calls, got %+v", len(expected), calls)
+	}
+
+	for i, c := range calls {
+		c.Time = time.Time{}
+		if c != expected[i] {
+			t.Errorf("call %d: expected %+v, got %+v", i, expected[i], c)
+		}
+	}
+}
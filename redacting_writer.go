@@ -0,0 +1,159 @@
+package miscio
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultRedactMaxMatch is the longest match a RedactingWriter is guaranteed
+// to catch across Write boundaries, if no other limit is given.
+const DefaultRedactMaxMatch = 256
+
+// RedactingWriter wraps an io.Writer, replacing every match of a set of
+// patterns in the stream written through it, e.g. to scrub tokens from
+// captured process output. Matches are found even when split across Writes:
+// the writer holds back the most recent maxMatch-1 bytes (and any match that
+// runs up to the end of what has been written, since it may continue) until
+// more data arrives or Flush is called. Matches longer than maxMatch may be
+// missed if split across Writes.
+//
+// Flush must be called after the last Write. It is safe for concurrent use.
+type RedactingWriter struct {
+	m           sync.Mutex
+	w           io.Writer
+	re          *regexp.Regexp
+	replacement []byte
+	maxMatch    int
+	pending     []byte
+	out         []byte
+}
+
+var (
+	_ io.WriteCloser = (*RedactingWriter)(nil)
+	_ Flusher        = (*RedactingWriter)(nil)
+)
+
+// NewRedactingWriter returns a RedactingWriter that replaces every match of
+// any of patterns with replacement, which is used literally. maxMatch bounds
+// the length of matches that are guaranteed to be caught; if it is not
+// positive, DefaultRedactMaxMatch is used. Use regexp.QuoteMeta, or
+// RedactLiteral, to redact literal strings.
+func NewRedactingWriter(w io.Writer, replacement string, maxMatch int, patterns ...*regexp.Regexp) *RedactingWriter {
+	if maxMatch <= 0 {
+		maxMatch = DefaultRedactMaxMatch
+	}
+
+	alternatives := make([]string, len(patterns))
+	for i, re := range patterns {
+		alternatives[i] = "(?:" + re.String() + ")"
+	}
+
+	// An empty alternation would match everywhere; match nothing instead.
+	combined := `[^\x00-\x{10FFFF}]`
+	if len(alternatives) > 0 {
+		combined = strings.Join(alternatives, "|")
+	}
+
+	return &RedactingWriter{
+		w:           w,
+		re:          regexp.MustCompile(combined),
+		replacement: []byte(replacement),
+		maxMatch:    maxMatch,
+	}
+}
+
+// RedactLiteral returns a pattern for NewRedactingWriter matching s literally.
+func RedactLiteral(s string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(s))
+}
+
+// Write implements io.Writer for RedactingWriter. On success it reports all of
+// p as written, though some of it may be held back until a later Write or
+// Flush.
+func (rw *RedactingWriter) Write(p []byte) (int, error) {
+	rw.m.Lock()
+	defer rw.m.Unlock()
+
+	rw.pending = append(rw.pending, p...)
+
+	if err := rw.emit(false); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush implements Flusher for RedactingWriter: it redacts and writes out
+// everything held back, then flushes the underlying writer.
+func (rw *RedactingWriter) Flush() error {
+	rw.m.Lock()
+	defer rw.m.Unlock()
+
+	if err := rw.emit(true); err != nil {
+		return err
+	}
+
+	return flushNext(rw.w)
+}
+
+// Close implements io.Closer for RedactingWriter by calling Flush. It does not
+// close the underlying writer.
+func (rw *RedactingWriter) Close() error {
+	return rw.Flush()
+}
+
+// emit writes out the part of pending that can no longer be affected by future
+// writes (or all of it, if final), with matches replaced. It must be called
+// with rw.m held.
+func (rw *RedactingWriter) emit(final bool) error {
+	boundary := len(rw.pending)
+	if !final {
+		boundary -= rw.maxMatch - 1
+		if boundary < 0 {
+			boundary = 0
+		}
+	}
+
+	rw.out = rw.out[:0]
+	prev := 0
+
+	for _, match := range rw.re.FindAllIndex(rw.pending, -1) {
+		start, end := match[0], match[1]
+		if start >= boundary || (!final && end == len(rw.pending)) {
+			// This match, or one starting here, may yet grow.
+			if start < boundary {
+				boundary = start
+			}
+
+			break
+		}
+
+		if start == end {
+			continue
+		}
+
+		rw.out = append(rw.out, rw.pending[prev:start]...)
+		rw.out = append(rw.out, rw.replacement...)
+		prev = end
+	}
+
+	if prev > boundary {
+		boundary = prev
+	}
+
+	rw.out = append(rw.out, rw.pending[prev:boundary]...)
+	rw.pending = append(rw.pending[:0], rw.pending[boundary:]...)
+
+	if len(rw.out) == 0 {
+		return nil
+	}
+
+	n, err := rw.w.Write(rw.out)
+	if err == nil && n < len(rw.out) {
+		err = io.ErrShortWrite
+	}
+
+	return err
+}
@@ -0,0 +1,65 @@
+package miscio
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactingWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	rw := NewRedactingWriter(&dst, "[REDACTED]", 32,
+		regexp.MustCompile(`token=[0-9a-f]+`),
+		RedactLiteral("hunter2"),
+	)
+
+	input := "login with hunter2, then token=deadbeef01 and token=cafe.\n"
+	for _, chunk := range []string{"login with hun", "ter2, then tok", "en=dead", "beef", "01 and token=cafe", ".\n"} {
+		if n, err := rw.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) got (%d, %v)", chunk, n, err)
+		}
+	}
+
+	if strings.Contains(dst.String(), "hunter2") || strings.Contains(dst.String(), "dead") {
+		t.Errorf("secret written before Flush: %q", dst.String())
+	}
+
+	rw.Flush()
+
+	expected := "login with [REDACTED], then [REDACTED] and [REDACTED].\n"
+	if dst.String() != expected {
+		t.Errorf("redacting %q got %q, want %q", input, dst.String(), expected)
+	}
+}
+
+func TestRedactingWriterHoldsOnlyTail(t *testing.T) {
+	var dst bytes.Buffer
+
+	rw := NewRedactingWriter(&dst, "***", 8, RedactLiteral("secret"))
+	rw.Write([]byte(strings.Repeat("x", 100)))
+
+	if dst.Len() != 93 {
+		t.Errorf("expected all but maxMatch-1 bytes to be written, got %d", dst.Len())
+	}
+
+	rw.Write([]byte("secret trailing"))
+	rw.Close()
+
+	if expected := strings.Repeat("x", 100) + "*** trailing"; dst.String() != expected {
+		t.Errorf("got %q, want %q", dst.String(), expected)
+	}
+}
+
+func TestRedactingWriterNoPatterns(t *testing.T) {
+	var dst bytes.Buffer
+
+	rw := NewRedactingWriter(&dst, "***", 0)
+	rw.Write([]byte("nothing to hide"))
+	rw.Flush()
+
+	if dst.String() != "nothing to hide" {
+		t.Errorf("got %q", dst.String())
+	}
+}
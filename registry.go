@@ -0,0 +1,200 @@
+package miscio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry is a set of named RollingLineBuffers and WriterAtReadClosers, and an
+// http.Handler serving a debug view of them, so that a service with many
+// capture buffers can inspect them all from one endpoint. Mount it under a
+// prefix with http.StripPrefix, e.g.
+//
+//	mux.Handle("/debug/buffers/", http.StripPrefix("/debug/buffers", reg))
+//
+// GET on the root returns a JSON array describing every buffer, sorted by name.
+// GET on /{name} returns the same description of one buffer, along with the
+// lines a RollingLineBuffer currently retains or the gaps a WriterAtReadCloser
+// is still waiting for. Its data is not served, since reading it would consume
+// it. Nothing served changes a buffer's read position.
+//
+// A Registry is safe for concurrent use. The zero value is an empty Registry.
+type Registry struct {
+	m       sync.RWMutex
+	lines   map[string]*RollingLineBuffer
+	streams map[string]*WriterAtReadCloser
+}
+
+var _ http.Handler = (*Registry)(nil)
+
+// RegisterLineBuffer adds rb to the registry under name. It returns
+// ErrAlreadyRegistered (wrapped) if name is taken.
+func (reg *Registry) RegisterLineBuffer(name string, rb *RollingLineBuffer) error {
+	reg.m.Lock()
+	defer reg.m.Unlock()
+
+	if err := reg.checkName(name); err != nil {
+		return err
+	}
+
+	if reg.lines == nil {
+		reg.lines = make(map[string]*RollingLineBuffer)
+	}
+
+	reg.lines[name] = rb
+
+	return nil
+}
+
+// RegisterWriterAtReadCloser adds wr to the registry under name. It returns
+// ErrAlreadyRegistered (wrapped) if name is taken.
+func (reg *Registry) RegisterWriterAtReadCloser(name string, wr *WriterAtReadCloser) error {
+	reg.m.Lock()
+	defer reg.m.Unlock()
+
+	if err := reg.checkName(name); err != nil {
+		return err
+	}
+
+	if reg.streams == nil {
+		reg.streams = make(map[string]*WriterAtReadCloser)
+	}
+
+	reg.streams[name] = wr
+
+	return nil
+}
+
+// checkName must be called with reg.m held.
+func (reg *Registry) checkName(name string) error {
+	_, isLines := reg.lines[name]
+	_, isStream := reg.streams[name]
+
+	if isLines || isStream {
+		return fmt.Errorf("%w: %q", ErrAlreadyRegistered, name)
+	}
+
+	return nil
+}
+
+// Unregister removes the buffer registered under name, if any.
+func (reg *Registry) Unregister(name string) {
+	reg.m.Lock()
+	defer reg.m.Unlock()
+
+	delete(reg.lines, name)
+	delete(reg.streams, name)
+}
+
+// Names returns the names of every registered buffer, sorted.
+func (reg *Registry) Names() []string {
+	reg.m.RLock()
+	defer reg.m.RUnlock()
+
+	names := make([]string, 0, len(reg.lines)+len(reg.streams))
+
+	for name := range reg.lines {
+		names = append(names, name)
+	}
+
+	for name := range reg.streams {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// RegistryEntry describes one buffer in a Registry, as served by its handler.
+type RegistryEntry struct {
+	Name string `json:"name"`
+	// Type is "lines" for a RollingLineBuffer, or "stream" for a
+	// WriterAtReadCloser.
+	Type string `json:"type"`
+	// Stats is the buffer's RollingLineBufferMetrics or
+	// WriterAtReadCloserStats.
+	Stats interface{} `json:"stats"`
+	// Lines are the lines a RollingLineBuffer retains. It is only set when a
+	// single buffer is requested.
+	Lines []string `json:"lines,omitempty"`
+	// Gaps are the ranges a WriterAtReadCloser is waiting for. It is only set
+	// when a single buffer is requested.
+	Gaps []Range `json:"gaps,omitempty"`
+}
+
+// entry describes the buffer registered under name, with its contents if full
+// is true. It returns false if there is no such buffer.
+func (reg *Registry) entry(name string, full bool) (RegistryEntry, bool) {
+	reg.m.RLock()
+	rb, isLines := reg.lines[name]
+	wr, isStream := reg.streams[name]
+	reg.m.RUnlock()
+
+	entry := RegistryEntry{Name: name}
+
+	switch {
+	case isLines:
+		entry.Type = "lines"
+		entry.Stats = rb.Metrics()
+
+		if full {
+			entry.Lines = rb.Snapshot()
+		}
+	case isStream:
+		entry.Type = "stream"
+		entry.Stats = wr.Stats()
+
+		if full {
+			entry.Gaps = wr.Gaps()
+		}
+	default:
+		return entry, false
+	}
+
+	return entry, true
+}
+
+// ServeHTTP implements http.Handler for Registry.
+func (reg *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	var body interface{}
+
+	if name := strings.TrimPrefix(req.URL.Path, "/"); name == "" {
+		entries := []RegistryEntry{}
+
+		for _, name := range reg.Names() {
+			// A buffer may be unregistered between Names and entry.
+			if entry, ok := reg.entry(name, false); ok {
+				entries = append(entries, entry)
+			}
+		}
+
+		body = entries
+	} else {
+		entry, ok := reg.entry(name, true)
+		if !ok {
+			http.NotFound(w, req)
+
+			return
+		}
+
+		body = entry
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(body) // nolint:errcheck
+}
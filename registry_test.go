@@ -0,0 +1,96 @@
+package miscio
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func getRegistry(t *testing.T, h http.Handler, path string, v interface{}) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s returned invalid JSON: %v", path, err)
+		}
+	}
+
+	return rec.Code
+}
+
+func TestRegistry(t *testing.T) {
+	var reg Registry
+
+	rb := NewRollingLineBuffer(2)
+	rb.Write([]byte("one\ntwo\nthree"))
+
+	wr := NewWriterAtReadCloser(0)
+	wr.WriteAt([]byte("later"), 10)
+
+	if err := reg.RegisterLineBuffer("job/output", rb); err != nil {
+		t.Fatalf("RegisterLineBuffer failed: %v", err)
+	}
+
+	if err := reg.RegisterWriterAtReadCloser("download", wr); err != nil {
+		t.Fatalf("RegisterWriterAtReadCloser failed: %v", err)
+	}
+
+	if err := reg.RegisterLineBuffer("download", rb); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("expected ErrAlreadyRegistered, got %v", err)
+	}
+
+	if names := reg.Names(); !reflect.DeepEqual(names, []string{"download", "job/output"}) {
+		t.Errorf("unexpected names %q", names)
+	}
+
+	h := http.StripPrefix("/debug/buffers", &reg)
+
+	var index []RegistryEntry
+	if code := getRegistry(t, h, "/debug/buffers/", &index); code != http.StatusOK {
+		t.Fatalf("index returned %d", code)
+	}
+
+	if len(index) != 2 || index[0].Type != "stream" || index[1].Type != "lines" || index[1].Lines != nil {
+		t.Errorf("unexpected index %+v", index)
+	}
+
+	var lines struct {
+		RegistryEntry
+		Stats RollingLineBufferMetrics `json:"stats"`
+	}
+	if code := getRegistry(t, h, "/debug/buffers/job/output", &lines); code != http.StatusOK {
+		t.Fatalf("GET job/output returned %d", code)
+	}
+
+	if !reflect.DeepEqual(lines.Lines, []string{"two", "three"}) || lines.Stats.LinesDropped != 1 {
+		t.Errorf("unexpected entry %+v", lines)
+	}
+
+	var stream RegistryEntry
+	if code := getRegistry(t, h, "/debug/buffers/download", &stream); code != http.StatusOK {
+		t.Fatalf("GET download returned %d", code)
+	}
+
+	if !reflect.DeepEqual(stream.Gaps, []Range{{Start: 0, End: 10}}) {
+		t.Errorf("unexpected gaps %v", stream.Gaps)
+	}
+
+	reg.Unregister("download")
+
+	if code := getRegistry(t, h, "/debug/buffers/download", &stream); code != http.StatusNotFound {
+		t.Errorf("expected 404 after Unregister, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/buffers/", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rec.Code)
+	}
+}
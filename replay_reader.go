@@ -0,0 +1,115 @@
+package miscio
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReplayReader wraps an io.Reader, recording everything read through it so that
+// the stream can be rewound and read again, e.g. to sniff the first bytes of a
+// stream to detect its format, then hand the full stream to a decoder.
+//
+// While recording, Seek may move anywhere within the recorded prefix. Rewind
+// moves back to the start and stops recording: once the recorded prefix has
+// been replayed, it is released and ReplayReader passes reads straight through
+// to the underlying reader, so memory use is bounded by how far the stream was
+// read before rewinding.
+//
+// A ReplayReader is not safe for concurrent use.
+type ReplayReader struct {
+	r         io.Reader
+	buf       []byte
+	off       int64 // Absolute offset of the next byte to read.
+	base      int64 // Absolute offset of buf[0].
+	recording bool
+}
+
+var _ io.ReadSeeker = (*ReplayReader)(nil)
+
+// NewReplayReader returns a ReplayReader that reads from r, recording from the
+// start.
+func NewReplayReader(r io.Reader) *ReplayReader {
+	return &ReplayReader{r: r, recording: true}
+}
+
+// Read implements io.Reader for ReplayReader, serving recorded bytes before
+// reading more from the underlying reader.
+func (rr *ReplayReader) Read(p []byte) (int, error) {
+	if end := rr.base + int64(len(rr.buf)); rr.off < end {
+		n := copy(p, rr.buf[rr.off-rr.base:])
+		rr.off += int64(n)
+
+		if !rr.recording && rr.off == end {
+			rr.base, rr.buf = end, nil
+		}
+
+		return n, nil
+	}
+
+	n, err := rr.r.Read(p)
+	rr.off += int64(n)
+
+	if rr.recording {
+		rr.buf = append(rr.buf, p[:n]...)
+	} else {
+		rr.base = rr.off
+	}
+
+	return n, err
+}
+
+// Seek implements io.Seeker for ReplayReader. The new offset must lie within
+// the recorded data still held (or be the current offset); otherwise Seek
+// returns ErrNotRecorded. io.SeekEnd is relative to the end of the recorded
+// data.
+func (rr *ReplayReader) Seek(offset int64, whence int) (int64, error) {
+	end := rr.base + int64(len(rr.buf))
+
+	var abs int64
+
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = rr.off + offset
+	case io.SeekEnd:
+		abs = end + offset
+	default:
+		return 0, errInvalidWhence
+	}
+
+	switch {
+	case abs < 0:
+		return 0, ErrNegativeOffset
+	case abs == rr.off:
+		return abs, nil
+	case abs < rr.base || abs > end:
+		return 0, fmt.Errorf("%w: offset %d outside [%d, %d]", ErrNotRecorded, abs, rr.base, end)
+	}
+
+	rr.off = abs
+
+	return abs, nil
+}
+
+// Rewind moves back to the start of the stream and stops recording. It returns
+// ErrNotRecorded if recording had already been stopped by an earlier Rewind and
+// the start of the stream is no longer held.
+func (rr *ReplayReader) Rewind() error {
+	if _, err := rr.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	rr.recording = false
+
+	if len(rr.buf) == 0 {
+		rr.base, rr.buf = rr.off, nil
+	}
+
+	return nil
+}
+
+// Recorded returns the number of bytes currently held for replay.
+func (rr *ReplayReader) Recorded() int {
+	return len(rr.buf)
+}
@@ -0,0 +1,58 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestReplayReader(t *testing.T) {
+	rr := NewReplayReader(strings.NewReader("MAGIC and the rest of the stream"))
+
+	head := make([]byte, 5)
+	if _, err := io.ReadFull(rr, head); err != nil || string(head) != "MAGIC" {
+		t.Fatalf("ReadFull got (%q, %v)", head, err)
+	}
+
+	// Seeking within the recording keeps recording.
+	if off, err := rr.Seek(-2, io.SeekCurrent); err != nil || off != 3 {
+		t.Errorf("Seek got (%d, %v)", off, err)
+	}
+
+	if _, err := rr.Seek(6, io.SeekStart); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded seeking past the recording, got %v", err)
+	}
+
+	if err := rr.Rewind(); err != nil {
+		t.Fatalf("Rewind failed: %v", err)
+	}
+
+	all, err := ioutil.ReadAll(rr)
+	if err != nil || string(all) != "MAGIC and the rest of the stream" {
+		t.Errorf("ReadAll got (%q, %v)", all, err)
+	}
+
+	if n := rr.Recorded(); n != 0 {
+		t.Errorf("expected the recording to be released after replay, %d bytes held", n)
+	}
+
+	if err := rr.Rewind(); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded rewinding a second time, got %v", err)
+	}
+}
+
+func TestReplayReaderRewindBeforeRead(t *testing.T) {
+	rr := NewReplayReader(strings.NewReader("abc"))
+
+	if err := rr.Rewind(); err != nil {
+		t.Fatalf("Rewind failed: %v", err)
+	}
+
+	rr.Read(make([]byte, 2))
+
+	if n := rr.Recorded(); n != 0 {
+		t.Errorf("expected nothing recorded after Rewind, got %d bytes", n)
+	}
+}
@@ -0,0 +1,214 @@
+package miscio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// reportSlots is the number of sub-windows a reporting window is divided
+	// into. Samples age out one slot at a time.
+	reportSlots = 10
+	// latencyBuckets is the number of histogram buckets. Bucket i holds
+	// latencies up to 1µs * 2^(i/2), so the histogram spans 1µs to roughly
+	// an hour with each bucket at most ~41% wider than the one before it.
+	latencyBuckets = 64
+)
+
+// ReportStats summarizes the calls a ReportingReader or ReportingWriter saw in
+// its most recent window. Percentiles are approximate: each is the upper bound
+// of the histogram bucket containing it, which is within ~41% of the true
+// value.
+type ReportStats struct {
+	Window         time.Duration
+	Calls          int64
+	Bytes          int64
+	BytesPerSecond float64
+	P50            time.Duration
+	P90            time.Duration
+	P99            time.Duration
+}
+
+// String implements fmt.Stringer for ReportStats.
+func (s ReportStats) String() string {
+	return fmt.Sprintf("window=%s calls=%d bytes=%d rate=%.1fB/s p50=%s p90=%s p99=%s",
+		s.Window, s.Calls, s.Bytes, s.BytesPerSecond, s.P50, s.P90, s.P99)
+}
+
+type reportSlot struct {
+	id        int64
+	calls     int64
+	bytes     int64
+	latencies [latencyBuckets]int64
+}
+
+// reporter maintains a sliding window of call sizes and latencies in a fixed
+// amount of memory, regardless of call rate.
+type reporter struct {
+	m      sync.Mutex
+	window time.Duration
+	slots  [reportSlots]reportSlot
+	now    func() time.Time
+}
+
+func newReporter(window time.Duration) *reporter {
+	if window < reportSlots {
+		window = reportSlots
+	}
+
+	return &reporter{window: window, now: time.Now}
+}
+
+func (rp *reporter) slotID(t time.Time) int64 {
+	return t.UnixNano() / int64(rp.window/reportSlots)
+}
+
+func (rp *reporter) record(start time.Time, n int) {
+	now := rp.now()
+	latency := now.Sub(start)
+	id := rp.slotID(now)
+
+	rp.m.Lock()
+	defer rp.m.Unlock()
+
+	slot := &rp.slots[id%reportSlots]
+	if slot.id != id {
+		*slot = reportSlot{id: id}
+	}
+
+	slot.calls++
+	slot.bytes += int64(n)
+	slot.latencies[latencyBucket(latency)]++
+}
+
+// Stats returns a summary of the calls made in the most recent window.
+func (rp *reporter) Stats() ReportStats {
+	id := rp.slotID(rp.now())
+	stats := ReportStats{Window: rp.window}
+
+	var hist [latencyBuckets]int64
+
+	rp.m.Lock()
+	for _, slot := range rp.slots {
+		if slot.id <= id-reportSlots || slot.id > id {
+			continue
+		}
+
+		stats.Calls += slot.calls
+		stats.Bytes += slot.bytes
+
+		for i, count := range slot.latencies {
+			hist[i] += count
+		}
+	}
+	rp.m.Unlock()
+
+	stats.BytesPerSecond = float64(stats.Bytes) / rp.window.Seconds()
+	stats.P50 = percentile(hist, stats.Calls, 0.5)
+	stats.P90 = percentile(hist, stats.Calls, 0.9)
+	stats.P99 = percentile(hist, stats.Calls, 0.99)
+
+	return stats
+}
+
+// Render writes a one-line, human-readable summary of Stats to w.
+func (rp *reporter) Render(w io.Writer) error {
+	_, err := fmt.Fprintln(w, rp.Stats())
+
+	return err
+}
+
+func latencyBucket(d time.Duration) int {
+	if d <= time.Microsecond {
+		return 0
+	}
+
+	b := int(math.Ceil(2 * math.Log2(float64(d)/float64(time.Microsecond))))
+	if b >= latencyBuckets {
+		b = latencyBuckets - 1
+	}
+
+	return b
+}
+
+func bucketUpperBound(b int) time.Duration {
+	return time.Duration(float64(time.Microsecond) * math.Pow(2, float64(b)/2))
+}
+
+func percentile(hist [latencyBuckets]int64, total int64, q float64) time.Duration {
+	if total == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(q * float64(total)))
+	seen := int64(0)
+
+	for b, count := range hist {
+		seen += count
+		if seen >= rank {
+			return bucketUpperBound(b)
+		}
+	}
+
+	return bucketUpperBound(latencyBuckets - 1)
+}
+
+// ReportingReader wraps an io.Reader, recording the size and latency of every
+// Read over a sliding window. It is safe to call Stats and Render while other
+// goroutines are reading.
+type ReportingReader struct {
+	*reporter
+	r io.Reader
+}
+
+// NewReportingReader returns a ReportingReader over r whose Stats cover the
+// most recent window of time.
+func NewReportingReader(r io.Reader, window time.Duration) *ReportingReader {
+	return &ReportingReader{reporter: newReporter(window), r: r}
+}
+
+// Read implements io.Reader for ReportingReader.
+func (rr *ReportingReader) Read(p []byte) (int, error) {
+	start := rr.now()
+	n, err := rr.r.Read(p)
+	rr.record(start, n)
+
+	return n, err
+}
+
+// ReportingWriter wraps an io.Writer, recording the size and latency of every
+// Write over a sliding window. It is safe to call Stats and Render while other
+// goroutines are writing.
+type ReportingWriter struct {
+	*reporter
+	w io.Writer
+}
+
+var (
+	_ io.Writer = (*ReportingWriter)(nil)
+	_ Flusher   = (*ReportingWriter)(nil)
+)
+
+// NewReportingWriter returns a ReportingWriter over w whose Stats cover the
+// most recent window of time.
+func NewReportingWriter(w io.Writer, window time.Duration) *ReportingWriter {
+	return &ReportingWriter{reporter: newReporter(window), w: w}
+}
+
+// Write implements io.Writer for ReportingWriter.
+func (rw *ReportingWriter) Write(p []byte) (int, error) {
+	start := rw.now()
+	n, err := rw.w.Write(p)
+	rw.record(start, n)
+
+	return n, err
+}
+
+// Flush implements Flusher for ReportingWriter by flushing the underlying
+// writer.
+func (rw *ReportingWriter) Flush() error {
+	return flushNext(rw.w)
+}
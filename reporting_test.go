@@ -0,0 +1,65 @@
+package miscio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t    time.Time
+	step time.Duration
+}
+
+// Now returns the current fake time, then advances it by step.
+func (c *fakeClock) Now() time.Time {
+	t := c.t
+	c.t = c.t.Add(c.step)
+
+	return t
+}
+
+func TestReportingReader(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0), step: time.Millisecond}
+	rr := NewReportingReader(strings.NewReader(strings.Repeat("x", 100)), time.Second)
+	rr.now = clock.Now
+
+	buf := make([]byte, 10)
+	for i := 0; i < 10; i++ {
+		rr.Read(buf)
+	}
+
+	stats := rr.Stats()
+	if stats.Calls != 10 || stats.Bytes != 100 {
+		t.Errorf("got %d calls and %d bytes, want 10 and 100", stats.Calls, stats.Bytes)
+	}
+
+	if stats.BytesPerSecond != 100 {
+		t.Errorf("got rate %f, want 100", stats.BytesPerSecond)
+	}
+
+	if stats.P50 < time.Millisecond || stats.P99 > 1500*time.Microsecond {
+		t.Errorf("percentiles out of range for 1ms calls: p50=%s p99=%s", stats.P50, stats.P99)
+	}
+
+	clock.t = clock.t.Add(2 * time.Second)
+	if stats := rr.Stats(); stats.Calls != 0 {
+		t.Errorf("expected samples to age out of the window, got %d calls", stats.Calls)
+	}
+}
+
+func TestReportingWriterRender(t *testing.T) {
+	rw := NewReportingWriter(ioutil.Discard, time.Minute)
+	rw.Write([]byte("hello"))
+
+	var out bytes.Buffer
+	if err := rw.Render(&out); err != nil {
+		t.Fatalf("Render failed with %s", err)
+	}
+
+	if !strings.Contains(out.String(), "calls=1 bytes=5") {
+		t.Errorf("unexpected rendering %q", out.String())
+	}
+}
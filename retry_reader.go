@@ -0,0 +1,277 @@
+package miscio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryReaderMaxRetries = 5
+	defaultRetryReaderMinBackoff = 100 * time.Millisecond
+	defaultRetryReaderMaxBackoff = 5 * time.Second
+)
+
+// RetryReader presents a flaky sequential source, such as an HTTP response body
+// that can be re-requested with a Range header, as one continuous io.Reader. It
+// tracks how many bytes have been read, and when a read fails with a retryable
+// error it reopens the source at that offset, backing off exponentially between
+// attempts.
+//
+// Read is not safe for concurrent use, but Close may be called from another
+// goroutine to abandon a pending backoff.
+type RetryReader struct {
+	open func(offset int64) (io.ReadCloser, error)
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	retryable  func(err error) bool
+	after      func(d time.Duration) <-chan time.Time
+
+	off      int64
+	failures int   // Consecutive failures without progress.
+	err      error // Sticky error, returned by every later Read.
+
+	m      sync.Mutex // Guards rc and closed against Close.
+	rc     io.ReadCloser
+	closed chan struct{}
+	leak   *leakTracker
+}
+
+var _ io.ReadCloser = (*RetryReader)(nil)
+
+// RetryReaderOption configures a RetryReader at construction.
+type RetryReaderOption func(rr *RetryReader)
+
+// WithMaxRetries sets how many consecutive failed attempts, without any data
+// read in between, a RetryReader makes before giving up and returning the last
+// error. The default is 5.
+func WithMaxRetries(n int) RetryReaderOption {
+	return func(rr *RetryReader) {
+		rr.maxRetries = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry, which doubles with each
+// consecutive failure up to max. The defaults are 100ms and 5s.
+func WithBackoff(min, max time.Duration) RetryReaderOption {
+	return func(rr *RetryReader) {
+		rr.minBackoff, rr.maxBackoff = min, max
+	}
+}
+
+// WithRetryIf sets the predicate deciding which errors, from reading or from
+// reopening the source, are retried. By default every error is retried except
+// io.EOF, context cancellation and os.ErrClosed, and errors wrapping them.
+func WithRetryIf(retryable func(err error) bool) RetryReaderOption {
+	return func(rr *RetryReader) {
+		rr.retryable = retryable
+	}
+}
+
+// NewRetryReader returns a RetryReader that calls open to (re)open the source
+// at the given offset: first at 0, then at the current offset after each
+// retryable failure. The source is opened lazily, on the first Read.
+func NewRetryReader(open func(offset int64) (io.ReadCloser, error), opts ...RetryReaderOption) *RetryReader {
+	rr := &RetryReader{
+		open:       open,
+		maxRetries: defaultRetryReaderMaxRetries,
+		minBackoff: defaultRetryReaderMinBackoff,
+		maxBackoff: defaultRetryReaderMaxBackoff,
+		retryable:  retryableError,
+		after:      time.After,
+		closed:     make(chan struct{}),
+		leak:       trackLeak("*miscio.RetryReader"),
+	}
+
+	for _, opt := range opts {
+		opt(rr)
+	}
+
+	return rr
+}
+
+// retryableError is the default predicate of a RetryReader, BackoffWriter and
+// BackoffWriterAt. It rejects errors that retrying cannot fix, even when
+// wrapped: the end of the stream, cancellation, and use of something closed.
+func retryableError(err error) bool {
+	for _, permanent := range []error{
+		io.EOF,
+		context.Canceled,
+		context.DeadlineExceeded,
+		os.ErrClosed,
+		io.ErrClosedPipe,
+	} {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Read implements io.Reader for RetryReader. Data read before a failure is
+// returned first; the failure is dealt with by the next Read.
+func (rr *RetryReader) Read(p []byte) (int, error) {
+	if rr.err == nil && rr.isClosed() {
+		rr.err = os.ErrClosed
+	}
+
+	if rr.err != nil {
+		return 0, rr.err
+	}
+
+	for {
+		rc, err := rr.current()
+		if err != nil {
+			if !rr.backoff(err) {
+				return 0, rr.err
+			}
+
+			continue
+		}
+
+		n, err := rc.Read(p)
+		rr.off += int64(n)
+
+		if n > 0 {
+			rr.failures = 0
+		}
+
+		switch {
+		case err == nil || err == io.EOF:
+			if err == io.EOF {
+				rr.err = io.EOF
+			}
+
+			return n, err
+		case n > 0:
+			// Report the data now, and the error with the next Read.
+			rr.discard()
+
+			if !rr.retryable(err) {
+				rr.err = err
+			}
+
+			return n, nil
+		case !rr.backoff(err):
+			return 0, rr.err
+		}
+	}
+}
+
+// backoff closes the current source, if any, and decides whether to retry
+// after err. If so, it waits out the backoff and returns true; otherwise it
+// records the error to return and returns false.
+func (rr *RetryReader) backoff(err error) bool {
+	rr.discard()
+
+	if !rr.retryable(err) || rr.failures >= rr.maxRetries {
+		rr.err = err
+
+		return false
+	}
+
+	delay := rr.minBackoff << uint(rr.failures)
+	if delay > rr.maxBackoff || delay <= 0 {
+		delay = rr.maxBackoff
+	}
+
+	rr.failures++
+
+	select {
+	case <-rr.after(delay):
+		return true
+	case <-rr.closed:
+		rr.err = os.ErrClosed
+
+		return false
+	}
+}
+
+// current returns the open source, opening it at the current offset if
+// necessary.
+func (rr *RetryReader) current() (io.ReadCloser, error) {
+	rr.m.Lock()
+	rc := rr.rc
+	rr.m.Unlock()
+
+	if rc != nil {
+		return rc, nil
+	}
+
+	if rr.isClosed() {
+		return nil, os.ErrClosed
+	}
+
+	rc, err := rr.open(rr.off)
+	if err != nil {
+		return nil, err
+	}
+
+	rr.m.Lock()
+	defer rr.m.Unlock()
+
+	if rr.isClosed() {
+		rc.Close()
+
+		return nil, os.ErrClosed
+	}
+
+	rr.rc = rc
+
+	return rc, nil
+}
+
+// discard closes the current source, if any.
+func (rr *RetryReader) discard() {
+	rr.m.Lock()
+	defer rr.m.Unlock()
+
+	if rr.rc != nil {
+		rr.rc.Close()
+		rr.rc = nil
+	}
+}
+
+func (rr *RetryReader) isClosed() bool {
+	select {
+	case <-rr.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Offset returns the number of bytes read so far, i.e. the offset the source
+// would be reopened at.
+func (rr *RetryReader) Offset() int64 {
+	return rr.off
+}
+
+// Close abandons any pending backoff, and closes the current source if one is
+// open. Subsequent Reads return os.ErrClosed.
+func (rr *RetryReader) Close() error {
+	rr.m.Lock()
+	defer rr.m.Unlock()
+
+	if rr.isClosed() {
+		return nil
+	}
+
+	close(rr.closed)
+	rr.leak.markClosed()
+
+	if rr.rc == nil {
+		return nil
+	}
+
+	err := rr.rc.Close()
+	rr.rc = nil
+
+	return err
+}
@@ -0,0 +1,169 @@
+package miscio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakySource serves data, failing each opened reader after failAfter bytes
+// until it has failed failures times.
+type flakySource struct {
+	data      string
+	failAfter int
+	failures  int
+	opens     []int64
+}
+
+var errFlaky = errors.New("connection reset")
+
+func (fs *flakySource) open(off int64) (io.ReadCloser, error) {
+	fs.opens = append(fs.opens, off)
+
+	r := io.Reader(strings.NewReader(fs.data[off:]))
+	if fs.failures > 0 {
+		fs.failures--
+		r = io.MultiReader(io.LimitReader(r, int64(fs.failAfter)), errReader{errFlaky})
+	}
+
+	return ioutil.NopCloser(r), nil
+}
+
+// errReader fails every Read with err.
+type errReader struct{ err error }
+
+func (er errReader) Read(p []byte) (int, error) { return 0, er.err }
+
+func instantAfter(delays *[]time.Duration) func(time.Duration) <-chan time.Time {
+	return func(d time.Duration) <-chan time.Time {
+		*delays = append(*delays, d)
+
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+
+		return ch
+	}
+}
+
+func TestRetryReader(t *testing.T) {
+	src := &flakySource{data: "a stream that keeps dropping", failAfter: 5, failures: 3}
+
+	var delays []time.Duration
+
+	rr := NewRetryReader(src.open, WithBackoff(time.Second, 3*time.Second))
+	rr.after = instantAfter(&delays)
+
+	got, err := ioutil.ReadAll(rr)
+	if err != nil || string(got) != src.data {
+		t.Errorf("ReadAll got (%q, %v)", got, err)
+	}
+
+	expectedOpens := []int64{0, 5, 10, 15}
+	if len(src.opens) != len(expectedOpens) {
+		t.Fatalf("expected opens at %v, got %v", expectedOpens, src.opens)
+	}
+
+	for i, off := range expectedOpens {
+		if src.opens[i] != off {
+			t.Errorf("open %d: expected offset %d, got %d", i, off, src.opens[i])
+		}
+	}
+
+	// Each failure came after progress, so the backoff never grew.
+	for _, d := range delays {
+		if d != time.Second {
+			t.Errorf("expected every delay to be 1s, got %v", delays)
+		}
+	}
+
+	if rr.Offset() != int64(len(src.data)) {
+		t.Errorf("expected offset %d, got %d", len(src.data), rr.Offset())
+	}
+}
+
+func TestRetryReaderGivesUp(t *testing.T) {
+	var delays []time.Duration
+
+	opens := 0
+	rr := NewRetryReader(func(off int64) (io.ReadCloser, error) {
+		opens++
+
+		return nil, errFlaky
+	}, WithMaxRetries(3), WithBackoff(time.Second, 3*time.Second))
+	rr.after = instantAfter(&delays)
+
+	if _, err := rr.Read(make([]byte, 1)); err != errFlaky {
+		t.Errorf("expected errFlaky once retries ran out, got %v", err)
+	}
+
+	if opens != 4 {
+		t.Errorf("expected 4 attempts, got %d", opens)
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, d := range expected {
+		if i >= len(delays) || delays[i] != d {
+			t.Errorf("expected delays %v, got %v", expected, delays)
+
+			break
+		}
+	}
+}
+
+func TestRetryReaderNotRetryable(t *testing.T) {
+	rr := NewRetryReader(func(off int64) (io.ReadCloser, error) {
+		return nil, errFlaky
+	}, WithRetryIf(func(err error) bool { return false }))
+
+	if _, err := rr.Read(make([]byte, 1)); err != errFlaky {
+		t.Errorf("expected errFlaky without retrying, got %v", err)
+	}
+}
+
+func TestRetryReaderPermanentOpenErrors(t *testing.T) {
+	for _, err := range []error{
+		fmt.Errorf("GET: %w", context.Canceled),
+		io.EOF,
+		fmt.Errorf("dial: %w", os.ErrClosed),
+	} {
+		opens := 0
+		rr := NewRetryReader(func(int64) (io.ReadCloser, error) {
+			opens++
+
+			return nil, err
+		})
+
+		var delays []time.Duration
+		rr.after = instantAfter(&delays)
+
+		if _, rerr := rr.Read(make([]byte, 1)); rerr != err || opens != 1 {
+			t.Errorf("expected %v not to be retried, got %v after %d opens", err, rerr, opens)
+		}
+	}
+}
+
+func TestRetryReaderClose(t *testing.T) {
+	rr := NewRetryReader(func(off int64) (io.ReadCloser, error) {
+		return nil, errFlaky
+	}, WithBackoff(time.Hour, time.Hour))
+
+	done := make(chan error)
+
+	go func() {
+		_, err := rr.Read(make([]byte, 1))
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	rr.Close()
+
+	if err := <-done; err != os.ErrClosed {
+		t.Errorf("expected Close to abandon the backoff with os.ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package miscio
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// RollingByteBuffer is the byte-oriented sibling of RollingLineBuffer: it
+// stores the N most recent bytes written to it in a circular buffer, for
+// capturing output that isn't line-delimited, such as progress bars. Reads are
+// done forward-only; it does not implement io.Seeker. If writes overtake the
+// reader, the unread bytes that were overwritten are skipped, and Read resumes
+// at the oldest byte still retained.
+//
+// All methods are safe for concurrent use.
+type RollingByteBuffer struct {
+	m       sync.RWMutex
+	ring    []byte
+	written int64 // Total bytes ever written; ring[written%len(ring)] is next.
+	readpos int64 // Absolute offset of the next byte to read.
+	closed  bool
+}
+
+var (
+	_ io.ReadCloser = (*RollingByteBuffer)(nil)
+	_ io.Writer     = (*RollingByteBuffer)(nil)
+)
+
+// NewRollingByteBuffer returns a new RollingByteBuffer that holds the
+// `capacity` most recently-written bytes.
+func NewRollingByteBuffer(capacity int) *RollingByteBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &RollingByteBuffer{ring: make([]byte, capacity)}
+}
+
+// oldest returns the absolute offset of the oldest retained byte. It must be
+// called with rb.m held.
+func (rb *RollingByteBuffer) oldest() int64 {
+	if start := rb.written - int64(len(rb.ring)); start > 0 {
+		return start
+	}
+
+	return 0
+}
+
+// copyOut copies the retained bytes from absolute offset off into p, returning
+// how many were copied. It must be called with rb.m held.
+func (rb *RollingByteBuffer) copyOut(p []byte, off int64) int {
+	n := 0
+
+	for n < len(p) && off < rb.written {
+		i := int(off % int64(len(rb.ring)))
+
+		end := len(rb.ring)
+		if remaining := rb.written - off; remaining < int64(end-i) {
+			end = i + int(remaining)
+		}
+
+		c := copy(p[n:], rb.ring[i:end])
+		n += c
+		off += int64(c)
+	}
+
+	return n
+}
+
+// Read implements io.Reader for RollingByteBuffer, reading unread bytes into p.
+// When no unread bytes remain, Read returns (0, nil), or io.EOF once the buffer
+// is closed.
+func (rb *RollingByteBuffer) Read(p []byte) (int, error) {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	if oldest := rb.oldest(); rb.readpos < oldest {
+		rb.readpos = oldest
+	}
+
+	if rb.readpos == rb.written {
+		if rb.closed {
+			return 0, io.EOF
+		}
+
+		return 0, nil
+	}
+
+	n := rb.copyOut(p, rb.readpos)
+	rb.readpos += int64(n)
+
+	return n, nil
+}
+
+// Write implements io.Writer for RollingByteBuffer. Only the last `capacity`
+// bytes of p can be retained, so only those are copied. Write returns
+// os.ErrClosed after Close.
+func (rb *RollingByteBuffer) Write(p []byte) (int, error) {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	if rb.closed {
+		return 0, os.ErrClosed
+	}
+
+	n := len(p)
+	if skip := len(p) - len(rb.ring); skip > 0 {
+		rb.written += int64(skip)
+		p = p[skip:]
+	}
+
+	for len(p) > 0 {
+		c := copy(rb.ring[rb.written%int64(len(rb.ring)):], p)
+		rb.written += int64(c)
+		p = p[c:]
+	}
+
+	return n, nil
+}
+
+// Close closes the buffer for writing. Bytes already written remain readable;
+// once they are drained, Read returns io.EOF. Subsequent calls to Write return
+// os.ErrClosed.
+func (rb *RollingByteBuffer) Close() error {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	rb.closed = true
+
+	return nil
+}
+
+// Len returns the number of bytes currently retained by the buffer.
+func (rb *RollingByteBuffer) Len() int {
+	rb.m.RLock()
+	defer rb.m.RUnlock()
+
+	return int(rb.written - rb.oldest())
+}
+
+// Snapshot returns a copy of every byte currently retained by the buffer,
+// oldest first, regardless of how much has been consumed by Read. It does not
+// affect the read position.
+func (rb *RollingByteBuffer) Snapshot() []byte {
+	rb.m.RLock()
+	defer rb.m.RUnlock()
+
+	oldest := rb.oldest()
+	snapshot := make([]byte, rb.written-oldest)
+	rb.copyOut(snapshot, oldest)
+
+	return snapshot
+}
@@ -0,0 +1,59 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRollingByteBuffer(t *testing.T) {
+	rb := NewRollingByteBuffer(8)
+
+	rb.Write([]byte("abc"))
+
+	buf := make([]byte, 2)
+	if n, err := rb.Read(buf); err != nil || string(buf[:n]) != "ab" {
+		t.Errorf("Read got (%q, %v)", buf[:n], err)
+	}
+
+	// Wraps around the ring, and overwrites the unread "c".
+	rb.Write([]byte("defghijk"))
+
+	if got := string(rb.Snapshot()); got != "defghijk" {
+		t.Errorf("expected snapshot %q, got %q", "defghijk", got)
+	}
+
+	if n := rb.Len(); n != 8 {
+		t.Errorf("expected Len 8, got %d", n)
+	}
+
+	buf = make([]byte, 16)
+	if n, err := rb.Read(buf); err != nil || string(buf[:n]) != "defghijk" {
+		t.Errorf("expected the reader to skip overwritten bytes, got (%q, %v)", buf[:n], err)
+	}
+
+	if n, err := rb.Read(buf); n != 0 || err != nil {
+		t.Errorf("expected (0, nil) when drained, got (%d, %v)", n, err)
+	}
+
+	// Larger than the whole buffer.
+	if n, err := rb.Write([]byte("0123456789")); n != 10 || err != nil {
+		t.Errorf("Write got (%d, %v)", n, err)
+	}
+
+	rb.Close()
+
+	got, err := ioutil.ReadAll(rb)
+	if err != nil || string(got) != "23456789" {
+		t.Errorf("ReadAll got (%q, %v)", got, err)
+	}
+
+	if _, err := rb.Read(buf); err != io.EOF {
+		t.Errorf("expected io.EOF after Close, got %v", err)
+	}
+
+	if _, err := rb.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("expected os.ErrClosed, got %v", err)
+	}
+}
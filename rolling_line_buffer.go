@@ -3,79 +3,363 @@ package miscio
 import (
 	"bytes"
 	"io"
+	"os"
 	"sync"
+	"unicode/utf8"
 )
 
 // RollingLineBuffer provides an implementation of io.Reader and io.Writer that
 // stores the N most recent lines (delimited with '\n') written to it. Reads are
 // done forward-only; it does not implement io.Seeker.
+//
+// All methods are safe for concurrent use. Read-only accessors such as Len and
+// Snapshot take a shared lock, so they do not serialize against each other.
 type RollingLineBuffer struct {
-	m        sync.Mutex
+	m        sync.RWMutex
+	cond     *sync.Cond
 	buf      [][]byte
 	capacity int
 	readpos  int
+	linepos  int
+	closed   bool
+	leak     *leakTracker
+
+	emptyReadMode EmptyReadMode
+	continues     func(line []byte) bool
+	maxLineLength int
+
+	// Counters reported by Metrics.
+	writes       uint64
+	reads        uint64
+	linesDropped uint64
+}
+
+// EmptyReadMode controls what (*RollingLineBuffer).Read does when every
+// retained line has already been read.
+type EmptyReadMode int
+
+const (
+	// EmptyReadNil returns (0, nil) from Read when the buffer is drained. This
+	// is the default, and matches the behavior of earlier versions.
+	EmptyReadNil EmptyReadMode = iota
+	// EmptyReadEOF returns (0, io.EOF) from Read when the buffer is drained.
+	// Unlike most readers, the EOF is not permanent: once new lines are
+	// written, Read returns them.
+	EmptyReadEOF
+	// EmptyReadBlock makes Read wait until new lines are written or the buffer
+	// is closed.
+	EmptyReadBlock
+)
+
+// RollingLineBufferOption configures a RollingLineBuffer at construction.
+type RollingLineBufferOption func(rb *RollingLineBuffer)
+
+// WithEmptyReadMode sets the behavior of Read once the buffer is drained. See
+// EmptyReadMode for the available modes.
+func WithEmptyReadMode(mode EmptyReadMode) RollingLineBufferOption {
+	return func(rb *RollingLineBuffer) {
+		rb.emptyReadMode = mode
+	}
+}
+
+// WithContinuation makes the buffer assemble multi-line records. Each line for
+// which continues returns true is joined (with a '\n') onto the record before
+// it instead of being retained as a record of its own, so a record such as a
+// stack trace is counted against capacity once and returned whole by Read.
+//
+// A continuation line is only joined onto a record that has not been read yet;
+// if the previous record was already consumed, the line starts a new record.
+func WithContinuation(continues func(line []byte) bool) RollingLineBufferOption {
+	return func(rb *RollingLineBuffer) {
+		rb.continues = continues
+	}
+}
+
+// WithMaxLineLength caps each retained line (or record, when combined with
+// WithContinuation) at n bytes. Longer lines are truncated at a rune boundary,
+// so a multi-byte UTF-8 character is never split, and may therefore be
+// slightly shorter than n.
+func WithMaxLineLength(n int) RollingLineBufferOption {
+	return func(rb *RollingLineBuffer) {
+		rb.maxLineLength = n
+	}
+}
+
+// LeadingWhitespace is a continuation predicate for use with WithContinuation
+// that treats any line beginning with a space or tab as part of the previous
+// record, which is how most stack traces are formatted.
+func LeadingWhitespace(line []byte) bool {
+	return len(line) > 0 && (line[0] == ' ' || line[0] == '\t')
 }
 
 // NewRollingLineBuffer returns a new RollingLineBuffer that holds `capacity`
 // most recently-written lines.
-func NewRollingLineBuffer(capacity int) *RollingLineBuffer {
-	return &RollingLineBuffer{
+func NewRollingLineBuffer(capacity int, opts ...RollingLineBufferOption) *RollingLineBuffer {
+	rb := &RollingLineBuffer{
 		buf:      make([][]byte, 0, capacity),
 		capacity: capacity,
+		leak:     trackLeak("*miscio.RollingLineBuffer"),
 	}
+	rb.cond = sync.NewCond(&rb.m)
+
+	for _, opt := range opts {
+		opt(rb)
+	}
+
+	return rb
 }
 
 var (
-	_ io.Reader = (*RollingLineBuffer)(nil)
-	_ io.Writer = (*RollingLineBuffer)(nil)
+	_ io.ReadCloser = (*RollingLineBuffer)(nil)
+	_ io.RuneReader = (*RollingLineBuffer)(nil)
+	_ io.Writer     = (*RollingLineBuffer)(nil)
 )
 
 // Read implements io.Reader for RollingLineBuffer. Read reads one or more full
 // lines into buf and returns according to the io.Reader specification. If buf
 // is too small to hold the first available line, Read returns ErrShortBuffer
 // to signal to the caller they need a bigger buffer.
+//
+// When no unread lines remain, Read behaves according to the buffer's
+// EmptyReadMode, except that a closed buffer always returns io.EOF.
 func (rb *RollingLineBuffer) Read(buf []byte) (int, error) {
 	rb.m.Lock()
 	defer rb.m.Unlock()
 
-	if len(rb.buf) == 0 {
-		return 0, nil
+	rb.reads++
+
+	if ok, err := rb.awaitUnread(); !ok {
+		return 0, err
 	}
 
-	if len(rb.buf[rb.readpos]) > len(buf) {
-		return 0, &ErrShortBuffer{minimumSize: len(rb.buf[rb.readpos])}
+	// The first line may have been partially consumed by ReadRune.
+	first := rb.buf[rb.readpos][rb.linepos:]
+	if len(first) > len(buf) {
+		return 0, NewErrShortBuffer(len(first))
 	}
 
-	tmp := make([]byte, 0, len(buf))
-	read := 0
+	read := copy(buf, first)
+	rb.readpos++
+	rb.linepos = 0
+
 	for rb.readpos < len(rb.buf) {
 		if read+len(rb.buf[rb.readpos]) > len(buf) {
 			break
 		}
 
-		tmp = append(tmp, rb.buf[rb.readpos]...)
+		read += copy(buf[read:], rb.buf[rb.readpos])
+		rb.readpos++
+	}
+
+	return read, nil
+}
+
+// ReadRune implements io.RuneReader for RollingLineBuffer. It decodes the next
+// UTF-8 encoded rune from the unread lines, returning '\n' at the end of each
+// line so that line boundaries remain visible. Invalid encodings are returned
+// as (utf8.RuneError, 1). Reads and ReadRunes may be freely interleaved: a
+// Read after a ReadRune returns the remainder of the current line first.
+//
+// When no unread lines remain, ReadRune behaves like Read, returning a zero
+// size with a nil error in EmptyReadNil mode.
+func (rb *RollingLineBuffer) ReadRune() (r rune, size int, err error) {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	if ok, err := rb.awaitUnread(); !ok {
+		return 0, 0, err
+	}
+
+	line := rb.buf[rb.readpos][rb.linepos:]
+	if len(line) == 0 {
 		rb.readpos++
+		rb.linepos = 0
+
+		return '\n', 1, nil
 	}
 
-	return copy(buf, tmp), nil
+	r, size = utf8.DecodeRune(line)
+	rb.linepos += size
+
+	return r, size, nil
 }
 
-// Write implements io.Writer for RollingLineBuffer.
+// awaitUnread must be called with rb.m held. It returns true once there is an
+// unread line, or false along with the error (possibly nil) that a read should
+// return according to the buffer's EmptyReadMode.
+func (rb *RollingLineBuffer) awaitUnread() (bool, error) {
+	for rb.readpos >= len(rb.buf) {
+		switch {
+		case rb.closed:
+			return false, io.EOF
+		case rb.emptyReadMode == EmptyReadEOF:
+			return false, io.EOF
+		case rb.emptyReadMode == EmptyReadBlock:
+			rb.cond.Wait()
+		default:
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// Close closes the buffer for writing. Lines already written remain readable;
+// once they are drained, Read returns io.EOF, including any Reads blocked in
+// EmptyReadBlock mode. Subsequent calls to Write return os.ErrClosed.
+func (rb *RollingLineBuffer) Close() error {
+	rb.m.Lock()
+	defer rb.m.Unlock()
+
+	rb.closed = true
+	rb.cond.Broadcast()
+	rb.leak.markClosed()
+
+	return nil
+}
+
+// Write implements io.Writer for RollingLineBuffer. The data is copied, so the
+// caller may reuse it once Write returns. Splitting and copying happen before
+// the lock is taken, keeping the critical section short for concurrent writers.
 func (rb *RollingLineBuffer) Write(data []byte) (int, error) {
-	lines := bytes.Split(data, []byte{'\n'})
+	lines := bytes.Split(append([]byte(nil), data...), []byte{'\n'})
+
+	// A leading continuation line can only be joined once the lock is held,
+	// since it belongs to a record from a previous write.
+	var leading []byte
+	if rb.continues != nil && len(lines) > 0 {
+		// A newline ends the record's last line rather than starting an
+		// empty one, which would otherwise be what a later continuation
+		// line is joined onto.
+		if len(lines) > 1 && len(lines[len(lines)-1]) == 0 {
+			lines = lines[:len(lines)-1]
+		}
+
+		joinsPrevious := rb.continues(lines[0])
+		lines = joinContinuations(lines, rb.continues)
+
+		if joinsPrevious {
+			leading, lines = lines[0], lines[1:]
+		}
+	}
+
+	for i, line := range lines {
+		lines[i] = rb.clip(line)
+	}
+
+	// Lines that would be evicted by this write alone never need to enter
+	// the ring.
+	preDropped := 0
+	if len(lines) > rb.capacity {
+		preDropped = len(lines) - rb.capacity
+		lines = lines[preDropped:]
+	}
 
 	rb.m.Lock()
 	defer rb.m.Unlock()
 
+	if rb.closed {
+		return 0, os.ErrClosed
+	}
+
+	if leading != nil {
+		if last := len(rb.buf) - 1; last >= rb.readpos && last >= 0 {
+			rb.buf[last] = rb.clip(joinLine(rb.buf[last], leading))
+		} else {
+			lines = append([][]byte{rb.clip(leading)}, lines...)
+		}
+	}
+
+	rb.writes++
+	rb.linesDropped += uint64(preDropped)
+
 	rb.buf = append(rb.buf, lines...)
 	if len(rb.buf) > rb.capacity {
 		shift := len(rb.buf) - rb.capacity
+		rb.linesDropped += uint64(shift)
 		rb.buf = rb.buf[shift:]
 		rb.readpos -= shift
 		if rb.readpos < 0 {
 			rb.readpos = 0
+			rb.linepos = 0
 		}
 	}
 
+	rb.cond.Broadcast()
+
 	return len(data), nil
 }
+
+// Len returns the number of lines currently retained by the buffer.
+func (rb *RollingLineBuffer) Len() int {
+	rb.m.RLock()
+	defer rb.m.RUnlock()
+
+	return len(rb.buf)
+}
+
+// Snapshot returns a copy of every line currently retained by the buffer,
+// oldest first, regardless of how much has been consumed by Read. It does not
+// affect the read position.
+func (rb *RollingLineBuffer) Snapshot() []string {
+	rb.m.RLock()
+	defer rb.m.RUnlock()
+
+	lines := make([]string, len(rb.buf))
+	for i, line := range rb.buf {
+		lines[i] = string(line)
+	}
+
+	return lines
+}
+
+// clip applies the buffer's maximum line length, if any, to line.
+func (rb *RollingLineBuffer) clip(line []byte) []byte {
+	if rb.maxLineLength <= 0 {
+		return line
+	}
+
+	return truncateUTF8(line, rb.maxLineLength)
+}
+
+// joinContinuations folds each line for which continues returns true into the
+// line before it.
+func joinContinuations(lines [][]byte, continues func(line []byte) bool) [][]byte {
+	records := lines[:0]
+
+	for _, line := range lines {
+		if len(records) > 0 && continues(line) {
+			records[len(records)-1] = joinLine(records[len(records)-1], line)
+
+			continue
+		}
+
+		records = append(records, line)
+	}
+
+	return records
+}
+
+// joinLine returns record and line joined by a newline. record is never
+// appended to in place, since it may share a backing array with other lines.
+func joinLine(record, line []byte) []byte {
+	joined := make([]byte, 0, len(record)+1+len(line))
+	joined = append(joined, record...)
+	joined = append(joined, '\n')
+
+	return append(joined, line...)
+}
+
+// truncateUTF8 returns the longest prefix of b that is at most n bytes long and
+// does not end partway through a UTF-8 encoded rune.
+func truncateUTF8(b []byte, n int) []byte {
+	if len(b) <= n {
+		return b
+	}
+
+	for n > 0 && !utf8.RuneStart(b[n]) {
+		n--
+	}
+
+	return b[:n]
+}
@@ -0,0 +1,57 @@
+package miscio
+
+import "expvar"
+
+// RollingLineBufferMetrics is a point-in-time view of a RollingLineBuffer's
+// internals, as returned by (*RollingLineBuffer).Metrics.
+type RollingLineBufferMetrics struct {
+	// LinesRetained is the number of lines (or records) currently held.
+	LinesRetained int `json:"lines_retained"`
+	// LinesDropped is the total number of lines evicted to make room for
+	// newer ones, whether or not they had been read.
+	LinesDropped uint64 `json:"lines_dropped"`
+	// BytesBuffered is the total size of the retained lines.
+	BytesBuffered int `json:"bytes_buffered"`
+	// Writes and Reads count calls to Write and Read respectively.
+	Writes uint64 `json:"writes"`
+	Reads  uint64 `json:"reads"`
+}
+
+// Each calls fn once for every metric, with a stable snake_case name. It lets
+// callers feed any metrics system (e.g. a prometheus.Collector's Collect
+// method) without this package depending on it.
+func (m RollingLineBufferMetrics) Each(fn func(name string, value float64)) {
+	fn("lines_retained", float64(m.LinesRetained))
+	fn("lines_dropped", float64(m.LinesDropped))
+	fn("bytes_buffered", float64(m.BytesBuffered))
+	fn("writes", float64(m.Writes))
+	fn("reads", float64(m.Reads))
+}
+
+// Metrics returns the buffer's current metrics. It takes only a shared lock,
+// so it is cheap to call from a metrics scraper while the buffer is in use.
+func (rb *RollingLineBuffer) Metrics() RollingLineBufferMetrics {
+	rb.m.RLock()
+	defer rb.m.RUnlock()
+
+	bytesBuffered := 0
+	for _, line := range rb.buf {
+		bytesBuffered += len(line)
+	}
+
+	return RollingLineBufferMetrics{
+		LinesRetained: len(rb.buf),
+		LinesDropped:  rb.linesDropped,
+		BytesBuffered: bytesBuffered,
+		Writes:        rb.writes,
+		Reads:         rb.reads,
+	}
+}
+
+// ExpvarVar returns an expvar.Var that renders the buffer's Metrics as a JSON
+// object, suitable for expvar.Publish.
+func (rb *RollingLineBuffer) ExpvarVar() expvar.Var {
+	return expvar.Func(func() interface{} {
+		return rb.Metrics()
+	})
+}
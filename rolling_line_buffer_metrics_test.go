@@ -0,0 +1,41 @@
+package miscio
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRollingLineBufferMetrics(t *testing.T) {
+	rb := NewRollingLineBuffer(2)
+	rb.Write([]byte("one\ntwo\nthree"))
+	rb.Write([]byte("four"))
+	rb.Read(make([]byte, 16))
+
+	want := RollingLineBufferMetrics{
+		LinesRetained: 2,
+		LinesDropped:  2,
+		BytesBuffered: 9,
+		Writes:        2,
+		Reads:         1,
+	}
+
+	if got := rb.Metrics(); got != want {
+		t.Errorf("Metrics mismatch; got %+v want %+v", got, want)
+	}
+
+	var decoded RollingLineBufferMetrics
+	if err := json.Unmarshal([]byte(rb.ExpvarVar().String()), &decoded); err != nil {
+		t.Fatalf("ExpvarVar did not render JSON: %s", err)
+	}
+
+	if decoded != want {
+		t.Errorf("ExpvarVar mismatch; got %+v want %+v", decoded, want)
+	}
+
+	names := map[string]float64{}
+	want.Each(func(name string, value float64) { names[name] = value })
+
+	if len(names) != 5 || names["lines_dropped"] != 2 {
+		t.Errorf("Each mismatch; got %v", names)
+	}
+}
@@ -1,6 +1,11 @@
 package miscio
 
-import "testing"
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
 
 func TestRollingLineBuffer(t *testing.T) {
 	rb := NewRollingLineBuffer(2)
@@ -26,3 +31,194 @@ func assertBufferContents(t *testing.T, expectedBuffer []string, rb *RollingLine
 		}
 	}
 }
+
+func TestRollingLineBufferCopiesWrites(t *testing.T) {
+	rb := NewRollingLineBuffer(2)
+	data := []byte("hello\nworld")
+
+	if _, err := rb.Write(data); err != nil {
+		t.Fatalf("Write failed with %s", err)
+	}
+
+	copy(data, "HELLO\nWORLD")
+
+	assertBufferContents(t, []string{"hello", "world"}, rb)
+}
+
+func TestRollingLineBufferSnapshot(t *testing.T) {
+	rb := NewRollingLineBuffer(3)
+	rb.Write([]byte("one\ntwo"))
+	rb.Read(make([]byte, 16))
+
+	snap := rb.Snapshot()
+	if len(snap) != 2 || snap[0] != "one" || snap[1] != "two" {
+		t.Errorf("Snapshot mismatch; got %v", snap)
+	}
+
+	if rb.Len() != 2 {
+		t.Errorf("Len mismatch; got %d want 2", rb.Len())
+	}
+}
+
+func TestRollingLineBufferEmptyReadModes(t *testing.T) {
+	buf := make([]byte, 16)
+
+	rb := NewRollingLineBuffer(2)
+	rb.Write([]byte("a"))
+	rb.Read(buf)
+
+	for i := 0; i < 2; i++ {
+		if n, err := rb.Read(buf); n != 0 || err != nil {
+			t.Errorf("default mode: got (%d, %v) want (0, nil)", n, err)
+		}
+	}
+
+	rb = NewRollingLineBuffer(2, WithEmptyReadMode(EmptyReadEOF))
+	if _, err := rb.Read(buf); err != io.EOF {
+		t.Errorf("EOF mode: got %v want io.EOF", err)
+	}
+
+	rb.Write([]byte("b"))
+	if n, err := rb.Read(buf); n != 1 || err != nil {
+		t.Errorf("EOF mode after write: got (%d, %v) want (1, nil)", n, err)
+	}
+}
+
+func TestRollingLineBufferBlockingRead(t *testing.T) {
+	rb := NewRollingLineBuffer(2, WithEmptyReadMode(EmptyReadBlock))
+	buf := make([]byte, 16)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		rb.Write([]byte("late"))
+	}()
+
+	n, err := rb.Read(buf)
+	if err != nil || string(buf[:n]) != "late" {
+		t.Errorf("blocking Read: got (%q, %v) want (\"late\", nil)", buf[:n], err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		rb.Close()
+	}()
+
+	if _, err := rb.Read(buf); err != io.EOF {
+		t.Errorf("blocking Read after Close: got %v want io.EOF", err)
+	}
+}
+
+func TestRollingLineBufferContinuation(t *testing.T) {
+	rb := NewRollingLineBuffer(2, WithContinuation(LeadingWhitespace))
+	rb.Write([]byte("starting\nException in thread main\n\tat Foo.bar"))
+	rb.Write([]byte("\tat Foo.main"))
+
+	assertBufferContents(t, []string{"starting", "Exception in thread main\n\tat Foo.bar\n\tat Foo.main"}, rb)
+
+	rb = NewRollingLineBuffer(2, WithContinuation(LeadingWhitespace))
+	rb.Write([]byte("Exception in thread main"))
+	rb.Write([]byte("\tat Foo.bar\n\tat Foo.main"))
+
+	assertBufferContents(t, []string{"Exception in thread main\n\tat Foo.bar\n\tat Foo.main"}, rb)
+
+	buf := make([]byte, 64)
+	n, _ := rb.Read(buf)
+
+	if string(buf[:n]) != "Exception in thread main\n\tat Foo.bar\n\tat Foo.main" {
+		t.Errorf("Read mismatch; got %q", buf[:n])
+	}
+
+	// The previous record was consumed, so this starts a new one.
+	rb.Write([]byte("\tat Foo.late"))
+	assertBufferContents(t, []string{"Exception in thread main\n\tat Foo.bar\n\tat Foo.main", "\tat Foo.late"}, rb)
+}
+
+func TestRollingLineBufferContinuationTerminated(t *testing.T) {
+	rb := NewRollingLineBuffer(3, WithContinuation(LeadingWhitespace))
+	fmt.Fprintln(rb, "Exception in thread main")
+	fmt.Fprintln(rb, "\tat Foo.bar")
+	fmt.Fprintln(rb, "\tat Foo.main")
+	fmt.Fprintln(rb, "done")
+
+	assertBufferContents(t, []string{"Exception in thread main\n\tat Foo.bar\n\tat Foo.main", "done"}, rb)
+}
+
+func TestRollingLineBufferReadRune(t *testing.T) {
+	rb := NewRollingLineBuffer(2, WithEmptyReadMode(EmptyReadEOF))
+	rb.Write([]byte("héllo\n世界"))
+
+	var got []rune
+
+	for {
+		r, _, err := rb.ReadRune()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("ReadRune failed with %s", err)
+		}
+
+		got = append(got, r)
+	}
+
+	if string(got) != "héllo\n世界\n" {
+		t.Errorf("ReadRune mismatch; got %q", string(got))
+	}
+
+	rb.Write([]byte("世界"))
+	rb.ReadRune()
+
+	buf := make([]byte, 16)
+	n, _ := rb.Read(buf)
+
+	if string(buf[:n]) != "界" {
+		t.Errorf("Read after ReadRune should return rest of line; got %q", buf[:n])
+	}
+}
+
+func TestRollingLineBufferMaxLineLength(t *testing.T) {
+	rb := NewRollingLineBuffer(2, WithMaxLineLength(4))
+	rb.Write([]byte("abcdef\nab世界"))
+
+	assertBufferContents(t, []string{"abcd", "ab"}, rb)
+}
+
+func BenchmarkRollingLineBufferWriteParallel(b *testing.B) {
+	rb := NewRollingLineBuffer(1024)
+	line := []byte("level=info msg=\"handled request\" status=200")
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rb.Write(line)
+		}
+	})
+}
+
+func BenchmarkRollingLineBufferWriteWithReaders(b *testing.B) {
+	rb := NewRollingLineBuffer(1024)
+	line := []byte("level=info msg=\"handled request\" status=200")
+	done := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					rb.Len()
+				}
+			}
+		}()
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rb.Write(line)
+		}
+	})
+	close(done)
+}
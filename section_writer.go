@@ -0,0 +1,76 @@
+package miscio
+
+import (
+	"fmt"
+	"io"
+)
+
+// SectionWriter implements io.WriterAt (and io.Writer) on a section of an
+// underlying io.WriterAt, like io.SectionReader does for io.ReaderAt. Offsets
+// passed to WriteAt are relative to the start of the section, and writes may
+// not extend past its end. It is handy for giving each of several download
+// workers its own window of a shared file or WriterAtReadCloser.
+type SectionWriter struct {
+	w     io.WriterAt
+	base  int64
+	limit int64
+	pos   int64
+}
+
+var (
+	_ io.WriterAt = (*SectionWriter)(nil)
+	_ io.Writer   = (*SectionWriter)(nil)
+	_ Flusher     = (*SectionWriter)(nil)
+)
+
+// NewSectionWriter returns a SectionWriter that writes to w starting at offset
+// off and stops with an error after n bytes.
+func NewSectionWriter(w io.WriterAt, off, n int64) *SectionWriter {
+	return &SectionWriter{w: w, base: off, limit: off + n}
+}
+
+// WriteAt writes p at offset off within the section. If p extends past the end
+// of the section, the part that fits is written, and WriteAt returns the number
+// of bytes written along with an error wrapping ErrBeyondSize. WriteAt is safe
+// for concurrent use if the underlying io.WriterAt is.
+func (sw *SectionWriter) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrNegativeOffset, off)
+	}
+
+	off += sw.base
+	if off >= sw.limit {
+		return 0, fmt.Errorf("%w: offset %d is past the end of the section", ErrBeyondSize, off-sw.base)
+	}
+
+	if max := sw.limit - off; int64(len(p)) > max {
+		n, err := sw.w.WriteAt(p[:max], off)
+		if err == nil {
+			err = fmt.Errorf("%w: write of %d bytes at offset %d exceeds section size %d",
+				ErrBeyondSize, len(p), off-sw.base, sw.Size())
+		}
+
+		return n, err
+	}
+
+	return sw.w.WriteAt(p, off)
+}
+
+// Write writes p at the current position in the section, and advances the
+// position by the number of bytes written, so a SectionWriter can be the
+// destination of io.Copy. Write is not safe for concurrent use.
+func (sw *SectionWriter) Write(p []byte) (int, error) {
+	n, err := sw.WriteAt(p, sw.pos)
+	sw.pos += int64(n)
+
+	return n, err
+}
+
+// Size returns the size of the section in bytes.
+func (sw *SectionWriter) Size() int64 { return sw.limit - sw.base }
+
+// Flush implements Flusher for SectionWriter by flushing the underlying
+// io.WriterAt, e.g. a BufferedWriterAt.
+func (sw *SectionWriter) Flush() error {
+	return flushNext(sw.w)
+}
@@ -0,0 +1,32 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSectionWriter(t *testing.T) {
+	w := NewWriterAtReadSeeker(0)
+	first, second := NewSectionWriter(w, 0, 6), NewSectionWriter(w, 6, 5)
+
+	if _, err := io.Copy(second, strings.NewReader("world")); err != nil {
+		t.Fatalf("io.Copy into section failed with %s", err)
+	}
+
+	first.WriteAt([]byte("hello "), 0)
+
+	buf := make([]byte, 11)
+	if _, err := w.ReadAt(buf, 0); err != nil || string(buf) != "hello world" {
+		t.Errorf("ReadAt got (%q, %v)", buf, err)
+	}
+
+	if n, err := second.WriteAt([]byte("there"), 3); n != 2 || !errors.Is(err, ErrBeyondSize) {
+		t.Errorf("write past the section end got (%d, %v), want (2, ErrBeyondSize)", n, err)
+	}
+
+	if _, err := first.WriteAt([]byte("x"), -1); !errors.Is(err, ErrNegativeOffset) {
+		t.Errorf("expected ErrNegativeOffset, got %v", err)
+	}
+}
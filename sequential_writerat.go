@@ -0,0 +1,157 @@
+package miscio
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// SequentialWriterAt adapts an io.Writer, such as a socket or pipe, to
+// io.WriterAt. Bytes that continue the stream written so far go straight
+// through to the writer; bytes written ahead of that are buffered in memory,
+// and written through as soon as the gap before them is filled. Unlike
+// WriterAtReadCloser, there is no reader side: it suits sinks that should
+// receive data as soon as possible, in order.
+//
+// Overlapping buffered writes are last-writer-wins. All methods are safe for
+// concurrent use.
+type SequentialWriterAt struct {
+	m           sync.Mutex
+	w           io.Writer
+	maxBuffered int64
+	written     int64
+	pending     IntervalSet
+	chunks      []bufChunk // Buffered ahead of written, in ascending order.
+	err         error      // Sticky error from w.
+}
+
+var (
+	_ io.WriterAt = (*SequentialWriterAt)(nil)
+	_ Flusher     = (*SequentialWriterAt)(nil)
+)
+
+// NewSequentialWriterAt returns a SequentialWriterAt writing to w, which
+// buffers up to maxBuffered bytes ahead of the stream (unlimited if
+// maxBuffered is not positive).
+func NewSequentialWriterAt(w io.Writer, maxBuffered int64) *SequentialWriterAt {
+	return &SequentialWriterAt{w: w, maxBuffered: maxBuffered}
+}
+
+// WriteAt implements io.WriterAt for SequentialWriterAt. It returns an error
+// wrapping ErrOffsetConsumed for a write that starts before the end of what has
+// already been written through, since that can no longer be changed, and one
+// wrapping ErrBufferFull if buffering the write would exceed the limit. Once
+// writing through fails, every WriteAt returns that error.
+func (sw *SequentialWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrNegativeOffset, off)
+	}
+
+	sw.m.Lock()
+	defer sw.m.Unlock()
+
+	switch {
+	case sw.err != nil:
+		return 0, sw.err
+	case off < sw.written:
+		return 0, fmt.Errorf("%w: write at offset %d, but %d bytes have been written through",
+			ErrOffsetConsumed, off, sw.written)
+	case off == sw.written:
+		return sw.writeThrough(p)
+	}
+
+	end := off + int64(len(p))
+	if sw.maxBuffered > 0 {
+		after := sw.pending.Len() + int64(len(p))
+		for _, r := range sw.pending.Covered(off, end) {
+			after -= r.Len()
+		}
+
+		if after > sw.maxBuffered {
+			return 0, fmt.Errorf("%w: buffering %d bytes at offset %d would exceed limit of %d",
+				ErrBufferFull, len(p), off, sw.maxBuffered)
+		}
+	}
+
+	sw.chunks = insertChunk(sw.chunks, p, off)
+	sw.pending.Add(off, end)
+
+	return len(p), nil
+}
+
+// writeThrough writes p, which continues the stream, to the writer, followed
+// by any buffered chunks that then continue it. It returns how many bytes of p
+// were written. It must be called with sw.m held.
+func (sw *SequentialWriterAt) writeThrough(p []byte) (int, error) {
+	start := sw.written
+	end := start + int64(len(p))
+
+	for {
+		n, err := sw.w.Write(p)
+		if err == nil && n < len(p) {
+			err = io.ErrShortWrite
+		}
+
+		sw.written += int64(n)
+		sw.pending.Remove(0, sw.written)
+
+		if err != nil {
+			sw.err = err
+
+			if sw.written > end {
+				// p was written; a buffered chunk after it failed.
+				return int(end - start), err
+			}
+
+			return int(sw.written - start), err
+		}
+
+		p = nil
+
+		for len(sw.chunks) > 0 && sw.chunks[0].off <= sw.written {
+			c := sw.chunks[0]
+			sw.chunks = sw.chunks[1:]
+
+			if c.end() > sw.written {
+				p = c.data[sw.written-c.off:]
+
+				break
+			}
+		}
+
+		if len(sw.chunks) == 0 {
+			sw.chunks = nil
+		}
+
+		if p == nil {
+			return int(end - start), nil
+		}
+	}
+}
+
+// Flush implements Flusher for SequentialWriterAt. Buffered data can only be
+// written once the gap before it is filled, so Flush only flushes the
+// underlying writer.
+func (sw *SequentialWriterAt) Flush() error {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+
+	return flushNext(sw.w)
+}
+
+// Written returns the number of bytes written through to the writer, i.e. the
+// offset the stream continues from.
+func (sw *SequentialWriterAt) Written() int64 {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+
+	return sw.written
+}
+
+// Pending returns the ranges buffered ahead of the stream, in ascending order.
+func (sw *SequentialWriterAt) Pending() []Range {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+
+	return sw.pending.Ranges()
+}
@@ -0,0 +1,76 @@
+package miscio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSequentialWriterAt(t *testing.T) {
+	rw := NewRecordingWriter(nil)
+	sw := NewSequentialWriterAt(rw, 0)
+
+	sw.WriteAt([]byte("ef"), 4)
+	sw.WriteAt([]byte("ij"), 8)
+
+	if len(rw.Calls()) != 0 {
+		t.Fatalf("expected nothing written through before offset 0 arrives")
+	}
+
+	if pending := sw.Pending(); len(pending) != 2 {
+		t.Errorf("expected 2 pending ranges, got %v", pending)
+	}
+
+	sw.WriteAt([]byte("abcd"), 0)
+
+	if sw.Written() != 6 {
+		t.Errorf("expected the stream to continue through the buffered chunk, got %d written", sw.Written())
+	}
+
+	sw.WriteAt([]byte("gh"), 6)
+
+	var got bytes.Buffer
+	for _, c := range rw.Calls() {
+		got.Write(c.Data)
+	}
+
+	if got.String() != "abcdefghij" || len(sw.Pending()) != 0 {
+		t.Errorf("expected everything written through in order, got %q with %v pending", got.String(), sw.Pending())
+	}
+
+	if _, err := sw.WriteAt([]byte("x"), 9); !errors.Is(err, ErrOffsetConsumed) {
+		t.Errorf("expected ErrOffsetConsumed, got %v", err)
+	}
+}
+
+func TestSequentialWriterAtLimit(t *testing.T) {
+	sw := NewSequentialWriterAt(NewRecordingWriter(nil), 4)
+
+	if _, err := sw.WriteAt([]byte("abc"), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overwriting buffered bytes takes no extra room.
+	if _, err := sw.WriteAt([]byte("abcd"), 10); err != nil {
+		t.Errorf("expected an overlapping write within the limit, got %v", err)
+	}
+
+	if _, err := sw.WriteAt([]byte("x"), 20); !errors.Is(err, ErrBufferFull) {
+		t.Errorf("expected ErrBufferFull, got %v", err)
+	}
+}
+
+func TestSequentialWriterAtError(t *testing.T) {
+	errBoom := errors.New("boom")
+	sw := NewSequentialWriterAt(failingWriter{errBoom}, 0)
+
+	sw.WriteAt([]byte("later"), 5)
+
+	if _, err := sw.WriteAt([]byte("first"), 0); err != errBoom {
+		t.Errorf("expected errBoom, got %v", err)
+	}
+
+	if _, err := sw.WriteAt([]byte("more"), 10); err != errBoom {
+		t.Errorf("expected the error to stick, got %v", err)
+	}
+}
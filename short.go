@@ -0,0 +1,140 @@
+package miscio
+
+import (
+	"io"
+	"math/rand"
+	"sync"
+)
+
+// ShortStrategy decides how many of the n bytes requested by the call'th call
+// (counting from 1) a ShortWriter or ShortReaderAt actually transfers. Results
+// outside [0, n] are clamped.
+type ShortStrategy func(call, n int) int
+
+// ShortByMax transfers at most max bytes per call.
+func ShortByMax(max int) ShortStrategy {
+	return func(call, n int) int { return max }
+}
+
+// ShortByHalf transfers half of each request, rounded up, so that every call
+// makes progress.
+func ShortByHalf() ShortStrategy {
+	return func(call, n int) int { return (n + 1) / 2 }
+}
+
+// ShortByRandom transfers a pseudo-random number of bytes in [1, n] per call,
+// from a source seeded with seed, so a failing run can be reproduced.
+func ShortByRandom(seed int64) ShortStrategy {
+	var m sync.Mutex
+
+	rnd := rand.New(rand.NewSource(seed)) // nolint:gosec
+
+	return func(call, n int) int {
+		if n <= 1 {
+			return n
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		return 1 + rnd.Intn(n)
+	}
+}
+
+// shortener applies a ShortStrategy, counting calls.
+type shortener struct {
+	m        sync.Mutex
+	calls    int
+	strategy ShortStrategy
+}
+
+func (s *shortener) limit(n int) int {
+	s.m.Lock()
+	s.calls++
+	call := s.calls
+	s.m.Unlock()
+
+	allowed := s.strategy(call, n)
+
+	switch {
+	case allowed < 0:
+		return 0
+	case allowed > n:
+		return n
+	default:
+		return allowed
+	}
+}
+
+// ShortWriter wraps an io.Writer, deliberately writing fewer bytes than asked
+// according to its ShortStrategy, to flush out callers that don't handle short
+// writes. It is safe for concurrent use if the underlying writer is.
+type ShortWriter struct {
+	// Err is returned along with every short write. It is nil by default,
+	// which breaks the io.Writer contract the same way buggy writers in the
+	// wild do; set it to io.ErrShortWrite to model a well-behaved writer.
+	Err error
+
+	w io.Writer
+	s shortener
+}
+
+var (
+	_ io.Writer = (*ShortWriter)(nil)
+	_ Flusher   = (*ShortWriter)(nil)
+)
+
+// NewShortWriter returns a ShortWriter writing to w.
+func NewShortWriter(w io.Writer, strategy ShortStrategy) *ShortWriter {
+	return &ShortWriter{w: w, s: shortener{strategy: strategy}}
+}
+
+// Write implements io.Writer for ShortWriter.
+func (sw *ShortWriter) Write(p []byte) (int, error) {
+	allowed := sw.s.limit(len(p))
+
+	n, err := sw.w.Write(p[:allowed])
+	if err == nil && n < len(p) {
+		err = sw.Err
+	}
+
+	return n, err
+}
+
+// Flush implements Flusher for ShortWriter by flushing the underlying writer.
+func (sw *ShortWriter) Flush() error {
+	return flushNext(sw.w)
+}
+
+// ShortReaderAt wraps an io.ReaderAt, deliberately reading fewer bytes than
+// asked according to its ShortStrategy, to flush out callers that assume
+// ReadAt always fills the buffer. It is safe for concurrent use if the
+// underlying io.ReaderAt is.
+type ShortReaderAt struct {
+	// Err is returned along with every short read that the underlying
+	// io.ReaderAt did not fail itself. It is nil by default, which breaks the
+	// io.ReaderAt contract the same way buggy implementations in the wild do.
+	Err error
+
+	r io.ReaderAt
+	s shortener
+}
+
+var _ io.ReaderAt = (*ShortReaderAt)(nil)
+
+// NewShortReaderAt returns a ShortReaderAt reading from r.
+func NewShortReaderAt(r io.ReaderAt, strategy ShortStrategy) *ShortReaderAt {
+	return &ShortReaderAt{r: r, s: shortener{strategy: strategy}}
+}
+
+// ReadAt implements io.ReaderAt for ShortReaderAt.
+func (sr *ShortReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	allowed := sr.s.limit(len(p))
+
+	n, err := sr.r.ReadAt(p[:allowed], off)
+	if err == nil && n < len(p) {
+		err = sr.Err
+	}
+
+	return n, err
+}
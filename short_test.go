@@ -0,0 +1,77 @@
+package miscio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestShortWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	sw := NewShortWriter(&buf, ShortByHalf())
+
+	if n, err := sw.Write([]byte("abcde")); n != 3 || err != nil {
+		t.Errorf("expected a silent short write of 3 bytes, got (%d, %v)", n, err)
+	}
+
+	sw.Err = io.ErrShortWrite
+
+	// io.Copy notices the short write.
+	if _, err := io.Copy(sw, strings.NewReader("fghij")); err != io.ErrShortWrite {
+		t.Errorf("expected io.ErrShortWrite from io.Copy, got %v", err)
+	}
+
+	if buf.String() != "abcfgh" {
+		t.Errorf("expected %q written, got %q", "abcfgh", buf.String())
+	}
+}
+
+func TestShortByMax(t *testing.T) {
+	var buf bytes.Buffer
+
+	sw := NewShortWriter(&buf, ShortByMax(6))
+
+	if n, err := sw.Write([]byte("abcdefgh")); n != 6 || err != nil {
+		t.Errorf("expected a silent short write of 6 bytes, got (%d, %v)", n, err)
+	}
+
+	if n, err := sw.Write([]byte("ij")); n != 2 || err != nil {
+		t.Errorf("expected a write within the max to be complete, got (%d, %v)", n, err)
+	}
+
+	if buf.String() != "abcdefij" {
+		t.Errorf("expected %q written, got %q", "abcdefij", buf.String())
+	}
+}
+
+func TestShortByRandom(t *testing.T) {
+	a, b := ShortByRandom(42), ShortByRandom(42)
+
+	for i := 1; i <= 100; i++ {
+		n := a(i, 10)
+		if n < 1 || n > 10 {
+			t.Fatalf("expected a count in [1, 10], got %d", n)
+		}
+
+		if m := b(i, 10); m != n {
+			t.Fatalf("expected the same sequence from the same seed, got %d and %d", n, m)
+		}
+	}
+}
+
+func TestShortReaderAt(t *testing.T) {
+	sr := NewShortReaderAt(strings.NewReader("0123456789"), ShortByMax(3))
+
+	buf := make([]byte, 8)
+	if n, err := sr.ReadAt(buf, 2); n != 3 || err != nil || string(buf[:n]) != "234" {
+		t.Errorf("expected a short ReadAt, got (%q, %v)", buf[:n], err)
+	}
+
+	// io.ReadFull over a SectionReader copes with the short reads.
+	got := make([]byte, 8)
+	if _, err := io.ReadFull(io.NewSectionReader(sr, 2, 8), got); err != nil || string(got) != "23456789" {
+		t.Errorf("ReadFull got (%q, %v)", got, err)
+	}
+}
@@ -0,0 +1,114 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// skipReader discards the first n bytes of r on its first use.
+type skipReader struct {
+	r       io.Reader
+	n       int64
+	skipped bool
+	err     error
+
+	// Set when skipping via io.ReaderAt, which is read at off from then on.
+	ra  io.ReaderAt
+	off int64
+}
+
+// SkipReader returns an io.Reader that reads from r after discarding its first
+// n bytes. The skip happens lazily, on the first Read, using the cheapest
+// method r supports: Seek if it is an io.Seeker (and seeking works, which isn't
+// the case for e.g. a pipe), otherwise ReadAt if it is an io.ReaderAt but not
+// an io.Seeker, in which case r is assumed to be at offset 0 and is read with
+// ReadAt from then on. Failing both, the bytes are read and discarded.
+//
+// If r has fewer than n bytes, the first Read returns io.EOF. The returned
+// reader implements io.WriterTo if r does, so io.Copy from it keeps r's
+// optimizations.
+func SkipReader(r io.Reader, n int64) io.Reader {
+	sr := &skipReader{r: r, n: n}
+	if _, ok := r.(io.WriterTo); ok {
+		return skipWriterTo{sr}
+	}
+
+	return sr
+}
+
+// skip discards the prefix, if not done already, and returns the error (if
+// any) from doing so.
+func (sr *skipReader) skip() error {
+	if sr.skipped {
+		return sr.err
+	}
+
+	sr.skipped = true
+
+	if sr.n <= 0 {
+		return nil
+	}
+
+	// A Seeker that can't seek, such as an *os.File for a pipe, can't ReadAt
+	// either, so only readers that can't Seek at all are tried with ReadAt.
+	if s, ok := sr.r.(io.Seeker); ok {
+		if _, err := s.Seek(sr.n, io.SeekCurrent); err == nil {
+			return nil
+		}
+	} else if ra, ok := sr.r.(io.ReaderAt); ok {
+		sr.ra, sr.off = ra, sr.n
+
+		return nil
+	}
+
+	n, err := io.CopyN(ioutil.Discard, sr.r, sr.n)
+	if n < sr.n && err == nil {
+		err = io.EOF
+	}
+
+	sr.err = err
+
+	return err
+}
+
+func (sr *skipReader) Read(p []byte) (int, error) {
+	if err := sr.skip(); err != nil {
+		return 0, err
+	}
+
+	if sr.ra != nil {
+		n, err := sr.ra.ReadAt(p, sr.off)
+		sr.off += int64(n)
+
+		if n > 0 && err == io.EOF {
+			// ReaderAt may return io.EOF with the final bytes; Read is
+			// expected to report it on the next call instead.
+			err = nil
+		}
+
+		return n, err
+	}
+
+	return sr.r.Read(p)
+}
+
+// skipWriterTo is a skipReader over an io.WriterTo.
+type skipWriterTo struct {
+	*skipReader
+}
+
+func (sw skipWriterTo) WriteTo(w io.Writer) (int64, error) {
+	if err := sw.skip(); err != nil {
+		if err == io.EOF {
+			err = nil
+		}
+
+		return 0, err
+	}
+
+	if sw.ra != nil {
+		return io.Copy(w, struct{ io.Reader }{sw.skipReader})
+	}
+
+	return sw.r.(io.WriterTo).WriteTo(w)
+}
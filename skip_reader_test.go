@@ -0,0 +1,86 @@
+package miscio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSkipReader(t *testing.T) {
+	// Only io.Reader.
+	onlyReader := func(s string) io.Reader { return struct{ io.Reader }{strings.NewReader(s)} }
+	// io.ReaderAt, but no Seek.
+	onlyReaderAt := func(s string) io.Reader {
+		return struct {
+			io.Reader
+			io.ReaderAt
+		}{strings.NewReader(s), strings.NewReader(s)}
+	}
+
+	for _, tc := range []struct {
+		name string
+		r    func(s string) io.Reader
+	}{
+		{"seeker", func(s string) io.Reader { return strings.NewReader(s) }},
+		{"reader-at", onlyReaderAt},
+		{"reader", onlyReader},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ioutil.ReadAll(SkipReader(tc.r("header:body"), 7))
+			if err != nil || string(got) != "body" {
+				t.Errorf("expected %q, got (%q, %v)", "body", got, err)
+			}
+
+			got, err = ioutil.ReadAll(SkipReader(tc.r("short"), 10))
+			if err != nil || len(got) != 0 {
+				t.Errorf("expected nothing from a reader shorter than the skip, got (%q, %v)", got, err)
+			}
+		})
+	}
+}
+
+func TestSkipReaderWriterTo(t *testing.T) {
+	r := SkipReader(bytes.NewBufferString("0123456789"), 4)
+	if _, ok := r.(io.WriterTo); !ok {
+		t.Fatal("expected the WriterTo of a bytes.Buffer to be kept")
+	}
+
+	var buf bytes.Buffer
+	if n, err := io.Copy(&buf, r); err != nil || n != 6 || buf.String() != "456789" {
+		t.Errorf("Copy got (%d, %v, %q)", n, err, buf.String())
+	}
+}
+
+func TestSkipReaderUnseekable(t *testing.T) {
+	// A pipe is an *os.File, so claims to be an io.Seeker, but can't seek.
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+
+	go func() {
+		pw.Write([]byte("skip me, then read this"))
+		pw.Close()
+	}()
+
+	got, err := ioutil.ReadAll(SkipReader(pr, 9))
+	if err != nil || string(got) != "then read this" {
+		t.Errorf("got (%q, %v)", got, err)
+	}
+
+	// A regular file seeks.
+	path := filepath.Join(t.TempDir(), "f")
+	ioutil.WriteFile(path, []byte("skip me, then read this"), 0o600)
+
+	f, _ := os.Open(path)
+	defer f.Close()
+
+	if got, err := ioutil.ReadAll(SkipReader(f, 9)); err != nil || string(got) != "then read this" {
+		t.Errorf("got (%q, %v)", got, err)
+	}
+}
@@ -0,0 +1,58 @@
+package miscio
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowReader(t *testing.T) {
+	lat := Latency{PerCall: 5 * time.Millisecond, PerByte: time.Millisecond}
+	sr := NewSlowReader(context.Background(), strings.NewReader("0123456789"), lat)
+
+	start := time.Now()
+
+	got, err := ioutil.ReadAll(sr)
+	if err != nil || string(got) != "0123456789" {
+		t.Errorf("ReadAll got (%q, %v)", got, err)
+	}
+
+	// At least one call reading 10 bytes, and one returning io.EOF.
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected at least 20ms of delay, took %v", elapsed)
+	}
+}
+
+func TestSlowWriterCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var buf bytes.Buffer
+
+	sw := NewSlowWriter(ctx, &buf, Latency{PerCall: time.Hour, Jitter: time.Second})
+
+	if n, err := sw.Write([]byte("never")); n != 0 || err != context.DeadlineExceeded {
+		t.Errorf("expected the delay to be cut short, got (%d, %v)", n, err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written, got %q", buf.String())
+	}
+}
+
+func TestSlowWriterAt(t *testing.T) {
+	sw := NewSlowWriterAt(context.Background(), DiscardWriterAt, Latency{PerCall: 5 * time.Millisecond})
+
+	start := time.Now()
+
+	if n, err := sw.WriteAt([]byte("abc"), 10); n != 3 || err != nil {
+		t.Errorf("WriteAt got (%d, %v)", n, err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Errorf("expected at least 5ms of delay, took %v", elapsed)
+	}
+}
@@ -0,0 +1,120 @@
+package miscio
+
+import "io"
+
+// StickyErrorWriter wraps an io.Writer, remembering the first error it returns
+// and failing every later Write with that error without calling it again. This
+// allows writing many times and checking the error once at the end:
+//
+//	sw := miscio.NewStickyErrorWriter(w)
+//	fmt.Fprintf(sw, "header\n")
+//	for _, row := range rows {
+//		fmt.Fprintf(sw, "%s\n", row)
+//	}
+//	if err := sw.Err(); err != nil {
+//		return err
+//	}
+//
+// A StickyErrorWriter is not safe for concurrent use.
+type StickyErrorWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+var (
+	_ io.Writer = (*StickyErrorWriter)(nil)
+	_ Flusher   = (*StickyErrorWriter)(nil)
+)
+
+// NewStickyErrorWriter returns a StickyErrorWriter writing to w.
+func NewStickyErrorWriter(w io.Writer) *StickyErrorWriter {
+	return &StickyErrorWriter{w: w}
+}
+
+// Write implements io.Writer for StickyErrorWriter. A short write without an
+// error is recorded as io.ErrShortWrite.
+func (sw *StickyErrorWriter) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+
+	n, err := sw.w.Write(p)
+	sw.n += int64(n)
+
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+
+	sw.err = err
+
+	return n, err
+}
+
+// Flush implements Flusher for StickyErrorWriter by flushing the underlying
+// writer. An error from flushing sticks like one from Write.
+func (sw *StickyErrorWriter) Flush() error {
+	if sw.err != nil {
+		return sw.err
+	}
+
+	sw.err = flushNext(sw.w)
+
+	return sw.err
+}
+
+// Err returns the first error encountered, if any.
+func (sw *StickyErrorWriter) Err() error {
+	return sw.err
+}
+
+// Written returns the number of bytes written successfully.
+func (sw *StickyErrorWriter) Written() int64 {
+	return sw.n
+}
+
+// StickyErrorReader wraps an io.Reader, remembering the first error it returns
+// and failing every later Read with that error without calling it again. It is
+// the read-side counterpart of StickyErrorWriter, and likewise is not safe for
+// concurrent use.
+type StickyErrorReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+var _ io.Reader = (*StickyErrorReader)(nil)
+
+// NewStickyErrorReader returns a StickyErrorReader reading from r.
+func NewStickyErrorReader(r io.Reader) *StickyErrorReader {
+	return &StickyErrorReader{r: r}
+}
+
+// Read implements io.Reader for StickyErrorReader.
+func (sr *StickyErrorReader) Read(p []byte) (int, error) {
+	if sr.err != nil {
+		return 0, sr.err
+	}
+
+	n, err := sr.r.Read(p)
+	sr.n += int64(n)
+	sr.err = err
+
+	return n, err
+}
+
+// Err returns the first error encountered, if any. Like bufio.Scanner's Err, it
+// returns nil if that error was io.EOF, since reaching the end of the stream is
+// not a failure; Read still returns io.EOF.
+func (sr *StickyErrorReader) Err() error {
+	if sr.err == io.EOF {
+		return nil
+	}
+
+	return sr.err
+}
+
+// BytesRead returns the number of bytes read successfully.
+func (sr *StickyErrorReader) BytesRead() int64 {
+	return sr.n
+}
@@ -0,0 +1,60 @@
+package miscio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestStickyErrorWriter(t *testing.T) {
+	errBoom := errors.New("boom")
+	fw := failingWriter{err: errBoom}
+
+	sw := NewStickyErrorWriter(NewLimitedWriter(ioutil.Discard, 4))
+	fmt.Fprint(sw, "abc")
+	fmt.Fprint(sw, "def")
+	fmt.Fprint(sw, "ghi")
+
+	var limitErr *ErrWriteLimitExceeded
+	if !errors.As(sw.Err(), &limitErr) {
+		t.Errorf("expected the first error to stick, got %v", sw.Err())
+	}
+
+	if sw.Written() != 4 {
+		t.Errorf("expected 4 bytes written, got %d", sw.Written())
+	}
+
+	sw = NewStickyErrorWriter(fw)
+	sw.Write([]byte("x"))
+
+	if _, err := sw.Write([]byte("y")); err != errBoom {
+		t.Errorf("expected errBoom, got %v", err)
+	}
+}
+
+func TestStickyErrorReader(t *testing.T) {
+	sr := NewStickyErrorReader(strings.NewReader("data"))
+
+	if got, _ := ioutil.ReadAll(sr); string(got) != "data" {
+		t.Errorf("expected %q, got %q", "data", got)
+	}
+
+	if _, err := sr.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected io.EOF to stick, got %v", err)
+	}
+
+	if sr.Err() != nil || sr.BytesRead() != 4 {
+		t.Errorf("expected no error and 4 bytes read, got %v and %d", sr.Err(), sr.BytesRead())
+	}
+
+	errBoom := errors.New("boom")
+	sr = NewStickyErrorReader(io.MultiReader(strings.NewReader("ab"), errReader{errBoom}, strings.NewReader("cd")))
+
+	got, err := ioutil.ReadAll(sr)
+	if err != errBoom || string(got) != "ab" || sr.Err() != errBoom {
+		t.Errorf("got (%q, %v), Err() = %v", got, err, sr.Err())
+	}
+}
@@ -0,0 +1,90 @@
+package miscio
+
+import (
+	"io"
+	"sync"
+)
+
+// SyncWriter serializes Writes to an underlying io.Writer that is not safe for
+// concurrent use, so several goroutines can share it. Each Write reaches the
+// underlying writer whole, so writes never interleave.
+type SyncWriter struct {
+	m sync.Mutex
+	w io.Writer
+}
+
+var (
+	_ io.Writer = (*SyncWriter)(nil)
+	_ Flusher   = (*SyncWriter)(nil)
+)
+
+// NewSyncWriter returns a SyncWriter writing to w.
+func NewSyncWriter(w io.Writer) *SyncWriter {
+	return &SyncWriter{w: w}
+}
+
+// Write implements io.Writer for SyncWriter.
+func (sw *SyncWriter) Write(p []byte) (int, error) {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+
+	return sw.w.Write(p)
+}
+
+// Flush implements Flusher for SyncWriter by flushing the underlying writer,
+// serialized with Writes.
+func (sw *SyncWriter) Flush() error {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+
+	return flushNext(sw.w)
+}
+
+// SyncReader serializes Reads from an underlying io.Reader that is not safe
+// for concurrent use, so several goroutines can consume it. Each Read's data
+// is contiguous in the stream, but which goroutine gets which part is of
+// course arbitrary.
+type SyncReader struct {
+	m sync.Mutex
+	r io.Reader
+}
+
+var _ io.Reader = (*SyncReader)(nil)
+
+// NewSyncReader returns a SyncReader reading from r.
+func NewSyncReader(r io.Reader) *SyncReader {
+	return &SyncReader{r: r}
+}
+
+// Read implements io.Reader for SyncReader.
+func (sr *SyncReader) Read(p []byte) (int, error) {
+	sr.m.Lock()
+	defer sr.m.Unlock()
+
+	return sr.r.Read(p)
+}
+
+// SyncWriterAt serializes WriteAts to an underlying io.WriterAt that is not
+// safe for concurrent use, e.g. one implemented with Seek and Write, so several
+// workers can share one sink. It is also useful for an *os.File that other
+// code reads or writes sequentially, since on some platforms (notably Windows)
+// WriteAt moves the file offset as a side effect.
+type SyncWriterAt struct {
+	m sync.Mutex
+	w io.WriterAt
+}
+
+var _ io.WriterAt = (*SyncWriterAt)(nil)
+
+// NewSyncWriterAt returns a SyncWriterAt writing to w.
+func NewSyncWriterAt(w io.WriterAt) *SyncWriterAt {
+	return &SyncWriterAt{w: w}
+}
+
+// WriteAt implements io.WriterAt for SyncWriterAt.
+func (sw *SyncWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+
+	return sw.w.WriteAt(p, off)
+}
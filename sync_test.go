@@ -0,0 +1,67 @@
+package miscio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// seekWriterAt implements io.WriterAt racily, with Seek and Write.
+type seekWriterAt struct {
+	buf []byte
+	pos int64
+}
+
+func (s *seekWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	s.pos = off
+	n := copy(s.buf[s.pos:], p)
+	s.pos += int64(n)
+
+	return n, nil
+}
+
+func TestSyncWrappers(t *testing.T) {
+	var wg sync.WaitGroup
+
+	var out bytes.Buffer
+
+	sw := NewSyncWriter(&out)
+	swa := NewSyncWriterAt(&seekWriterAt{buf: make([]byte, 800)})
+	sr := NewSyncReader(strings.NewReader(strings.Repeat("x", 800)))
+
+	var read int64
+
+	var readMu sync.Mutex
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				sw.Write([]byte("line\n"))
+				swa.WriteAt([]byte{byte(i)}, int64(i*100+j))
+			}
+
+			n, _ := io.Copy(ioutil.Discard, sr)
+
+			readMu.Lock()
+			read += n
+			readMu.Unlock()
+		}(i)
+	}
+
+	wg.Wait()
+
+	if out.String() != strings.Repeat("line\n", 800) {
+		t.Error("expected whole, uninterleaved writes")
+	}
+
+	if read != 800 {
+		t.Errorf("expected 800 bytes read in total, got %d", read)
+	}
+}
@@ -0,0 +1,95 @@
+package miscio
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// SizedReader is a finite stream of data that can be read sequentially or at
+// any offset, as returned by ZeroReader, PatternReader and RandomReader.
+// *io.SectionReader implements it.
+type SizedReader interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	// Size returns the length of the stream in bytes.
+	Size() int64
+}
+
+var _ SizedReader = (*io.SectionReader)(nil)
+
+// ZeroReader returns a SizedReader of n zero bytes. It allocates nothing
+// however large n is.
+func ZeroReader(n int64) SizedReader {
+	return PatternReader([]byte{0}, n)
+}
+
+// PatternReader returns a SizedReader of n bytes made of pattern repeated, the
+// byte at offset off being pattern[off%len(pattern)]. An empty pattern is
+// treated as a single zero byte. pattern is copied, so the caller may reuse it.
+func PatternReader(pattern []byte, n int64) SizedReader {
+	if len(pattern) == 0 {
+		pattern = []byte{0}
+	}
+
+	// Repeat short patterns so that each ReadAt copies in large pieces.
+	repeated := append([]byte(nil), pattern...)
+	for len(repeated) < minPatternSize {
+		repeated = append(repeated, pattern...)
+	}
+
+	return io.NewSectionReader(patternReaderAt(repeated), 0, n)
+}
+
+// minPatternSize is the size PatternReader repeats its pattern up to.
+const minPatternSize = 4096
+
+type patternReaderAt []byte
+
+func (pattern patternReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+
+	for n < len(p) {
+		pos := off + int64(n)
+		n += copy(p[n:], pattern[pos%int64(len(pattern)):])
+	}
+
+	return n, nil
+}
+
+// RandomReader returns a SizedReader of n pseudo-random bytes determined by
+// seed: the same seed always produces the same stream, and every offset can be
+// read directly without generating the bytes before it. The data is not
+// cryptographically random.
+func RandomReader(seed int64, n int64) SizedReader {
+	return io.NewSectionReader(randomReaderAt(seed), 0, n)
+}
+
+// randomReaderAt generates each 8-byte word from its index and the seed. The
+// seed is mixed before being combined with the index, so that streams with
+// different seeds are not shifted copies of each other.
+type randomReaderAt int64
+
+func (seed randomReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	var word [8]byte
+
+	n := 0
+
+	for n < len(p) {
+		pos := off + int64(n)
+		binary.LittleEndian.PutUint64(word[:], splitmix64(splitmix64(uint64(seed))^uint64(pos/8)))
+		n += copy(p[n:], word[pos%8:])
+	}
+
+	return n, nil
+}
+
+// splitmix64 returns the output of the SplitMix64 generator for state x: a
+// well-mixed function of x, so consecutive states yield unrelated values.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+
+	return x ^ (x >> 31)
+}
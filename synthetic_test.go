@@ -0,0 +1,90 @@
+package miscio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+)
+
+func TestZeroReader(t *testing.T) {
+	data, err := ioutil.ReadAll(ZeroReader(10000))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+
+	if !bytes.Equal(data, make([]byte, 10000)) {
+		t.Error("expected 10000 zero bytes")
+	}
+}
+
+func TestPatternReader(t *testing.T) {
+	pattern := []byte("abc")
+	r := PatternReader(pattern, 10001)
+	pattern[0] = 'x' // The pattern is copied.
+
+	expected := bytes.Repeat([]byte("abc"), 3334)[:10001]
+
+	data, err := ioutil.ReadAll(iotest.HalfReader(r))
+	if err != nil || !bytes.Equal(data, expected) {
+		t.Fatalf("ReadAll returned %d bytes of the wrong data, or failed: %v", len(data), err)
+	}
+
+	buf := make([]byte, 5)
+	if n, err := r.ReadAt(buf, 4097); n != 5 || err != nil || string(buf) != "cabca" {
+		t.Errorf("expected (cabca, nil) mid-stream, got (%q, %v)", buf[:n], err)
+	}
+
+	if n, err := r.ReadAt(buf, 9998); n != 3 || err != io.EOF || string(buf[:n]) != "cab" {
+		t.Errorf("expected (cab, EOF) at the end, got (%q, %v)", buf[:n], err)
+	}
+}
+
+func TestRandomReader(t *testing.T) {
+	const size = 1 << 16
+
+	first, err := ioutil.ReadAll(RandomReader(42, size))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+
+	again := make([]byte, size-1001)
+	if _, err := RandomReader(42, size).ReadAt(again, 1001); err != nil || !bytes.Equal(again, first[1001:]) {
+		t.Errorf("expected the same seed to produce the same data at any offset: %v", err)
+	}
+
+	other, _ := ioutil.ReadAll(RandomReader(43, size))
+	if bytes.Equal(first, other) {
+		t.Error("expected a different seed to produce different data")
+	}
+
+	if bytes.Count(first, []byte{0}) > size/128 {
+		t.Error("expected data that doesn't look like zeros")
+	}
+}
+
+func TestRandomReaderSeedsDontOverlap(t *testing.T) {
+	zero := make([]byte, 1<<12)
+	RandomReader(0, int64(len(zero))).ReadAt(zero, 0) // nolint:errcheck
+
+	for seed := int64(1); seed < 64; seed++ {
+		head := make([]byte, 8)
+		RandomReader(seed, 8).ReadAt(head, 0) // nolint:errcheck
+
+		if i := bytes.Index(zero, head); i >= 0 {
+			t.Errorf("seed %d starts like seed 0 does at offset %d", seed, i)
+		}
+	}
+}
+
+func BenchmarkZeroReader(b *testing.B) {
+	buf := make([]byte, 1<<20)
+	r := ZeroReader(1 << 40)
+
+	b.SetBytes(int64(len(buf)))
+
+	for i := 0; i < b.N; i++ {
+		r.ReadAt(buf, int64(i)*int64(len(buf))+1) // nolint:errcheck
+	}
+}
@@ -0,0 +1,105 @@
+package miscio
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// TailPosition is where TailFile starts reading the file it follows.
+type TailPosition int
+
+const (
+	// TailFromEnd starts at the end of the file, so only lines written after
+	// TailFile is called are delivered, as with `tail -n 0 -F`. This is the
+	// default.
+	TailFromEnd TailPosition = iota
+	// TailFromStart delivers every line already in the file first.
+	TailFromStart
+)
+
+type tailConfig struct {
+	pollInterval time.Duration
+	start        TailPosition
+}
+
+// TailOption configures TailFile.
+type TailOption func(cfg *tailConfig)
+
+// WithPollInterval sets how often TailFile checks the file for new data and
+// rotation. The default is DefaultFollowPollInterval.
+func WithPollInterval(d time.Duration) TailOption {
+	return func(cfg *tailConfig) {
+		cfg.pollInterval = d
+	}
+}
+
+// WithStartPosition sets where in the file TailFile starts. Files it switches
+// to after a rotation are always read from the start.
+func WithStartPosition(pos TailPosition) TailOption {
+	return func(cfg *tailConfig) {
+		cfg.start = pos
+	}
+}
+
+// TailFile follows the file at path like `tail -F`, writing each complete line
+// appended to it into rb, until ctx is done. It follows the file through
+// truncation and rotation as a FollowReader does. Lines longer than
+// bufio.MaxScanTokenSize are truncated; a partial line still being written when
+// ctx is done is not delivered.
+//
+// TailFile blocks until ctx is done, returning ctx.Err(), or until opening or
+// reading the file or writing to rb fails (e.g. because rb was closed),
+// returning that error. The file must exist when TailFile is called.
+func TailFile(ctx context.Context, path string, rb *RollingLineBuffer, opts ...TailOption) error {
+	cfg := tailConfig{start: TailFromEnd}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	fr, err := NewFollowReader(path)
+	if err != nil {
+		return err
+	}
+	defer fr.Close()
+
+	fr.PollInterval = cfg.pollInterval
+
+	if cfg.start == TailFromEnd {
+		if fr.offset, err = fr.f.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			fr.Close()
+		case <-stop:
+		}
+	}()
+
+	lr := NewLineReader(fr, 0)
+
+	for {
+		line, err := lr.ReadLine()
+
+		switch err {
+		case nil, ErrLineTooLong:
+		case io.EOF, ErrUnterminatedLine:
+			// The FollowReader only ends once closed, which only happens
+			// when ctx is done.
+			return ctx.Err()
+		default:
+			return err
+		}
+
+		if _, err := rb.Write(line); err != nil {
+			return err
+		}
+	}
+}
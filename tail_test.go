@@ -0,0 +1,130 @@
+package miscio
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// waitForLines waits for rb to hold want, failing the test if it doesn't within
+// a few seconds.
+func waitForLines(t *testing.T, rb *RollingLineBuffer, want ...string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !reflect.DeepEqual(rb.Snapshot(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected lines %q, have %q", want, rb.Snapshot())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Close()
+}
+
+func TestTailFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := ioutil.WriteFile(path, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rb := NewRollingLineBuffer(10)
+	done := make(chan error, 1)
+
+	go func() { done <- TailFile(ctx, path, rb, WithPollInterval(time.Millisecond)) }()
+
+	// Lines already in the file are skipped, and partial lines held back
+	// until complete.
+	time.Sleep(20 * time.Millisecond)
+	appendFile(t, path, "one\ntw")
+	waitForLines(t, rb, "one")
+	appendFile(t, path, "o\n")
+	waitForLines(t, rb, "one", "two")
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("three\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForLines(t, rb, "one", "two", "three")
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the truncation to be noticed before writing, since a longer
+	// file would look like an append.
+	time.Sleep(20 * time.Millisecond)
+	appendFile(t, path, "four\n")
+	waitForLines(t, rb, "one", "two", "three", "four")
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("TailFile did not return after cancel")
+	}
+}
+
+func TestTailFileFromStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := ioutil.WriteFile(path, []byte("a\nb\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rb := NewRollingLineBuffer(10)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- TailFile(context.Background(), path, rb, WithStartPosition(TailFromStart), WithPollInterval(time.Millisecond))
+	}()
+
+	waitForLines(t, rb, "a", "b")
+
+	// Closing the buffer stops TailFile at the next line.
+	rb.Close()
+	appendFile(t, path, "c\n")
+
+	select {
+	case err := <-done:
+		if err != os.ErrClosed {
+			t.Errorf("expected os.ErrClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("TailFile did not return after the buffer was closed")
+	}
+}
+
+func TestTailFileMissing(t *testing.T) {
+	err := TailFile(context.Background(), filepath.Join(t.TempDir(), "nope"), NewRollingLineBuffer(1))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package miscio
+
+import "io"
+
+type teeReaderAt struct {
+	r io.ReaderAt
+	w io.WriterAt
+}
+
+// TeeReaderAt returns an io.ReaderAt that writes to w, at the same offset,
+// whatever it reads from r, like io.TeeReader does for sequential reads. Bytes
+// read are written even if ReadAt also returns an error such as io.EOF. An
+// error writing to w is returned as the read error. Combined with a WriterAt
+// that remembers what was written, this makes a read-through cache.
+func TeeReaderAt(r io.ReaderAt, w io.WriterAt) io.ReaderAt {
+	return &teeReaderAt{r: r, w: w}
+}
+
+// ReadAt implements io.ReaderAt for the value returned by TeeReaderAt.
+func (t *teeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := t.r.ReadAt(p, off)
+	if n > 0 {
+		if _, werr := t.w.WriteAt(p[:n], off); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
+
+type teeWriterAt struct {
+	primary, secondary io.WriterAt
+	onSecondaryErr     func(off int64, n int, err error)
+}
+
+// TeeWriterAt returns an io.WriterAt that writes each chunk to primary, then
+// what primary accepted to secondary at the same offset, e.g. to persist a
+// parallel download to disk while also feeding it to a WriterAtReadCloser for
+// processing on the fly.
+//
+// An error from primary is returned without writing to secondary. What
+// happens on an error from secondary depends on onSecondaryErr: if it is nil,
+// the error is returned (fail fast); otherwise onSecondaryErr is called with
+// the offset and length of the failed write and the error, for logging, and
+// the write succeeds. onSecondaryErr may be called concurrently if WriteAt is.
+func TeeWriterAt(primary, secondary io.WriterAt, onSecondaryErr func(off int64, n int, err error)) io.WriterAt {
+	return &teeWriterAt{primary: primary, secondary: secondary, onSecondaryErr: onSecondaryErr}
+}
+
+// WriteAt implements io.WriterAt for the value returned by TeeWriterAt.
+func (t *teeWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := t.primary.WriteAt(p, off)
+	if err != nil {
+		return n, err
+	}
+
+	if _, werr := t.secondary.WriteAt(p[:n], off); werr != nil {
+		if t.onSecondaryErr == nil {
+			return n, werr
+		}
+
+		t.onSecondaryErr(off, n, werr)
+	}
+
+	return n, nil
+}
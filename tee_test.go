@@ -0,0 +1,84 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTeeReaderAt(t *testing.T) {
+	cache := NewWriterAtReadSeeker(0)
+	r := TeeReaderAt(strings.NewReader("hello world"), cache)
+
+	buf := make([]byte, 5)
+	if n, err := r.ReadAt(buf, 8); n != 3 || err != io.EOF {
+		t.Errorf("ReadAt at the end got (%d, %v), want (3, io.EOF)", n, err)
+	}
+
+	r.ReadAt(buf, 0)
+
+	if n, err := cache.ReadAt(buf, 0); err != nil || string(buf[:n]) != "hello" {
+		t.Errorf("cache holds (%q, %v) at 0", buf[:n], err)
+	}
+
+	if n, err := cache.ReadAt(buf[:3], 8); err != nil || string(buf[:n]) != "rld" {
+		t.Errorf("cache holds (%q, %v) at 8", buf[:n], err)
+	}
+
+	if _, err := cache.ReadAt(buf[:1], 5); err != ErrNotWritten {
+		t.Errorf("expected unread bytes not to be cached, got %v", err)
+	}
+}
+
+func TestTeeWriterAt(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	primary := NewRecordingWriterAt(nil)
+	secondary := NewWriterAtReadSeeker(0)
+
+	w := TeeWriterAt(primary, secondary, nil)
+	w.WriteAt([]byte("world"), 6)
+	w.WriteAt([]byte("hello "), 0)
+
+	buf := make([]byte, 11)
+	if _, err := secondary.ReadAt(buf, 0); err != nil || string(buf) != "hello world" {
+		t.Errorf("secondary holds (%q, %v)", buf, err)
+	}
+
+	if len(primary.Calls()) != 2 {
+		t.Errorf("expected 2 writes to the primary, got %d", len(primary.Calls()))
+	}
+
+	failing := NewFaultWriterAt(DiscardWriterAt, FailOnCall(1, errBoom))
+
+	if _, err := TeeWriterAt(DiscardWriterAt, failing, nil).WriteAt([]byte("x"), 0); err != errBoom {
+		t.Errorf("expected a failing secondary to fail fast, got %v", err)
+	}
+
+	var logged []int64
+
+	failing = NewFaultWriterAt(DiscardWriterAt, FailOnCall(1, errBoom))
+	w = TeeWriterAt(DiscardWriterAt, failing, func(off int64, n int, err error) {
+		logged = append(logged, off)
+	})
+
+	if n, err := w.WriteAt([]byte("abc"), 7); n != 3 || err != nil {
+		t.Errorf("expected the write to succeed despite the secondary, got (%d, %v)", n, err)
+	}
+
+	if len(logged) != 1 || logged[0] != 7 {
+		t.Errorf("expected the failure at 7 to be reported, got %v", logged)
+	}
+
+	untouched := NewRecordingWriterAt(nil)
+	failing = NewFaultWriterAt(DiscardWriterAt, FailOnCall(1, errBoom))
+
+	if _, err := TeeWriterAt(failing, untouched, nil).WriteAt([]byte("x"), 0); err != errBoom {
+		t.Errorf("expected errBoom from the primary, got %v", err)
+	}
+
+	if len(untouched.Calls()) != 0 {
+		t.Error("expected nothing written to the secondary when the primary fails")
+	}
+}
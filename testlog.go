@@ -0,0 +1,79 @@
+package miscio
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// TestLogger is the subset of testing.TB used by NewTestLogWriter, so that
+// this package does not have to import testing. *testing.T, *testing.B and
+// any other testing.TB implement it.
+type TestLogger interface {
+	Helper()
+	Logf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// testLogWriter is the io.Writer returned by NewTestLogWriter.
+type testLogWriter struct {
+	m       sync.Mutex
+	tb      TestLogger
+	partial []byte
+	done    bool
+}
+
+// NewTestLogWriter returns an io.Writer that logs each line written to it with
+// tb.Logf, so that output from a subprocess or library under test is shown
+// alongside the test's own logs, and only if it fails (or with -v). Lines may
+// be split across any number of Writes; a partial line still held back when
+// the test finishes is logged by a tb.Cleanup function. Writes after that, e.g.
+// from a goroutine the test leaked, are discarded rather than logged, since
+// logging after a test has completed panics.
+//
+// The returned writer is safe for concurrent use.
+func NewTestLogWriter(tb TestLogger) io.Writer {
+	tw := &testLogWriter{tb: tb}
+	tb.Cleanup(tw.flush)
+
+	return tw
+}
+
+func (tw *testLogWriter) Write(p []byte) (int, error) {
+	tw.tb.Helper()
+
+	tw.m.Lock()
+	defer tw.m.Unlock()
+
+	if tw.done {
+		return len(p), nil
+	}
+
+	for rest := p; ; {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			tw.partial = append(tw.partial, rest...)
+
+			break
+		}
+
+		tw.tb.Logf("%s%s", tw.partial, rest[:i])
+		tw.partial = tw.partial[:0]
+		rest = rest[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// flush logs the partial line held back, if any, and stops further logging.
+func (tw *testLogWriter) flush() {
+	tw.m.Lock()
+	defer tw.m.Unlock()
+
+	if len(tw.partial) > 0 {
+		tw.tb.Logf("%s", tw.partial)
+		tw.partial = nil
+	}
+
+	tw.done = true
+}
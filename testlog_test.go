@@ -0,0 +1,52 @@
+package miscio
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Any testing.TB can be passed to NewTestLogWriter.
+var _ TestLogger = testing.TB(nil)
+
+// fakeTB records what is logged through it, and the cleanup functions
+// registered with it.
+type fakeTB struct {
+	logs     []string
+	cleanups []func()
+}
+
+func (tb *fakeTB) Helper()          {}
+func (tb *fakeTB) Cleanup(f func()) { tb.cleanups = append(tb.cleanups, f) }
+func (tb *fakeTB) Logf(format string, args ...interface{}) {
+	tb.logs = append(tb.logs, fmt.Sprintf(format, args...))
+}
+
+func TestTestLogWriter(t *testing.T) {
+	tb := &fakeTB{}
+	w := NewTestLogWriter(tb)
+
+	fmt.Fprint(w, "first line\nsecond ")
+	fmt.Fprint(w, "line\n\nthird")
+
+	expected := []string{"first line", "second line", ""}
+	if !reflect.DeepEqual(tb.logs, expected) {
+		t.Errorf("expected %q before cleanup, got %q", expected, tb.logs)
+	}
+
+	if len(tb.cleanups) != 1 {
+		t.Fatalf("expected one cleanup function, got %d", len(tb.cleanups))
+	}
+
+	tb.cleanups[0]()
+	fmt.Fprint(w, "too late\n")
+
+	expected = append(expected, "third")
+	if !reflect.DeepEqual(tb.logs, expected) {
+		t.Errorf("expected %q after cleanup, got %q", expected, tb.logs)
+	}
+}
+
+func TestTestLogWriterReal(t *testing.T) {
+	fmt.Fprintln(NewTestLogWriter(t), "logged via NewTestLogWriter")
+}
@@ -0,0 +1,135 @@
+package miscio
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// timeoutError is an error satisfying os.IsTimeout.
+type timeoutError struct {
+	msg string
+}
+
+func (err *timeoutError) Error() string   { return err.msg }
+func (err *timeoutError) Timeout() bool   { return true }
+func (err *timeoutError) Temporary() bool { return true }
+
+// ErrIdleTimeout is returned by a TimeoutReader or TimeoutWriter once its
+// IdleTimeout passes without progress. It satisfies os.IsTimeout.
+var ErrIdleTimeout error = &timeoutError{"miscio: idle timeout"}
+
+// TimeoutReader wraps an io.Reader, failing any Read that takes longer than a
+// timeout with os.ErrDeadlineExceeded (which satisfies os.IsTimeout), so a
+// pipeline does not hang on an upstream that stops responding without
+// erroring.
+//
+// Since an arbitrary io.Reader cannot be interrupted, each Read runs in a
+// goroutine, into a buffer owned by the TimeoutReader. A Read that times out
+// is not abandoned: the next Read waits for it (again with a timeout) and
+// returns its data. TimeoutReader is not safe for concurrent use.
+type TimeoutReader struct {
+	ar       asyncReader
+	timeout  time.Duration
+	progress time.Time
+
+	// IdleTimeout, if positive, makes the TimeoutReader fail for good with
+	// ErrIdleTimeout once a Read times out and no bytes have been read for at
+	// least this long, for callers that retry timed-out Reads.
+	IdleTimeout time.Duration
+}
+
+var _ io.Reader = (*TimeoutReader)(nil)
+
+// NewTimeoutReader returns a TimeoutReader reading from r, whose Reads time out
+// after timeout.
+func NewTimeoutReader(r io.Reader, timeout time.Duration) *TimeoutReader {
+	return &TimeoutReader{ar: asyncReader{r: r}, timeout: timeout, progress: time.Now()}
+}
+
+// Read implements io.Reader for TimeoutReader.
+func (tr *TimeoutReader) Read(p []byte) (int, error) {
+	if tr.IdleTimeout > 0 && time.Since(tr.progress) >= tr.IdleTimeout {
+		return 0, ErrIdleTimeout
+	}
+
+	timer := time.NewTimer(tr.timeout)
+	defer timer.Stop()
+
+	res, ok := tr.ar.read(p, nil, timer.C)
+	if !ok {
+		return 0, os.ErrDeadlineExceeded
+	}
+
+	if res.n > 0 {
+		tr.progress = time.Now()
+	}
+
+	return res.n, res.err
+}
+
+// TimeoutWriter wraps an io.Writer, failing any Write that takes longer than a
+// timeout with os.ErrDeadlineExceeded (which satisfies os.IsTimeout).
+//
+// Since an arbitrary io.Writer cannot be interrupted, each Write runs in a
+// goroutine, from a copy of p. A Write that times out is not abandoned and may
+// still complete: the next Write first waits for it (again with a timeout),
+// and returns its error, if any, without writing anything further.
+// TimeoutWriter is not safe for concurrent use.
+type TimeoutWriter struct {
+	aw       asyncWriter
+	timeout  time.Duration
+	progress time.Time
+
+	// IdleTimeout, if positive, makes the TimeoutWriter fail for good with
+	// ErrIdleTimeout once a Write times out and no bytes have been written
+	// for at least this long.
+	IdleTimeout time.Duration
+}
+
+var (
+	_ io.Writer = (*TimeoutWriter)(nil)
+	_ Flusher   = (*TimeoutWriter)(nil)
+)
+
+// NewTimeoutWriter returns a TimeoutWriter writing to w, whose Writes time out
+// after timeout.
+func NewTimeoutWriter(w io.Writer, timeout time.Duration) *TimeoutWriter {
+	return &TimeoutWriter{aw: asyncWriter{w: w}, timeout: timeout, progress: time.Now()}
+}
+
+// Write implements io.Writer for TimeoutWriter.
+func (tw *TimeoutWriter) Write(p []byte) (int, error) {
+	if tw.IdleTimeout > 0 && time.Since(tw.progress) >= tw.IdleTimeout {
+		return 0, ErrIdleTimeout
+	}
+
+	timer := time.NewTimer(tw.timeout)
+	defer timer.Stop()
+
+	res, written, ok := tw.aw.write(p, nil, timer.C)
+	if written {
+		tw.progress = time.Now()
+	}
+
+	if !ok {
+		return 0, os.ErrDeadlineExceeded
+	}
+
+	return res.n, res.err
+}
+
+// Flush implements Flusher for TimeoutWriter by flushing the underlying writer,
+// after waiting for any Write that timed out earlier. Like Write, it fails with
+// os.ErrDeadlineExceeded if that takes longer than the timeout.
+func (tw *TimeoutWriter) Flush() error {
+	timer := time.NewTimer(tw.timeout)
+	defer timer.Stop()
+
+	res, ok := tw.aw.flush(nil, timer.C)
+	if !ok {
+		return os.ErrDeadlineExceeded
+	}
+
+	return res.err
+}
@@ -0,0 +1,71 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTimeoutReader(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	tr := NewTimeoutReader(pr, 10*time.Millisecond)
+	buf := make([]byte, 8)
+
+	if _, err := tr.Read(buf); !os.IsTimeout(err) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	go pw.Write([]byte("late data"))
+
+	// The timed-out Read is still pending, and its data is returned now.
+	n, err := tr.Read(buf[:4])
+	if err != nil || string(buf[:n]) != "late" {
+		t.Errorf("Read after timeout got (%q, %v)", buf[:n], err)
+	}
+
+	if n, err := tr.Read(buf); err != nil || string(buf[:n]) != " dat" {
+		t.Errorf("Read of the remainder got (%q, %v)", buf[:n], err)
+	}
+}
+
+func TestTimeoutReaderIdle(t *testing.T) {
+	pr, _ := io.Pipe()
+	defer pr.Close()
+
+	tr := NewTimeoutReader(pr, 5*time.Millisecond)
+	tr.IdleTimeout = 20 * time.Millisecond
+
+	var err error
+
+	for i := 0; i < 100 && err != ErrIdleTimeout; i++ {
+		_, err = tr.Read(make([]byte, 1))
+		if !os.IsTimeout(err) {
+			t.Fatalf("expected a timeout, got %v", err)
+		}
+	}
+
+	if err != ErrIdleTimeout {
+		t.Errorf("expected ErrIdleTimeout eventually, got %v", err)
+	}
+}
+
+func TestTimeoutWriter(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	tw := NewTimeoutWriter(pw, 10*time.Millisecond)
+
+	if _, err := tw.Write([]byte("stuck")); !os.IsTimeout(err) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	go io.Copy(ioutil.Discard, pr)
+
+	if n, err := tw.Write([]byte("next")); n != 4 || err != nil {
+		t.Errorf("Write once the reader resumed got (%d, %v)", n, err)
+	}
+}
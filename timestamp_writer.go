@@ -0,0 +1,29 @@
+package miscio
+
+import (
+	"io"
+	"time"
+)
+
+// DefaultTimestampLayout is the layout used by NewTimestampWriter if none is
+// given: RFC 3339 with millisecond precision, followed by a space.
+const DefaultTimestampLayout = "2006-01-02T15:04:05.000Z07:00 "
+
+// NewTimestampWriter returns a PrefixWriter that starts every line with the
+// time at which its first byte was written, formatted with layout
+// (DefaultTimestampLayout if empty). The layout should include any separator
+// wanted between the timestamp and the line. now supplies the time, and may be
+// set to a fake clock in tests; if nil, time.Now is used.
+func NewTimestampWriter(w io.Writer, layout string, now func() time.Time) *PrefixWriter {
+	if layout == "" {
+		layout = DefaultTimestampLayout
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return NewPrefixFuncWriter(w, func() string {
+		return now().Format(layout)
+	})
+}
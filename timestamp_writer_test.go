@@ -0,0 +1,28 @@
+package miscio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTimestampWriter(t *testing.T) {
+	var dst bytes.Buffer
+
+	clock := &fakeClock{t: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), step: time.Second}
+	tw := NewTimestampWriter(&dst, "15:04:05 ", clock.Now)
+
+	tw.Write([]byte("first li"))
+	tw.Write([]byte("ne\nsecond line\n"))
+
+	if expected := "05:06:07 first line\n05:06:08 second line\n"; dst.String() != expected {
+		t.Errorf("got %q, want %q", dst.String(), expected)
+	}
+
+	dst.Reset()
+	NewTimestampWriter(&dst, "", clock.Now).Write([]byte("x"))
+
+	if expected := "2021-03-04T05:06:09.000Z x"; dst.String() != expected {
+		t.Errorf("default layout got %q, want %q", dst.String(), expected)
+	}
+}
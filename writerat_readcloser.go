@@ -1,39 +1,66 @@
 package miscio
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"math"
 	"os"
 	"sync"
+	"time"
 )
 
+// rangeSet is an IntervalSet whose values are relative to a base that Consume
+// advances, so a WriterAtReadCloser can track its bytes relative to the read
+// cursor.
 type rangeSet struct {
-	m map[int64]bool
+	base int64
+	set  IntervalSet
 }
 
 func newRangeSet() *rangeSet {
-	return &rangeSet{map[int64]bool{}}
+	return &rangeSet{}
 }
 
 // Add marks all values [a, b) as included in the range set.
 func (rs *rangeSet) Add(a, b int64) {
-	for i := a; i < b; i++ {
-		rs.m[i] = true
+	if a < 0 {
+		a = 0
 	}
+
+	rs.set.Add(a+rs.base, b+rs.base)
 }
 
 // NextCap returns the highest value N for which [0, N) is covered by the range set.
 func (rs *rangeSet) NextCap() int64 {
-	i := int64(0)
+	return rs.set.NextCap(rs.base) - rs.base
+}
 
-	for {
-		if covered, ok := rs.m[i]; ok && covered {
-			i++
+// Len returns the number of values covered by the range set.
+func (rs *rangeSet) Len() int64 {
+	return rs.set.Len()
+}
 
-			continue
-		}
+// Gaps returns the maximal ranges within [0, limit) that are not covered by the
+// range set, in ascending order.
+func (rs *rangeSet) Gaps(limit int64) []Range {
+	return rs.relative(rs.set.Gaps(rs.base, rs.base+limit))
+}
+
+// Covered returns the maximal ranges within [a, b) that are covered by the
+// range set, in ascending order.
+func (rs *rangeSet) Covered(a, b int64) []Range {
+	return rs.relative(rs.set.Covered(a+rs.base, b+rs.base))
+}
 
-		return i
+// relative adjusts ranges from the underlying set down by the base, in place.
+func (rs *rangeSet) relative(ranges []Range) []Range {
+	for i := range ranges {
+		ranges[i].Start -= rs.base
+		ranges[i].End -= rs.base
 	}
+
+	return ranges
 }
 
 // Consume removes the first N values from the range set, adjusting all other values down by N.
@@ -47,17 +74,12 @@ func (rs *rangeSet) NextCap() int64 {
 //   - [0, 1)
 //   - [2, 4)
 func (rs *rangeSet) Consume(n int64) {
-	newMap := make(map[int64]bool, int64(len(rs.m))-n)
-
-	for k, v := range rs.m {
-		if k < n {
-			continue
-		}
-
-		newMap[k-n] = v
+	if n <= 0 {
+		return
 	}
 
-	rs.m = newMap
+	rs.set.Remove(rs.base, rs.base+n)
+	rs.base += n
 }
 
 // WriterAtReadCloser is a struct implementing io.WriterAt and io.ReadCloser
@@ -67,44 +89,442 @@ func (rs *rangeSet) Consume(n int64) {
 type WriterAtReadCloser struct {
 	buf []byte
 	m   sync.Mutex
+	// alloc is the whole backing array that buf is a suffix of, kept so Reset
+	// can reuse it.
+	alloc []byte
+	// chunks holds writes beyond the end of buf; see storeAt.
+	chunks []bufChunk
 
 	bytesAvail *rangeSet
 	bytesRead  int64
 
-	readClosed bool
+	readClosed  bool
+	writeClosed bool
+	closeErr    error
+	leak        *leakTracker
+
+	// blockingRead makes Read wait for data instead of returning (0, nil).
+	blockingRead bool
+	// readDeadline bounds how long a Read may wait for data. The zero value
+	// means no deadline.
+	readDeadline time.Time
+	// size is the expected total length of the stream, or -1 if unknown.
+	size int64
+	// written is one past the highest absolute offset written so far.
+	written int64
+	// spill, if set, holds writes far ahead of the read cursor on disk.
+	spill *spillFile
+	// backing, if set, holds every write on disk instead of in buf.
+	backing *backingFile
+	// verify, if set, is called on every write before it is accepted.
+	verify func(off int64, p []byte) error
+	// progress, if set, is called as the contiguous prefix grows; see
+	// reportProgress.
+	progress       func(contiguous int64)
+	progressMu     sync.Mutex
+	progressLatest int64
+	// maxEmptyReads is the number of consecutive empty Reads allowed before
+	// Read reports io.ErrNoProgress, or 0 for no limit. emptyReads counts them.
+	maxEmptyReads int
+	emptyReads    int
+	// autoCompact enables the shrink policy in take.
+	autoCompact bool
+	// conflictCheck makes WriteAt reject writes that change written bytes.
+	conflictCheck bool
+	// sink, if set, is flushed into by a goroutine started at construction.
+	sink *sink
+	// observer, if set, receives instrumentation events.
+	observer WriterAtReadCloserObserver
+	// maxBuffered caps how far past the read cursor a write may extend, or 0
+	// for no cap.
+	maxBuffered int64
+	// bytesWritten counts every byte accepted by WriteAt, including overwrites.
+	bytesWritten int64
+	// changed is closed, and replaced, whenever more bytes may have become
+	// readable or the WriterAtReadCloser is closed. It acts as a condition
+	// variable that can also be selected on alongside other channels.
+	changed chan struct{}
 
 	GrowthCoeff float64
 }
 
+// WriterAtReadCloserOption configures a WriterAtReadCloser at construction.
+type WriterAtReadCloserOption func(wr *WriterAtReadCloser)
+
+// WithBlockingRead makes Read block until at least one contiguous byte is
+// available at the front of the buffer, rather than returning (0, nil). A
+// blocked Read returns io.EOF once Close is called. This is the mode to use
+// when the WriterAtReadCloser is passed to io.Copy or similar, which would
+// otherwise spin.
+func WithBlockingRead() WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.blockingRead = true
+	}
+}
+
+// WithSize sets the expected total length of the stream at construction; see
+// SetSize.
+func WithSize(n int64) WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.size = n
+	}
+}
+
+// WithMaxBuffered bounds memory use by capping how far past the read cursor a
+// WriteAt may extend at n bytes, which is also the most the internal buffer
+// will hold. A WriteAt that would exceed the cap waits for the reader to catch
+// up if the WriterAtReadCloser was created WithBlockingRead; otherwise it fails
+// with an error wrapping ErrBufferFull, and the caller should retry later.
+// A single write larger than n always fails.
+func WithMaxBuffered(n int64) WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.maxBuffered = n
+	}
+}
+
+// WithChunkVerifier installs a hook that is called with the offset and data of
+// every WriteAt before the write is accepted, e.g. to check a per-range
+// checksum. If verify returns an error, WriteAt returns it unchanged and the
+// data is discarded; the caller may then retry the range, or abort the reader
+// with CloseWithError. verify is called without any locks held, and may be
+// called concurrently from multiple writing goroutines.
+func WithChunkVerifier(verify func(off int64, p []byte) error) WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.verify = verify
+	}
+}
+
+// WithProgressFunc installs a callback that is called whenever the contiguous
+// prefix of the stream grows, with the total number of contiguous bytes written
+// from offset 0 (whether or not they have been read yet). This is the number to
+// drive a download progress bar with. The callback is called after the write
+// that advanced the prefix has been accepted, without the WriterAtReadCloser's
+// lock held; calls are serialized and their values strictly increasing.
+func WithProgressFunc(progress func(contiguous int64)) WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.progress = progress
+	}
+}
+
+// WithMaxEmptyReads makes a non-blocking Read return io.ErrNoProgress, instead
+// of (0, nil), once n consecutive Reads have returned no bytes, so a consumer
+// polling the WriterAtReadCloser can detect a stalled producer. Read keeps
+// returning io.ErrNoProgress until more bytes become readable. Next and WriteTo
+// count as Reads. The option has no effect on a WriterAtReadCloser created
+// WithBlockingRead.
+func WithMaxEmptyReads(n int) WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.maxEmptyReads = n
+	}
+}
+
+// autoCompactMinCap is the smallest backing array WithAutoCompact will shrink,
+// so that small buffers are not reallocated on every Read.
+const autoCompactMinCap = 64 * 1024
+
+// WithAutoCompact enables a shrink policy: whenever a Read leaves the unread
+// part of the buffer using less than a quarter of its backing array (and the
+// array is at least 64KiB), the buffer is reallocated to fit, returning the
+// rest to the garbage collector. Long-lived instances that see occasional large
+// bursts should use this; instances reused via Reset usually should not, as it
+// discards the allocation Reset is meant to keep.
+func WithAutoCompact() WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.autoCompact = true
+	}
+}
+
 // NewWriterAtReadCloser returns a new WriterAtReadCloser object. Its underlying
 // buffer is preallocated to have n bytes.
-func NewWriterAtReadCloser(n int) *WriterAtReadCloser {
-	return &WriterAtReadCloser{
-		buf:        make([]byte, n),
+func NewWriterAtReadCloser(n int, opts ...WriterAtReadCloserOption) *WriterAtReadCloser {
+	buf := make([]byte, n)
+	wr := &WriterAtReadCloser{
+		buf:        buf,
+		alloc:      buf,
 		bytesAvail: newRangeSet(),
 		bytesRead:  0,
 		readClosed: false,
+		leak:       trackLeak("*miscio.WriterAtReadCloser"),
+		changed:    make(chan struct{}),
+		size:       -1,
+	}
+
+	for _, opt := range opts {
+		opt(wr)
+	}
+
+	if wr.sink != nil {
+		wr.startSink()
+	}
+
+	return wr
+}
+
+var (
+	_ io.ReadCloser = (*WriterAtReadCloser)(nil)
+	_ io.WriterAt   = (*WriterAtReadCloser)(nil)
+	_ io.WriterTo   = (*WriterAtReadCloser)(nil)
+)
+
+// broadcast wakes every goroutine waiting on wr.changed. It must be called
+// with wr.m held.
+func (wr *WriterAtReadCloser) broadcast() {
+	close(wr.changed)
+	wr.changed = make(chan struct{})
+}
+
+// wait releases wr.m until the next broadcast, then reacquires it. It returns
+// early with ctx's error if ctx is done, or with os.ErrDeadlineExceeded if the
+// deadline (if non-zero) passes. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) wait(ctx context.Context, deadline time.Time) error {
+	changed := wr.changed
+
+	wr.m.Unlock()
+	defer wr.m.Lock()
+
+	var timeout <-chan time.Time
+
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return os.ErrDeadlineExceeded
 	}
 }
 
 // Write copies the contents of p into the underlying buffer, beginning at the
 // specified offset. The underlying buffer will expand as necessary, according
 // to len(p) and wr.GrowthCoeff. It is not an error to write over the same section
-// of the underlying buffer. Write returns an os.ErrClosed if Closed() was previously
-// called.
+// of the underlying buffer. Write returns ErrWriteAfterClose if Closed() was previously
+// called, and an error wrapping ErrNegativeOffset or ErrOffsetConsumed if off is
+// negative or has already been read.
 func (wr *WriterAtReadCloser) WriteAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrNegativeOffset, off)
+	}
+
+	// The verifier may be expensive, so it runs before taking the lock.
+	if wr.verify != nil {
+		if err := wr.verify(off, p); err != nil {
+			return 0, err
+		}
+	}
+
+	var contiguous int64
+
+	// Registered before the unlock, so it runs after it.
+	defer func() {
+		if contiguous > 0 {
+			wr.reportProgress(contiguous)
+		}
+	}()
+
 	wr.m.Lock()
 	defer wr.m.Unlock()
 
-	if wr.readClosed {
-		return 0, os.ErrClosed
+	if err := wr.checkWrite(p, off); err != nil {
+		return 0, err
+	}
+
+	waited, err := wr.awaitRoom(p, off)
+	if err != nil {
+		return 0, err
+	}
+
+	if waited {
+		// The lock was released while waiting, so the reader may have moved
+		// past off, or the size may have changed.
+		if err := wr.checkWrite(p, off); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := wr.checkConflict(p, off); err != nil {
+		return 0, err
+	}
+
+	if err := wr.store(p, off); err != nil {
+		return 0, err
 	}
 
 	// the caller shouldn't have to know about or care that we're shrinking the buffer from the
 	// left-hand side as they're read.
 	adjustedOffset := off - wr.bytesRead
+	before := wr.bytesAvail.NextCap()
+	wr.bytesAvail.Add(adjustedOffset, adjustedOffset+int64(len(p)))
+
+	if wr.progress != nil && wr.bytesAvail.NextCap() > before {
+		contiguous = wr.bytesRead + wr.bytesAvail.NextCap()
+	}
+
+	wr.bytesWritten += int64(len(p))
+
+	if wr.observer != nil {
+		wr.observer.OnWrite(off, len(p))
+	}
+
+	if end := off + int64(len(p)); end > wr.written {
+		wr.written = end
+	}
+
+	wr.broadcast()
+
+	return len(p), nil
+}
+
+// store writes p at absolute offset off to wherever it belongs: the backing
+// file, the spill file, or memory. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) store(p []byte, off int64) error {
+	if wr.backing != nil {
+		return wr.backingWrite(p, off)
+	}
+
+	spilled, err := wr.spillWrite(p, off)
+	if err != nil || spilled {
+		return err
+	}
+
+	wr.storeAt(p, off)
+
+	return nil
+}
+
+// checkWrite returns the error, if any, that a write of p at off must fail
+// with before anything is stored. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) checkWrite(p []byte, off int64) error {
+	if wr.readClosed || wr.writeClosed {
+		return wr.writeErr()
+	}
+
+	if off < wr.bytesRead {
+		return fmt.Errorf("%w: write at offset %d, but %d bytes have been read", ErrOffsetConsumed, off, wr.bytesRead)
+	}
+
+	if wr.size >= 0 && off+int64(len(p)) > wr.size {
+		return fmt.Errorf("%w: write of %d bytes at offset %d exceeds size %d", ErrBeyondSize, len(p), off, wr.size)
+	}
+
+	return nil
+}
+
+// awaitRoom enforces WithMaxBuffered for a write of p at off. It must be called
+// with wr.m held, and may release it while waiting for the reader, in which
+// case it returns true.
+func (wr *WriterAtReadCloser) awaitRoom(p []byte, off int64) (bool, error) {
+	if wr.maxBuffered <= 0 {
+		return false, nil
+	}
+
+	if int64(len(p)) > wr.maxBuffered {
+		return false, fmt.Errorf("%w: write of %d bytes exceeds limit of %d", ErrBufferFull, len(p), wr.maxBuffered)
+	}
+
+	waited := false
+
+	for off+int64(len(p))-wr.bytesRead > wr.maxBuffered {
+		if !wr.blockingRead {
+			return waited, fmt.Errorf("%w: write at offset %d is more than %d bytes past the read cursor at %d",
+				ErrBufferFull, off, wr.maxBuffered, wr.bytesRead)
+		}
+
+		waited = true
+
+		if err := wr.wait(context.Background(), time.Time{}); err != nil {
+			return waited, err
+		}
+
+		if wr.readClosed || wr.writeClosed {
+			return waited, wr.writeErr()
+		}
+	}
+
+	return waited, nil
+}
+
+// readFromAtBufSize matches the buffer size io.Copy uses.
+const readFromAtBufSize = 32 * 1024
 
-	expLen := adjustedOffset + int64(len(p))
+var readFromAtPool = sync.Pool{ // nolint:gochecknoglobals
+	New: func() interface{} {
+		buf := make([]byte, readFromAtBufSize)
+
+		return &buf
+	},
+}
+
+// ReadFromAt reads from r until io.EOF or an error, writing the data into the
+// WriterAtReadCloser starting at offset off, as if by consecutive WriteAt calls.
+// It returns the number of bytes written; io.EOF from r is not reported as an
+// error. This is the body of a typical range-download goroutine:
+//
+//	resp, err := client.Do(rangeRequest)
+//	...
+//	_, err = wr.ReadFromAt(resp.Body, rangeStart)
+//
+// Data is staged through a pooled buffer, so callers need not allocate any, and
+// contiguous bytes become readable as each chunk arrives rather than when r is
+// exhausted. Each chunk is still copied once more by WriteAt: reading from r
+// straight into the internal buffer would mean either holding the lock while r
+// blocks, stalling every reader and writer, or releasing it while the buffer
+// may be grown, compacted or consumed underneath the read. Going through
+// WriteAt also keeps WithMaxBuffered, spilling and conflict checks in force.
+func (wr *WriterAtReadCloser) ReadFromAt(r io.Reader, off int64) (n int64, err error) {
+	bufp := readFromAtPool.Get().(*[]byte)
+	defer readFromAtPool.Put(bufp)
+
+	buf := *bufp
+
+	for {
+		read, rerr := r.Read(buf)
+		if read > 0 {
+			written, werr := wr.WriteAt(buf[:read], off+n)
+			n += int64(written)
+
+			if werr != nil {
+				return n, werr
+			}
+		}
+
+		if rerr == io.EOF {
+			return n, nil
+		}
+
+		if rerr != nil {
+			return n, rerr
+		}
+	}
+}
+
+// reportProgress calls the progress callback with contiguous, unless a larger
+// value was already reported by a concurrent writer. It must not be called with
+// wr.m held.
+func (wr *WriterAtReadCloser) reportProgress(contiguous int64) {
+	wr.progressMu.Lock()
+	defer wr.progressMu.Unlock()
+
+	if contiguous > wr.progressLatest {
+		wr.progressLatest = contiguous
+		wr.progress(contiguous)
+	}
+}
+
+// ensureLen extends the buffer to at least expLen bytes, growing it according to
+// GrowthCoeff if necessary.
+func (wr *WriterAtReadCloser) ensureLen(expLen int64) {
 	if int64(len(wr.buf)) < expLen {
 		if int64(cap(wr.buf)) < expLen {
 			wr.growBuffer(expLen)
@@ -112,11 +532,6 @@ func (wr *WriterAtReadCloser) WriteAt(p []byte, off int64) (n int, err error) {
 
 		wr.buf = wr.buf[:expLen]
 	}
-
-	copy(wr.buf[adjustedOffset:], p)
-	wr.bytesAvail.Add(adjustedOffset, adjustedOffset+int64(len(p)))
-
-	return len(p), nil
 }
 
 func (wr *WriterAtReadCloser) growBuffer(expLen int64) {
@@ -126,46 +541,485 @@ func (wr *WriterAtReadCloser) growBuffer(expLen int64) {
 
 	newBuf := make([]byte, expLen, int64(wr.GrowthCoeff*float64(expLen)))
 	copy(newBuf, wr.buf)
+
+	if wr.observer != nil {
+		wr.observer.OnGrow(cap(wr.buf), cap(newBuf))
+	}
+
 	wr.buf = newBuf
+	wr.alloc = newBuf
 }
 
 // Read consumes up to len(p) bytes from the underlying buffer and writes them into
-// p. io.EOF is Closed() was previously called.
+// p. io.EOF is Closed() was previously called. If no bytes are available at the
+// front of the buffer, Read returns (0, nil), or waits for them if the
+// WriterAtReadCloser was created WithBlockingRead.
 func (wr *WriterAtReadCloser) Read(p []byte) (n int, err error) {
+	return wr.read(context.Background(), p, wr.blockingRead)
+}
+
+// ReadContext is like Read, but always waits until at least one byte is
+// available, returning ctx.Err() if ctx is done first. It may be used
+// regardless of whether the WriterAtReadCloser was created WithBlockingRead.
+func (wr *WriterAtReadCloser) ReadContext(ctx context.Context, p []byte) (n int, err error) {
+	return wr.read(ctx, p, true)
+}
+
+// SetReadDeadline sets a deadline on waiting for data in Read and ReadContext.
+// Once it passes, a Read that would otherwise wait instead returns an error
+// satisfying os.IsTimeout (os.ErrDeadlineExceeded); a Read of data that is
+// already available still succeeds. Setting the deadline also affects Reads
+// that are already waiting. A zero value for t means Reads will not time out.
+func (wr *WriterAtReadCloser) SetReadDeadline(t time.Time) error {
 	wr.m.Lock()
 	defer wr.m.Unlock()
 
-	if wr.readClosed {
-		return 0, io.EOF
+	wr.readDeadline = t
+	wr.broadcast()
+
+	return nil
+}
+
+// Wait blocks until at least n contiguous unread bytes are available at the
+// front of the buffer, so that a following Read of n bytes will not come up
+// short, e.g. to parse a fixed-size header as soon as it is complete. It
+// returns the number of contiguous unread bytes available, which may exceed n.
+//
+// If the stream ends first (by CloseWrite, or by reaching the size set with
+// SetSize), Wait returns the bytes that remain along with io.ErrUnexpectedEOF.
+// It also returns early with ctx.Err(), with os.ErrDeadlineExceeded once the
+// read deadline passes, or with the close error if the WriterAtReadCloser is
+// closed. Asking for more than WithMaxBuffered allows fails immediately with an
+// error wrapping ErrBufferFull, since the bytes could never all be buffered.
+func (wr *WriterAtReadCloser) Wait(ctx context.Context, n int64) (int64, error) {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	if wr.maxBuffered > 0 && n > wr.maxBuffered {
+		return 0, fmt.Errorf("%w: waiting for %d bytes", ErrBufferFull, n)
+	}
+
+	for {
+		if wr.readClosed {
+			return 0, wr.readErr()
+		}
+
+		avail := wr.bytesAvail.NextCap()
+		if avail >= n {
+			return avail, nil
+		}
+
+		if wr.writeClosed || (wr.size >= 0 && wr.bytesRead+avail >= wr.size) {
+			return avail, io.ErrUnexpectedEOF
+		}
+
+		if err := wr.wait(ctx, wr.readDeadline); err != nil {
+			return avail, err
+		}
+	}
+}
+
+// Next consumes up to n contiguous bytes from the front of the buffer like Read,
+// but returns them as a slice of the internal buffer instead of copying them
+// into the caller's. The slice remains valid until the next call to Next, Read,
+// ReadContext, WriteTo or Reset; callers that need the bytes for longer must
+// copy them. Next waits for data, and reports the end of the stream, exactly as
+// Read does.
+func (wr *WriterAtReadCloser) Next(n int) ([]byte, error) {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	return wr.take(context.Background(), int64(n), wr.blockingRead)
+}
+
+func (wr *WriterAtReadCloser) read(ctx context.Context, p []byte, block bool) (n int, err error) {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	chunk, err := wr.take(ctx, int64(len(p)), block)
+
+	return copy(p, chunk), err
+}
+
+// readable returns how many bytes at the front of the buffer can be consumed,
+// first paging in any spilled data that the read cursor has approached. It must
+// be called with wr.m held.
+func (wr *WriterAtReadCloser) readable() (int64, error) {
+	if wr.backing != nil {
+		return wr.bytesAvail.NextCap(), nil
 	}
 
-	// nolint:godox
-	// TODO: If `readable` is zero, maybe block until some bytes were written?
-	// 		Alternatively, consider parameterizing a `NumAllowedEmptyReads`, then
-	// 		return an `io.ErrNoProgress` if `readable` is zero that many times in
-	//		a row.
+	if wr.spill != nil {
+		if err := wr.pageIn(wr.bytesRead + wr.spill.threshold); err != nil {
+			return 0, err
+		}
+	}
+
+	wr.attachChunks()
+
 	readable := wr.bytesAvail.NextCap()
-	if readable >= int64(len(p)) {
-		readable = int64(len(p))
+	if readable > int64(len(wr.buf)) {
+		readable = int64(len(wr.buf))
+	}
+
+	return readable, nil
+}
+
+// take consumes up to max contiguous bytes from the front of the buffer, and
+// returns them as a slice of the buffer's old contents, which writers never
+// touch again (or, if file-backed, as a new slice read from the file). If block
+// is true, it waits until at least one byte is readable.
+// take must be called with wr.m held.
+func (wr *WriterAtReadCloser) take(ctx context.Context, max int64, block bool) ([]byte, error) {
+	if wr.readClosed {
+		return nil, wr.readErr()
+	}
+
+	if wr.bytesRead == wr.size && max > 0 {
+		return nil, io.EOF
+	}
+
+	readable, err := wr.readable()
+	if err != nil {
+		return nil, err
+	}
+
+	for block && readable == 0 && max > 0 && !wr.writeClosed {
+		if err := wr.wait(ctx, wr.readDeadline); err != nil {
+			return nil, err
+		}
+
+		if wr.readClosed {
+			return nil, wr.readErr()
+		}
+
+		if wr.bytesRead == wr.size {
+			return nil, io.EOF
+		}
+
+		if readable, err = wr.readable(); err != nil {
+			return nil, err
+		}
+	}
+
+	if readable == 0 && wr.writeClosed && max > 0 {
+		return nil, io.EOF
+	}
+
+	if readable == 0 && max > 0 {
+		wr.emptyReads++
+		if wr.maxEmptyReads > 0 && wr.emptyReads > wr.maxEmptyReads {
+			return nil, io.ErrNoProgress
+		}
+	} else if readable > 0 {
+		wr.emptyReads = 0
+	}
+
+	if readable >= max {
+		readable = max
+	}
+
+	var chunk []byte
+
+	if wr.backing != nil {
+		if readable > fileBackedReadMax {
+			readable = fileBackedReadMax
+		}
+
+		if chunk, err = wr.backingRead(readable); err != nil {
+			return nil, err
+		}
+	} else {
+		chunk = wr.buf[:readable:readable]
+		wr.buf = wr.buf[readable:]
 	}
 
 	wr.bytesAvail.Consume(readable)
 	wr.bytesRead += readable
 
-	copy(p, wr.buf[:readable])
-	wr.buf = wr.buf[readable:]
+	if wr.observer != nil && readable > 0 {
+		wr.observer.OnRead(int(readable))
+	}
+
+	if wr.autoCompact && cap(wr.alloc) >= autoCompactMinCap && len(wr.buf) < cap(wr.alloc)/4 {
+		wr.compact()
+	}
+
+	if wr.maxBuffered > 0 && readable > 0 {
+		wr.broadcast() // wake writers waiting for room
+	}
 
-	return int(readable), nil
+	return chunk, nil
 }
 
-// Close closes off the WriterAtReadCloser for both future reading and writing.
+// WriteTo implements io.WriterTo for WriterAtReadCloser, so io.Copy hands each
+// contiguous run of bytes directly to w instead of copying through an
+// intermediate buffer. The mutex is not held while w.Write runs, so writers are
+// not stalled by a slow destination.
+//
+// WriteTo returns once Read would return io.EOF (with a nil error, as for
+// io.Copy), or the error from CloseWithError. Without WithBlockingRead, it also
+// returns as soon as no more bytes are currently readable.
+func (wr *WriterAtReadCloser) WriteTo(w io.Writer) (n int64, err error) {
+	for {
+		wr.m.Lock()
+		chunk, err := wr.take(context.Background(), math.MaxInt64, wr.blockingRead)
+		wr.m.Unlock()
+
+		if err == io.EOF {
+			return n, nil
+		}
+
+		if err != nil || len(chunk) == 0 {
+			return n, err
+		}
+
+		written, err := w.Write(chunk)
+		n += int64(written)
+
+		if err != nil {
+			return n, err
+		}
+
+		if written != len(chunk) {
+			return n, io.ErrShortWrite
+		}
+	}
+}
+
+// Close closes off the WriterAtReadCloser for both future reading and writing,
+// discarding any unread bytes; see CloseWrite for a graceful alternative.
 // Subsequent calls to Read() will return io.EOF, and subsequent calls to Write()
-// will return os.ErrClosed.
+// will return ErrWriteAfterClose.
 func (wr *WriterAtReadCloser) Close() error {
+	return wr.CloseWithError(nil)
+}
+
+// CloseWithError closes off the WriterAtReadCloser like Close, but subsequent
+// calls to Read and WriteAt return err instead of io.EOF and ErrWriteAfterClose,
+// mirroring (*io.PipeWriter).CloseWithError. Use it to abort a reader with the
+// real reason a transfer failed. CloseWithError never overwrites the error from
+// an earlier close; passing a nil err is equivalent to calling Close.
+func (wr *WriterAtReadCloser) CloseWithError(err error) error {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	if !wr.readClosed {
+		wr.readClosed = true
+		wr.closeErr = err
+		wr.removeSpill()
+		wr.removeBacking()
+	}
+
+	wr.leak.markClosed()
+	wr.broadcast()
+
+	return nil
+}
+
+// SetSize declares the total length of the stream, if it is known up front.
+// Once n bytes have been read, Read returns io.EOF without the writer having
+// to call CloseWrite, and a WriteAt extending past n fails with an error
+// wrapping ErrBeyondSize. SetSize itself returns such an error, and leaves the
+// size unchanged, if bytes past n have already been written.
+func (wr *WriterAtReadCloser) SetSize(n int64) error {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	if n < wr.written {
+		return fmt.Errorf("%w: %d bytes already written", ErrBeyondSize, wr.written)
+	}
+
+	wr.size = n
+	wr.broadcast()
+
+	return nil
+}
+
+// WriterAtReadCloserStats is a point-in-time view of a WriterAtReadCloser, as
+// returned by its Stats method.
+type WriterAtReadCloserStats struct {
+	// Buffered is the number of bytes that Read could return right now.
+	Buffered int64
+	// Pending is the number of written bytes that are not yet readable because
+	// they sit beyond a gap.
+	Pending int64
+	// BytesWritten is the total number of bytes accepted by WriteAt, counting
+	// overwritten regions each time they were written.
+	BytesWritten int64
+	// BytesRead is the total number of bytes returned by Read.
+	BytesRead int64
+	// BufferLen and BufferCap are the total length and capacity of the
+	// in-memory buffers. BufferCap growing well beyond Buffered+Pending
+	// indicates that GrowthCoeff is too high for the write pattern.
+	BufferLen int
+	BufferCap int
+	// Chunks is the number of separate in-memory runs of bytes written beyond
+	// a gap.
+	Chunks int
+}
+
+// Buffered returns the number of bytes that are contiguously readable at the
+// front of the buffer right now.
+func (wr *WriterAtReadCloser) Buffered() int64 {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	return wr.bytesAvail.NextCap()
+}
+
+// Pending returns the number of bytes that have been written but cannot be read
+// yet, because some earlier bytes are still missing.
+func (wr *WriterAtReadCloser) Pending() int64 {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	return wr.bytesAvail.Len() - wr.bytesAvail.NextCap()
+}
+
+// Stats returns a snapshot of the WriterAtReadCloser's counters and buffer
+// usage.
+func (wr *WriterAtReadCloser) Stats() WriterAtReadCloserStats {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	buffered := wr.bytesAvail.NextCap()
+	stats := WriterAtReadCloserStats{
+		Buffered:     buffered,
+		Pending:      wr.bytesAvail.Len() - buffered,
+		BytesWritten: wr.bytesWritten,
+		BytesRead:    wr.bytesRead,
+		BufferLen:    len(wr.buf),
+		BufferCap:    cap(wr.buf),
+		Chunks:       len(wr.chunks),
+	}
+
+	for _, c := range wr.chunks {
+		stats.BufferLen += len(c.data)
+		stats.BufferCap += cap(c.data)
+	}
+
+	return stats
+}
+
+// Gaps returns the unread byte ranges that have not been written yet, as
+// absolute offsets in ascending order. Ranges are reported up to the highest
+// offset written so far or, if a size was set, up to the size. Since written
+// bytes are only dropped once read, retrying exactly the returned ranges is
+// enough to complete the stream.
+func (wr *WriterAtReadCloser) Gaps() []Range {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	end := wr.written
+	if wr.size > end {
+		end = wr.size
+	}
+
+	gaps := wr.bytesAvail.Gaps(end - wr.bytesRead)
+	for i := range gaps {
+		gaps[i].Start += wr.bytesRead
+		gaps[i].End += wr.bytesRead
+	}
+
+	return gaps
+}
+
+// CloseWrite signals that no more writes are coming. Subsequent calls to
+// WriteAt return ErrWriteAfterClose, but Reads continue to drain every contiguous
+// byte already written, and only then return io.EOF (including Reads that are
+// waiting for data). Bytes written beyond a gap that was never filled are
+// never returned. Use Close or CloseWithError to abort both sides immediately.
+func (wr *WriterAtReadCloser) CloseWrite() error {
 	wr.m.Lock()
 	defer wr.m.Unlock()
 
-	wr.readClosed = true
+	wr.writeClosed = true
+	wr.leak.markClosed()
+	wr.broadcast()
 
 	return nil
 }
+
+// Compact reallocates the internal buffer to exactly fit the unread bytes it
+// holds, releasing the already-read prefix and any spare capacity to the garbage
+// collector. See also WithAutoCompact.
+func (wr *WriterAtReadCloser) Compact() {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	wr.compact()
+}
+
+// compact implements Compact. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) compact() {
+	var buf []byte
+	if len(wr.buf) > 0 {
+		buf = make([]byte, len(wr.buf))
+		copy(buf, wr.buf)
+	}
+
+	wr.buf = buf
+	wr.alloc = buf
+}
+
+// Reset returns the WriterAtReadCloser to the state of a newly created one, so
+// that it can be reused (e.g. from a sync.Pool) for another transfer without
+// reallocating its buffer. Options passed at construction are kept, except
+// that the size set by WithSize or SetSize is cleared, as is the read
+// deadline. Any spill file is removed, and a sink that finished flushing starts
+// flushing the next transfer. Reset must not be called while other
+// goroutines are using the WriterAtReadCloser.
+func (wr *WriterAtReadCloser) Reset() {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	wr.removeSpill()
+	wr.removeBacking()
+
+	wr.buf = wr.alloc[:0]
+	wr.chunks = nil
+	wr.bytesAvail = newRangeSet()
+	wr.bytesRead = 0
+	wr.emptyReads = 0
+	wr.bytesWritten = 0
+	wr.written = 0
+	wr.size = -1
+	wr.readDeadline = time.Time{}
+	wr.readClosed = false
+	wr.writeClosed = false
+	wr.closeErr = nil
+
+	// The old tracker would otherwise report the instance as leaked even if
+	// the next transfer closes it.
+	wr.leak.markClosed()
+	wr.leak = trackLeak("*miscio.WriterAtReadCloser")
+
+	wr.progressMu.Lock()
+	wr.progressLatest = 0
+	wr.progressMu.Unlock()
+
+	wr.resetSink()
+
+	wr.broadcast()
+}
+
+// readErr returns the error Read reports once the WriterAtReadCloser is
+// closed. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) readErr() error {
+	if wr.closeErr != nil {
+		return wr.closeErr
+	}
+
+	return io.EOF
+}
+
+// writeErr returns the error WriteAt reports once the WriterAtReadCloser is
+// closed. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) writeErr() error {
+	if wr.closeErr != nil {
+		return wr.closeErr
+	}
+
+	return ErrWriteAfterClose
+}
@@ -0,0 +1,41 @@
+package miscio
+
+// WithConflictCheck makes WriteAt compare each write against any bytes already
+// written at the same offsets, and reject it with an *ErrWriteConflict if they
+// differ. Rewriting identical bytes, as a correct retry does, is still allowed.
+// Without this option, overlapping writes are last-writer-wins.
+//
+// Bytes that have already been read can no longer be compared.
+func WithConflictCheck() WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.conflictCheck = true
+	}
+}
+
+// checkConflict implements WithConflictCheck for a write of p at off. It must
+// be called with wr.m held.
+func (wr *WriterAtReadCloser) checkConflict(p []byte, off int64) error {
+	if !wr.conflictCheck {
+		return nil
+	}
+
+	rel := off - wr.bytesRead
+
+	for _, r := range wr.bytesAvail.Covered(rel, rel+int64(len(p))) {
+		start := r.Start + wr.bytesRead
+
+		existing := make([]byte, r.Len())
+		if err := wr.copyStored(existing, start); err != nil {
+			return err
+		}
+
+		incoming := p[start-off:]
+		for i, b := range existing {
+			if b != incoming[i] {
+				return &ErrWriteConflict{Offset: start + int64(i)}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package miscio
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConflictCheck(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithConflictCheck(), WithSpillToDisk(t.TempDir(), 8))
+	defer w.Close()
+
+	w.WriteAt([]byte("hello "), 0)
+	w.WriteAt([]byte("world"), 20) // spilled
+	w.WriteAt([]byte("abc"), 10)   // chunk
+
+	// Identical retries are fine.
+	for _, retry := range []struct {
+		data string
+		off  int64
+	}{{"llo", 2}, {"world", 20}, {"abc", 10}} {
+		if _, err := w.WriteAt([]byte(retry.data), retry.off); err != nil {
+			t.Errorf("identical rewrite at %d failed with %s", retry.off, err)
+		}
+	}
+
+	for _, conflict := range []struct {
+		data string
+		off  int64
+		at   int64
+	}{{"lo!", 3, 5}, {"wOrld", 20, 21}, {"xbc", 9, 10}} {
+		_, err := w.WriteAt([]byte(conflict.data), conflict.off)
+
+		var errConflict *ErrWriteConflict
+		if !errors.As(err, &errConflict) || errConflict.Offset != conflict.at {
+			t.Errorf("expected conflict at offset %d, got %v", conflict.at, err)
+		}
+	}
+}
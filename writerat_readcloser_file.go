@@ -0,0 +1,90 @@
+package miscio
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// fileBackedReadMax bounds how much a file-backed WriterAtReadCloser reads from
+// its backing file into memory at once.
+const fileBackedReadMax = 1 << 20
+
+// backingFile is the store of a file-backed WriterAtReadCloser. Unlike a
+// spillFile, it holds every byte written, at its absolute offset.
+type backingFile struct {
+	dir string
+	f   *os.File
+}
+
+// NewFileBackedWriterAtReadCloser returns a WriterAtReadCloser that keeps
+// written data in a temporary file in dir (os.TempDir() if empty) instead of
+// on the heap, for transfers too large to buffer in memory. It has the same
+// read-once semantics as any other WriterAtReadCloser, but each Read costs a
+// read from the file, and returns data in newly allocated memory.
+//
+// The file is created on the first WriteAt, which returns the error if that
+// fails, and is removed by Close, CloseWithError or Reset, so callers must
+// always call one of them. Since nothing is held in memory, WithSpillToDisk
+// has no effect; the file is written at absolute offsets, so its size grows
+// with the highest offset written, unless the filesystem supports sparse
+// files.
+func NewFileBackedWriterAtReadCloser(dir string, opts ...WriterAtReadCloserOption) *WriterAtReadCloser {
+	// The backing file is set up by an option applied last, so that it is in
+	// place before a sink set WithSink starts reading.
+	opts = append(opts[:len(opts):len(opts)], withBackingFile(dir))
+
+	return NewWriterAtReadCloser(0, opts...)
+}
+
+// withBackingFile makes a WriterAtReadCloser keep its data in a temporary file
+// in dir, overriding WithSpillToDisk.
+func withBackingFile(dir string) WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.backing = &backingFile{dir: dir}
+		wr.spill = nil
+	}
+}
+
+// backingWrite writes p at absolute offset off to the backing file, creating it
+// if necessary. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) backingWrite(p []byte, off int64) error {
+	if wr.backing.f == nil {
+		f, err := ioutil.TempFile(wr.backing.dir, "miscio-buffer-*")
+		if err != nil {
+			return err
+		}
+
+		wr.backing.f = f
+	}
+
+	_, err := wr.backing.f.WriteAt(p, off)
+
+	return err
+}
+
+// backingRead reads n bytes at the read cursor from the backing file into a new
+// slice. It must be called with wr.m held, and only for bytes already written.
+func (wr *WriterAtReadCloser) backingRead(n int64) ([]byte, error) {
+	chunk := make([]byte, n)
+	if n == 0 {
+		return chunk, nil
+	}
+
+	if _, err := wr.backing.f.ReadAt(chunk, wr.bytesRead); err != nil {
+		return nil, err
+	}
+
+	return chunk, nil
+}
+
+// removeBacking closes and deletes the backing file, if one was created. It must
+// be called with wr.m held.
+func (wr *WriterAtReadCloser) removeBacking() {
+	if wr.backing == nil || wr.backing.f == nil {
+		return
+	}
+
+	wr.backing.f.Close()
+	os.Remove(wr.backing.f.Name())
+	wr.backing.f = nil
+}
@@ -0,0 +1,80 @@
+package miscio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestFileBacked(t *testing.T) {
+	dir := t.TempDir()
+	w := NewFileBackedWriterAtReadCloser(dir, WithSize(11))
+	expected := "hello world"
+
+	if err := WriteInChunks(w, []byte(expected), 0, 3); err != nil {
+		t.Fatalf("got error writing: %s", err)
+	}
+
+	if stats := w.Stats(); stats.BufferLen != 0 || stats.Buffered != 11 {
+		t.Errorf("expected all data on disk, got %+v", stats)
+	}
+
+	buf, err := ioutil.ReadAll(w)
+	if err != nil || string(buf) != expected {
+		t.Errorf("ReadAll got (%q, %v)", buf, err)
+	}
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("expected one backing file, found %d", len(files))
+	}
+
+	w.Close()
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected Close to remove the backing file, found %d files", len(files))
+	}
+}
+
+func TestFileBackedWriteTo(t *testing.T) {
+	w := NewFileBackedWriterAtReadCloser(t.TempDir(), WithConflictCheck())
+	defer w.Close()
+
+	data := bytes.Repeat([]byte("0123456789"), fileBackedReadMax/5)
+
+	w.WriteAt(data[len(data)/2:], int64(len(data)/2))
+	w.WriteAt(data[:len(data)/2], 0)
+
+	if _, err := w.WriteAt([]byte("x"), 1); err == nil {
+		t.Errorf("expected a conflict with data in the backing file")
+	}
+
+	w.CloseWrite()
+
+	var out bytes.Buffer
+	if n, err := w.WriteTo(&out); n != int64(len(data)) || err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("WriteTo got (%d, %v)", n, err)
+	}
+}
+
+func TestFileBackedSink(t *testing.T) {
+	var dst bytes.Buffer
+
+	w := NewFileBackedWriterAtReadCloser(t.TempDir(), WithSink(&dst))
+	defer w.Close()
+
+	expected := "hello from disk"
+	if err := WriteInChunks(w, []byte(expected), 0, 4); err != nil {
+		t.Fatalf("got error writing: %s", err)
+	}
+
+	w.CloseWrite()
+	<-w.Done()
+
+	if err := w.Err(); err != nil {
+		t.Errorf("expected flushing to succeed, got %v", err)
+	}
+
+	if dst.String() != expected {
+		t.Errorf("sink got %q, want %q", dst.String(), expected)
+	}
+}
@@ -0,0 +1,28 @@
+package miscio
+
+// WriterAtReadCloserObserver receives instrumentation events from a
+// WriterAtReadCloser created WithObserver, e.g. to export throughput and buffer
+// growth as metrics.
+//
+// The methods are called with the WriterAtReadCloser's lock held, so they must
+// be quick and must not call back into the WriterAtReadCloser.
+type WriterAtReadCloserObserver interface {
+	// OnWrite is called for every accepted WriteAt, with its offset and length.
+	OnWrite(off int64, n int)
+	// OnRead is called whenever bytes are consumed by Read, ReadContext or
+	// WriteTo, with the number of bytes.
+	OnRead(n int)
+	// OnGrow is called whenever the in-memory head buffer is reallocated to
+	// make room for a write, with its old and new capacities. Frequent
+	// calls suggest GrowthCoeff (or the initial size) is too small; new
+	// capacities far beyond what is written suggest it is too large.
+	OnGrow(oldCap, newCap int)
+}
+
+// WithObserver installs o to receive instrumentation events; see
+// WriterAtReadCloserObserver.
+func WithObserver(o WriterAtReadCloserObserver) WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.observer = o
+	}
+}
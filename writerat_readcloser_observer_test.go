@@ -0,0 +1,45 @@
+package miscio
+
+import "testing"
+
+type countingObserver struct {
+	writes, written, read, grows int
+	lastCap                      int
+}
+
+func (o *countingObserver) OnWrite(off int64, n int) {
+	o.writes++
+	o.written += n
+}
+
+func (o *countingObserver) OnRead(n int) { o.read += n }
+
+func (o *countingObserver) OnGrow(oldCap, newCap int) {
+	o.grows++
+	o.lastCap = newCap
+}
+
+func TestObserver(t *testing.T) {
+	o := &countingObserver{}
+	w := NewWriterAtReadCloser(2, WithObserver(o))
+	w.GrowthCoeff = 2
+
+	w.WriteAt([]byte("world"), 6)
+	w.WriteAt([]byte("hello "), 0)
+
+	if o.writes != 2 || o.written != 11 {
+		t.Errorf("expected 2 writes of 11 bytes, got %d writes of %d bytes", o.writes, o.written)
+	}
+
+	if o.grows == 0 || o.lastCap < 11 {
+		t.Errorf("expected the buffer to grow to at least 11 bytes, got %d grows to %d", o.grows, o.lastCap)
+	}
+
+	w.Read(make([]byte, 4))
+	w.Read(make([]byte, 16))
+	w.Read(make([]byte, 16))
+
+	if o.read != 11 {
+		t.Errorf("expected 11 bytes read, got %d", o.read)
+	}
+}
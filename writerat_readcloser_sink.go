@@ -0,0 +1,92 @@
+package miscio
+
+import "io"
+
+// sink is the state of a WriterAtReadCloser created WithSink.
+type sink struct {
+	dst  io.Writer
+	done chan struct{}
+	err  error
+}
+
+// WithSink makes the WriterAtReadCloser flush bytes into dst by itself, in
+// order, as soon as the contiguous front of the stream advances, so callers
+// need not run their own reader goroutine. It implies WithBlockingRead. Read,
+// Next and WriteTo must not be used on such a WriterAtReadCloser, since they
+// would take bytes away from dst.
+//
+// Flushing finishes once the stream ends (see CloseWrite and SetSize), or
+// fails with the first error from dst.Write, which also aborts the
+// WriterAtReadCloser as if by CloseWithError. Done and Err report the outcome.
+// Close still has to be called once the WriterAtReadCloser is no longer needed;
+// calling it earlier stops flushing.
+func WithSink(dst io.Writer) WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.sink = &sink{dst: dst}
+		wr.blockingRead = true
+	}
+}
+
+// startSink starts the goroutine that flushes into the sink. It must be called
+// with wr.m held, or before the WriterAtReadCloser is shared.
+func (wr *WriterAtReadCloser) startSink() {
+	s := wr.sink
+	s.done = make(chan struct{})
+	s.err = nil
+
+	go func() {
+		_, err := wr.WriteTo(s.dst)
+		if err != nil {
+			wr.CloseWithError(err)
+		}
+
+		wr.m.Lock()
+		defer wr.m.Unlock()
+
+		s.err = err
+		close(s.done)
+	}()
+}
+
+// Done returns a channel that is closed once a WriterAtReadCloser created
+// WithSink has finished flushing, successfully or not; Err then reports which.
+// Done returns nil if the WriterAtReadCloser was not created WithSink.
+func (wr *WriterAtReadCloser) Done() <-chan struct{} {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	if wr.sink == nil {
+		return nil
+	}
+
+	return wr.sink.done
+}
+
+// Err returns the error that stopped a WriterAtReadCloser created WithSink
+// from flushing: the error from the sink's Write, or the error passed to
+// CloseWithError. It returns nil while flushing is still in progress, or if it
+// finished successfully.
+func (wr *WriterAtReadCloser) Err() error {
+	wr.m.Lock()
+	defer wr.m.Unlock()
+
+	if wr.sink == nil {
+		return nil
+	}
+
+	return wr.sink.err
+}
+
+// resetSink restarts flushing after Reset, if the previous transfer's flushing
+// had already finished. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) resetSink() {
+	if wr.sink == nil {
+		return
+	}
+
+	select {
+	case <-wr.sink.done:
+		wr.startSink()
+	default:
+	}
+}
@@ -0,0 +1,51 @@
+package miscio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSink(t *testing.T) {
+	var dst bytes.Buffer
+
+	w := NewWriterAtReadCloser(0, WithSink(&dst))
+	defer w.Close()
+
+	expected := "hello world"
+	if err := WriteInChunks(w, []byte(expected), 0, 3); err != nil {
+		t.Fatalf("got error writing: %s", err)
+	}
+
+	w.CloseWrite()
+	<-w.Done()
+
+	if err := w.Err(); err != nil {
+		t.Errorf("expected flushing to succeed, got %v", err)
+	}
+
+	if dst.String() != expected {
+		t.Errorf("sink got %q, want %q", dst.String(), expected)
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (fw failingWriter) Write(p []byte) (int, error) { return 0, fw.err }
+
+func TestSinkError(t *testing.T) {
+	cause := errors.New("disk full")
+	w := NewWriterAtReadCloser(0, WithSink(failingWriter{cause}))
+	defer w.Close()
+
+	w.WriteAt([]byte("data"), 0)
+	<-w.Done()
+
+	if err := w.Err(); err != cause {
+		t.Errorf("expected the sink's error, got %v", err)
+	}
+
+	if _, err := w.WriteAt([]byte("more"), 4); err != cause {
+		t.Errorf("expected writes to fail with the sink's error, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package miscio
+
+import "sort"
+
+// bufChunk is a run of written bytes that is not (yet) attached to the
+// contiguous head buffer of a WriterAtReadCloser.
+type bufChunk struct {
+	off  int64 // absolute offset
+	data []byte
+}
+
+func (c bufChunk) end() int64 { return c.off + int64(len(c.data)) }
+
+// storeAt copies p into memory at absolute offset off. Writes touching the
+// contiguous head buffer (wr.buf, which starts at the read cursor) or its spare
+// capacity are copied into it. Writes starting beyond that are kept as separate
+// chunks instead, so a far-ahead write does not allocate and zero-fill the
+// hole before it. The chunks are kept sorted, non-overlapping and at or beyond
+// the end of the head; they are merged into the head as it reaches them.
+//
+// storeAt must be called with wr.m held.
+func (wr *WriterAtReadCloser) storeAt(p []byte, off int64) {
+	end := off + int64(len(p))
+
+	// Writes within the head's existing capacity go into the head too, as
+	// extending it costs no allocation.
+	if off <= wr.bytesRead+int64(cap(wr.buf)) {
+		// Older chunk data under the extended head must be merged first, so
+		// p lands on top of it.
+		wr.mergeChunks(end)
+		wr.ensureLen(end - wr.bytesRead)
+		copy(wr.buf[off-wr.bytesRead:], p)
+
+		return
+	}
+
+	wr.chunks = insertChunk(wr.chunks, p, off)
+}
+
+// insertChunk copies p into the sorted, non-overlapping chunks at absolute
+// offset off, replacing the chunks it overlaps or abuts with a single chunk
+// covering their union, and returns the updated slice.
+func insertChunk(chunks []bufChunk, p []byte, off int64) []bufChunk {
+	end := off + int64(len(p))
+
+	lo := sort.Search(len(chunks), func(i int) bool { return chunks[i].end() >= off })
+	hi := lo
+
+	start := off
+	for hi < len(chunks) && chunks[hi].off <= end {
+		if chunks[hi].off < start {
+			start = chunks[hi].off
+		}
+
+		if chunks[hi].end() > end {
+			end = chunks[hi].end()
+		}
+
+		hi++
+	}
+
+	merged := bufChunk{off: start, data: make([]byte, end-start)}
+	for _, c := range chunks[lo:hi] {
+		copy(merged.data[c.off-start:], c.data)
+	}
+
+	copy(merged.data[off-start:], p)
+
+	return append(chunks[:lo], append([]bufChunk{merged}, chunks[hi:]...)...)
+}
+
+// mergeChunks copies every chunk starting before the absolute offset upTo into
+// the head buffer, extending it as needed. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) mergeChunks(upTo int64) {
+	merged := 0
+
+	for _, c := range wr.chunks {
+		if c.off >= upTo {
+			break
+		}
+
+		wr.ensureLen(c.end() - wr.bytesRead)
+		copy(wr.buf[c.off-wr.bytesRead:], c.data)
+		merged++
+	}
+
+	wr.chunks = wr.chunks[merged:]
+}
+
+// attachChunks merges chunks into the head buffer for as long as the next one
+// starts at or before the head's end, so that all contiguous data is in the
+// head. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) attachChunks() {
+	for len(wr.chunks) > 0 && wr.chunks[0].off <= wr.bytesRead+int64(len(wr.buf)) {
+		wr.mergeChunks(wr.chunks[0].off + 1)
+	}
+}
+
+// memEnd returns one past the highest absolute offset held in memory, in
+// either the head buffer or a chunk. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) memEnd() int64 {
+	if n := len(wr.chunks); n > 0 {
+		return wr.chunks[n-1].end()
+	}
+
+	return wr.bytesRead + int64(len(wr.buf))
+}
+
+// copyStored fills dst with the bytes stored at absolute offset off, wherever
+// they are held: the head buffer, a chunk, the spill file, or the backing file.
+// Positions that were never written are left untouched, except in a backing
+// file, where they read as zeros. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) copyStored(dst []byte, off int64) error {
+	if wr.backing != nil {
+		_, err := wr.backing.f.ReadAt(dst, off)
+
+		return err
+	}
+
+	end := off + int64(len(dst))
+
+	if headEnd := wr.bytesRead + int64(len(wr.buf)); off < headEnd {
+		start := off - wr.bytesRead
+		if start < 0 {
+			start = 0
+		}
+
+		copy(dst[start+wr.bytesRead-off:], wr.buf[start:])
+	}
+
+	for _, c := range wr.chunks {
+		if c.off >= end {
+			break
+		}
+
+		if c.end() > off {
+			copyOverlap(dst, off, c.data, c.off)
+		}
+	}
+
+	return wr.copySpilled(dst, off)
+}
+
+// copyOverlap copies the overlap of src (at absolute offset srcOff) into dst (at
+// absolute offset dstOff).
+func copyOverlap(dst []byte, dstOff int64, src []byte, srcOff int64) {
+	if srcOff < dstOff {
+		if dstOff-srcOff >= int64(len(src)) {
+			return
+		}
+
+		copy(dst, src[dstOff-srcOff:])
+
+		return
+	}
+
+	if srcOff-dstOff < int64(len(dst)) {
+		copy(dst[srcOff-dstOff:], src)
+	}
+}
@@ -0,0 +1,34 @@
+package miscio
+
+import (
+	"io"
+	"testing"
+)
+
+func TestSparseWrites(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithSize(1<<30+3))
+	w.WriteAt([]byte("z"), 1<<30+2)
+
+	if stats := w.Stats(); stats.BufferCap > 1024 {
+		t.Fatalf("far-ahead write allocated %d bytes", stats.BufferCap)
+	}
+
+	w.WriteAt([]byte("cd"), 10)
+	w.WriteAt([]byte("ef"), 13)
+	w.WriteAt([]byte("XY"), 12)
+
+	if stats := w.Stats(); stats.Chunks != 2 {
+		t.Errorf("expected abutting chunks to be coalesced into 2, have %d", stats.Chunks)
+	}
+
+	w.WriteAt([]byte("0123456789"), 0)
+
+	buf := make([]byte, 15)
+	if _, err := io.ReadFull(w, buf); err != nil {
+		t.Fatalf("got error reading: %s", err)
+	}
+
+	if string(buf) != "0123456789cdXYf" {
+		t.Errorf("Read mismatch, have %s want 0123456789cdXYf", buf)
+	}
+}
@@ -0,0 +1,146 @@
+package miscio
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// spillFile is the on-disk backing for regions of a WriterAtReadCloser that are
+// written far ahead of the read cursor.
+type spillFile struct {
+	dir       string
+	threshold int64
+	f         *os.File
+	// ranges lists the absolute byte ranges currently held only on disk. The
+	// file itself is written at absolute offsets, so overlapping ranges need
+	// no special handling: the file always holds the latest data.
+	ranges []Range
+}
+
+// WithSpillToDisk keeps memory use bounded for transfers where chunks arrive
+// far ahead of the reader. A WriteAt landing entirely beyond both the in-memory
+// buffer and the next threshold bytes after the read cursor is written to a
+// temporary file in dir (os.TempDir() if empty) instead of memory, and paged
+// back in once the read cursor comes within threshold bytes of it. The file is
+// created on the first spill and removed by Close or CloseWithError, so callers
+// using this option must always call one of them.
+func WithSpillToDisk(dir string, threshold int64) WriterAtReadCloserOption {
+	return func(wr *WriterAtReadCloser) {
+		wr.spill = &spillFile{dir: dir, threshold: threshold}
+	}
+}
+
+// spillWrite writes p to the spill file, and reports true, if it lies entirely
+// beyond both the data held in memory and the in-memory window. Otherwise it
+// pages in any spilled data that p could overlap, so the in-memory write is not
+// later overwritten with stale data, and reports false. It must be called with
+// wr.m held.
+func (wr *WriterAtReadCloser) spillWrite(p []byte, off int64) (bool, error) {
+	if wr.spill == nil {
+		return false, nil
+	}
+
+	memEnd := wr.memEnd()
+	if windowEnd := wr.bytesRead + wr.spill.threshold; windowEnd > memEnd {
+		memEnd = windowEnd
+	}
+
+	if off < memEnd {
+		return false, wr.pageIn(off + int64(len(p)))
+	}
+
+	if wr.spill.f == nil {
+		f, err := ioutil.TempFile(wr.spill.dir, "miscio-spill-*")
+		if err != nil {
+			return false, err
+		}
+
+		wr.spill.f = f
+	}
+
+	if _, err := wr.spill.f.WriteAt(p, off); err != nil {
+		return false, err
+	}
+
+	wr.spill.ranges = append(wr.spill.ranges, Range{Start: off, End: off + int64(len(p))})
+
+	return true, nil
+}
+
+// pageIn loads every spilled range starting at or before the absolute offset
+// upTo into the in-memory buffer. A range starting exactly at upTo is included,
+// since with a threshold of zero that is where the read cursor is. It must be
+// called with wr.m held.
+func (wr *WriterAtReadCloser) pageIn(upTo int64) error {
+	if wr.spill == nil || len(wr.spill.ranges) == 0 {
+		return nil
+	}
+
+	remaining := wr.spill.ranges[:0]
+
+	for i, r := range wr.spill.ranges {
+		if r.Start > upTo {
+			remaining = append(remaining, r)
+
+			continue
+		}
+
+		// Spilled ranges never overlap in-memory data, so the order in which
+		// they are stored does not matter.
+		data := make([]byte, r.Len())
+		if _, err := wr.spill.f.ReadAt(data, r.Start); err != nil {
+			wr.spill.ranges = append(remaining, wr.spill.ranges[i:]...)
+
+			return err
+		}
+
+		wr.storeAt(data, r.Start)
+	}
+
+	wr.spill.ranges = remaining
+
+	return nil
+}
+
+// copySpilled fills the parts of dst (at absolute offset off) that are held in
+// the spill file. It must be called with wr.m held.
+func (wr *WriterAtReadCloser) copySpilled(dst []byte, off int64) error {
+	if wr.spill == nil {
+		return nil
+	}
+
+	end := off + int64(len(dst))
+
+	for _, r := range wr.spill.ranges {
+		if r.Start >= end || r.End <= off {
+			continue
+		}
+
+		if r.Start < off {
+			r.Start = off
+		}
+
+		if r.End > end {
+			r.End = end
+		}
+
+		if _, err := wr.spill.f.ReadAt(dst[r.Start-off:r.End-off], r.Start); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeSpill closes and deletes the spill file, if one was created. It must be
+// called with wr.m held.
+func (wr *WriterAtReadCloser) removeSpill() {
+	if wr.spill == nil || wr.spill.f == nil {
+		return
+	}
+
+	wr.spill.f.Close()
+	os.Remove(wr.spill.f.Name())
+	wr.spill.f = nil
+	wr.spill.ranges = nil
+}
@@ -0,0 +1,70 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestSpillToDisk(t *testing.T) {
+	dir := t.TempDir()
+	w := NewWriterAtReadCloser(0, WithSpillToDisk(dir, 4), WithSize(11))
+	expected := "hello world"
+
+	w.WriteAt([]byte("world"), 6)
+
+	if stats := w.Stats(); stats.BufferLen != 0 {
+		t.Errorf("expected far-ahead write to be spilled, buffer holds %d bytes", stats.BufferLen)
+	}
+
+	w.WriteAt([]byte("hello "), 0)
+
+	buf, err := ioutil.ReadAll(w)
+	if err != nil {
+		t.Errorf("got error reading: %s", err)
+	}
+
+	if string(buf) != expected {
+		t.Errorf("Read mismatch, have %s want %s", buf, expected)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("expected one spill file, found %d", len(files))
+	}
+
+	w.Close()
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected Close to remove the spill file, found %d files", len(files))
+	}
+}
+
+func TestSpillOverwrite(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithSpillToDisk(t.TempDir(), 2), WithSize(6))
+	defer w.Close()
+
+	w.WriteAt([]byte("xxx"), 3)
+	w.WriteAt([]byte("abcdef"), 0)
+
+	buf := make([]byte, 6)
+	if _, err := io.ReadFull(w, buf); err != nil || string(buf) != "abcdef" {
+		t.Errorf("expected later in-memory write to win, got (%q, %v)", buf, err)
+	}
+}
+
+func TestSpillZeroThreshold(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithBlockingRead(), WithSpillToDisk(t.TempDir(), 0))
+	defer w.Close()
+
+	w.SetReadDeadline(time.Now().Add(5 * time.Second))
+	w.WriteAt([]byte("hello"), 0)
+
+	buf := make([]byte, 5)
+
+	n, err := w.Read(buf)
+	if err != nil || string(buf[:n]) != "hello" {
+		t.Errorf("expected to read the spilled write at the cursor, got %q, %v", buf[:n], err)
+	}
+}
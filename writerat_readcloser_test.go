@@ -1,9 +1,15 @@
 package miscio
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"io"
+	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func WriteInChunks(w io.WriterAt, b []byte, base, chunkSize int) error {
@@ -96,3 +102,487 @@ func TestInitialSize(t *testing.T) {
 		t.Errorf("Read mismatch, have got %s want %s", buf, expected)
 	}
 }
+
+func TestBlockingRead(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithBlockingRead())
+	expected := "hello world"
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteAt([]byte("world"), 6)
+		time.Sleep(10 * time.Millisecond)
+		w.WriteAt([]byte("hello "), 0)
+	}()
+
+	buf := make([]byte, len(expected))
+	n, err := w.Read(buf)
+
+	if err != nil {
+		t.Errorf("got error reading: %s. %d bytes read", err, n)
+	}
+
+	if string(buf[:n]) != expected {
+		t.Errorf("Read mismatch, have %s want %s", buf[:n], expected)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		w.Close()
+	}()
+
+	if _, err := w.Read(buf); err != io.EOF {
+		t.Errorf("expected io.EOF from blocked Read after Close, got %v", err)
+	}
+}
+
+func TestReadContext(t *testing.T) {
+	w := NewWriterAtReadCloser(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := w.ReadContext(ctx, make([]byte, 4)); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	w.WriteAt([]byte("data"), 0)
+
+	buf := make([]byte, 4)
+	if n, err := w.ReadContext(context.Background(), buf); err != nil || string(buf[:n]) != "data" {
+		t.Errorf("ReadContext got (%q, %v)", buf[:n], err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	w := NewWriterAtReadCloser(0)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteAt([]byte("der"), 3)
+		time.Sleep(10 * time.Millisecond)
+		w.WriteAt([]byte("hea"), 0)
+	}()
+
+	if n, err := w.Wait(context.Background(), 6); n != 6 || err != nil {
+		t.Fatalf("Wait got (%d, %v)", n, err)
+	}
+
+	buf := make([]byte, 6)
+	if n, _ := w.Read(buf); string(buf[:n]) != "header" {
+		t.Errorf("Read after Wait got %q", buf[:n])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := w.Wait(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	w.WriteAt([]byte("ab"), 6)
+	w.CloseWrite()
+
+	if n, err := w.Wait(context.Background(), 4); n != 2 || err != io.ErrUnexpectedEOF {
+		t.Errorf("Wait after CloseWrite got (%d, %v)", n, err)
+	}
+}
+
+func TestNext(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithBlockingRead())
+	w.WriteAt([]byte("hello world"), 0)
+	w.CloseWrite()
+
+	var got []string
+
+	for {
+		chunk, err := w.Next(4)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Next failed with %s", err)
+		}
+
+		got = append(got, string(chunk))
+	}
+
+	if strings.Join(got, "|") != "hell|o wo|rld" {
+		t.Errorf("Next returned chunks %q", got)
+	}
+}
+
+func TestMaxEmptyReads(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithMaxEmptyReads(2))
+	defer w.Close()
+
+	buf := make([]byte, 4)
+
+	for i := 0; i < 2; i++ {
+		if n, err := w.Read(buf); n != 0 || err != nil {
+			t.Fatalf("empty Read %d got (%d, %v), want (0, nil)", i, n, err)
+		}
+	}
+
+	if _, err := w.Read(buf); err != io.ErrNoProgress {
+		t.Errorf("expected io.ErrNoProgress, got %v", err)
+	}
+
+	w.WriteAt([]byte("data"), 0)
+
+	if n, err := w.Read(buf); n != 4 || err != nil {
+		t.Errorf("Read after progress got (%d, %v)", n, err)
+	}
+
+	if n, err := w.Read(buf); n != 0 || err != nil {
+		t.Errorf("expected the empty read count to start over, got (%d, %v)", n, err)
+	}
+}
+
+func TestSetReadDeadline(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithBlockingRead())
+	w.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+
+	_, err := w.Read(make([]byte, 4))
+	if !os.IsTimeout(err) {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+
+	w.SetReadDeadline(time.Time{})
+	w.WriteAt([]byte("data"), 0)
+
+	if n, err := w.Read(make([]byte, 4)); n != 4 || err != nil {
+		t.Errorf("Read after clearing deadline got (%d, %v)", n, err)
+	}
+}
+
+func TestCloseWithError(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithBlockingRead())
+	cause := errors.New("range request failed")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		w.CloseWithError(cause)
+	}()
+
+	if _, err := w.Read(make([]byte, 4)); err != cause {
+		t.Errorf("expected Read to return the close cause, got %v", err)
+	}
+
+	if _, err := w.WriteAt([]byte("data"), 0); err != cause {
+		t.Errorf("expected WriteAt to return the close cause, got %v", err)
+	}
+
+	w.CloseWithError(errors.New("later error"))
+
+	if _, err := w.Read(make([]byte, 4)); err != cause {
+		t.Errorf("expected the first close error to be kept, got %v", err)
+	}
+}
+
+func TestWriteAtOffsetValidation(t *testing.T) {
+	w := NewWriterAtReadCloser(0)
+	defer w.Close()
+
+	if _, err := w.WriteAt([]byte("x"), -1); !errors.Is(err, ErrNegativeOffset) {
+		t.Errorf("expected ErrNegativeOffset, got %v", err)
+	}
+
+	w.WriteAt([]byte("hello"), 0)
+	w.Read(make([]byte, 3))
+
+	if _, err := w.WriteAt([]byte("lo"), 2); !errors.Is(err, ErrOffsetConsumed) {
+		t.Errorf("expected ErrOffsetConsumed, got %v", err)
+	}
+
+	if _, err := w.WriteAt([]byte("lo"), 3); err != nil {
+		t.Errorf("expected rewrite of unread bytes to succeed, got %v", err)
+	}
+}
+
+func TestCloseWrite(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithBlockingRead())
+	w.WriteAt([]byte("hello"), 0)
+	w.WriteAt([]byte("unreachable"), 10)
+	w.CloseWrite()
+
+	if _, err := w.WriteAt([]byte("late"), 5); err != ErrWriteAfterClose || !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected ErrWriteAfterClose from WriteAt after CloseWrite, got %v", err)
+	}
+
+	buf := make([]byte, 16)
+	if n, err := w.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Errorf("expected buffered bytes after CloseWrite, got (%q, %v)", buf[:n], err)
+	}
+
+	if _, err := w.Read(buf); err != io.EOF {
+		t.Errorf("expected io.EOF once drained, got %v", err)
+	}
+}
+
+func TestSetSize(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithBlockingRead())
+	if err := w.SetSize(5); err != nil {
+		t.Fatalf("SetSize failed with %s", err)
+	}
+
+	if _, err := w.WriteAt([]byte("toolong"), 0); !errors.Is(err, ErrBeyondSize) {
+		t.Errorf("expected ErrBeyondSize, got %v", err)
+	}
+
+	w.WriteAt([]byte("hello"), 0)
+
+	buf := make([]byte, 16)
+	if n, err := w.Read(buf); err != nil || n != 5 {
+		t.Errorf("Read got (%d, %v)", n, err)
+	}
+
+	if _, err := w.Read(buf); err != io.EOF {
+		t.Errorf("expected io.EOF after size bytes were read, got %v", err)
+	}
+
+	if err := w.SetSize(2); !errors.Is(err, ErrBeyondSize) {
+		t.Errorf("expected shrinking below written bytes to fail, got %v", err)
+	}
+}
+
+func TestGaps(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithSize(20))
+	w.WriteAt([]byte("ab"), 0)
+	w.WriteAt([]byte("ef"), 4)
+	w.WriteAt([]byte("ij"), 8)
+	w.Read(make([]byte, 1))
+
+	expected := []Range{{2, 4}, {6, 8}, {10, 20}}
+	gaps := w.Gaps()
+
+	if len(gaps) != len(expected) {
+		t.Fatalf("Gaps mismatch, have %v want %v", gaps, expected)
+	}
+
+	for i := range expected {
+		if gaps[i] != expected[i] {
+			t.Errorf("Gaps mismatch at %d, have %v want %v", i, gaps[i], expected[i])
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	w := NewWriterAtReadCloser(0)
+	w.WriteAt([]byte("abcd"), 0)
+	w.WriteAt([]byte("gh"), 6)
+	w.WriteAt([]byte("gh"), 6)
+	w.Read(make([]byte, 1))
+
+	if w.Buffered() != 3 || w.Pending() != 2 {
+		t.Errorf("expected 3 buffered and 2 pending, got %d and %d", w.Buffered(), w.Pending())
+	}
+
+	stats := w.Stats()
+	if stats.BytesWritten != 8 || stats.BytesRead != 1 {
+		t.Errorf("expected 8 bytes written and 1 read, got %+v", stats)
+	}
+
+	if stats.BufferLen != 5 || stats.Chunks != 1 {
+		t.Errorf("unexpected buffer size in %+v", stats)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithBlockingRead(), WithSize(11))
+	expected := "hello world"
+
+	go WriteInChunks(w, []byte(expected), 0, 3)
+
+	var out bytes.Buffer
+
+	n, err := io.Copy(&out, w)
+	if err != nil || n != int64(len(expected)) {
+		t.Errorf("io.Copy got (%d, %v)", n, err)
+	}
+
+	if out.String() != expected {
+		t.Errorf("WriteTo mismatch, have %s want %s", out.String(), expected)
+	}
+}
+
+func TestReadFromAt(t *testing.T) {
+	w := NewWriterAtReadCloser(0)
+	expected := "hello world"
+
+	var wg sync.WaitGroup
+
+	for _, part := range []struct {
+		data string
+		off  int64
+	}{{"world", 6}, {"hello ", 0}} {
+		wg.Add(1)
+
+		go func(data string, off int64) {
+			defer wg.Done()
+
+			n, err := w.ReadFromAt(strings.NewReader(data), off)
+			if err != nil || n != int64(len(data)) {
+				t.Errorf("ReadFromAt got (%d, %v)", n, err)
+			}
+		}(part.data, part.off)
+	}
+
+	wg.Wait()
+
+	buf := make([]byte, len(expected))
+	n, _ := w.Read(buf)
+
+	if string(buf[:n]) != expected {
+		t.Errorf("Read mismatch, have %s want %s", buf[:n], expected)
+	}
+}
+
+func TestMaxBuffered(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithMaxBuffered(4))
+
+	if _, err := w.WriteAt([]byte("ef"), 4); !errors.Is(err, ErrBufferFull) {
+		t.Errorf("expected ErrBufferFull in non-blocking mode, got %v", err)
+	}
+
+	w = NewWriterAtReadCloser(0, WithMaxBuffered(4), WithBlockingRead())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		w.WriteAt([]byte("ef"), 4)
+	}()
+
+	w.WriteAt([]byte("abcd"), 0)
+
+	select {
+	case <-done:
+		t.Fatal("WriteAt past the limit did not block")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	buf := make([]byte, 6)
+	n, _ := io.ReadFull(w, buf)
+
+	if string(buf[:n]) != "abcdef" {
+		t.Errorf("Read mismatch, have %s want abcdef", buf[:n])
+	}
+
+	<-done
+}
+
+func TestMaxBufferedWriterOvertaken(t *testing.T) {
+	// A writer blocked for room must not store its data once the reader has
+	// moved past its offset while it waited.
+	for i := 0; i < 200; i++ {
+		w := NewWriterAtReadCloser(0, WithMaxBuffered(4), WithBlockingRead())
+		result := make(chan error, 1)
+
+		go func() {
+			_, err := w.WriteAt([]byte("ef"), 4)
+			result <- err
+		}()
+
+		buf := make([]byte, 4)
+
+		time.Sleep(100 * time.Microsecond) // Let the writer start waiting.
+		w.WriteAt([]byte("abcd"), 0)
+		io.ReadFull(w, buf)
+		w.WriteAt([]byte("efgh"), 4)
+		io.ReadFull(w, buf)
+
+		if err := <-result; err != nil && !errors.Is(err, ErrOffsetConsumed) {
+			t.Fatalf("expected the blocked write to succeed or report ErrOffsetConsumed, got %v", err)
+		}
+
+		w.Close()
+	}
+}
+
+func TestChunkVerifier(t *testing.T) {
+	errCorrupt := errors.New("checksum mismatch")
+	w := NewWriterAtReadCloser(0, WithChunkVerifier(func(off int64, p []byte) error {
+		if bytes.Contains(p, []byte("?")) {
+			return errCorrupt
+		}
+
+		return nil
+	}))
+
+	if _, err := w.WriteAt([]byte("he??o"), 0); err != errCorrupt {
+		t.Errorf("expected verifier error, got %v", err)
+	}
+
+	if w.Buffered() != 0 {
+		t.Errorf("rejected write was buffered")
+	}
+
+	if _, err := w.WriteAt([]byte("hello"), 0); err != nil {
+		t.Errorf("verified write failed with %s", err)
+	}
+}
+
+func TestProgressFunc(t *testing.T) {
+	var reports []int64
+
+	w := NewWriterAtReadCloser(0, WithProgressFunc(func(contiguous int64) {
+		reports = append(reports, contiguous)
+	}))
+
+	w.WriteAt([]byte("world"), 6)
+	w.WriteAt([]byte("hel"), 0)
+	w.Read(make([]byte, 2))
+	w.WriteAt([]byte("lo "), 3)
+
+	if len(reports) != 2 || reports[0] != 3 || reports[1] != 11 {
+		t.Errorf("expected progress reports [3 11], have %v", reports)
+	}
+}
+
+func TestReset(t *testing.T) {
+	w := NewWriterAtReadCloser(0, WithBlockingRead())
+	WriteInChunks(w, []byte("hello world"), 0, 2)
+	w.Read(make([]byte, 4))
+	w.CloseWithError(errors.New("aborted"))
+
+	capBefore := w.Stats().BufferCap
+
+	w.Reset()
+
+	if stats := w.Stats(); stats.BufferCap == 0 || stats.BufferCap < capBefore || stats.BytesRead != 0 {
+		t.Errorf("expected counters cleared and buffer retained, got %+v", stats)
+	}
+
+	expected := "goodbye"
+	WriteInChunks(w, []byte(expected), 0, 3)
+
+	buf := make([]byte, len(expected))
+	if n, err := w.Read(buf); err != nil || string(buf[:n]) != expected {
+		t.Errorf("Read after Reset got (%q, %v)", buf[:n], err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	w := NewWriterAtReadCloser(0)
+	w.WriteAt(make([]byte, 1<<20), 0)
+	w.Read(make([]byte, 1<<20-10))
+	w.Compact()
+
+	if stats := w.Stats(); stats.BufferCap != 10 || stats.Buffered != 10 {
+		t.Errorf("expected Compact to shrink the buffer to its 10 unread bytes, got %+v", stats)
+	}
+
+	w = NewWriterAtReadCloser(0, WithAutoCompact())
+	w.WriteAt(make([]byte, 1<<20), 0)
+	w.Read(make([]byte, 1<<19))
+
+	if stats := w.Stats(); stats.BufferCap != 1<<19 {
+		t.Errorf("expected no compaction while half the buffer is unread, got %+v", stats)
+	}
+
+	w.Read(make([]byte, 1<<19-10))
+
+	if stats := w.Stats(); stats.BufferCap != 10 {
+		t.Errorf("expected auto-compaction, got %+v", stats)
+	}
+}
@@ -0,0 +1,193 @@
+package miscio
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// WriterAtReadSeeker is the non-destructive sibling of WriterAtReadCloser. It
+// accepts out-of-order writes via io.WriterAt, and streams them sequentially via
+// io.Reader, but never discards anything: everything written stays available to
+// ReadAt, and Seek can move the read position back to re-read earlier sections.
+// Memory use therefore grows with the highest offset written.
+//
+// All methods are safe for concurrent use.
+type WriterAtReadSeeker struct {
+	m    sync.Mutex
+	cond *sync.Cond
+
+	buf     []byte
+	avail   IntervalSet
+	written int64
+	pos     int64
+	closed  bool
+	leak    *leakTracker
+}
+
+var (
+	_ io.WriterAt   = (*WriterAtReadSeeker)(nil)
+	_ io.ReaderAt   = (*WriterAtReadSeeker)(nil)
+	_ io.ReadSeeker = (*WriterAtReadSeeker)(nil)
+	_ io.Closer     = (*WriterAtReadSeeker)(nil)
+)
+
+// NewWriterAtReadSeeker returns a new WriterAtReadSeeker whose buffer has room
+// for n bytes preallocated.
+func NewWriterAtReadSeeker(n int) *WriterAtReadSeeker {
+	ws := &WriterAtReadSeeker{buf: make([]byte, 0, n), leak: trackLeak("*miscio.WriterAtReadSeeker")}
+	ws.cond = sync.NewCond(&ws.m)
+
+	return ws
+}
+
+// WriteAt implements io.WriterAt for WriterAtReadSeeker. Overlapping writes
+// are last-writer-wins. WriteAt returns ErrWriteAfterClose after Close.
+func (ws *WriterAtReadSeeker) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	ws.m.Lock()
+	defer ws.m.Unlock()
+
+	if ws.closed {
+		return 0, ErrWriteAfterClose
+	}
+
+	end := off + int64(len(p))
+	if end > int64(len(ws.buf)) {
+		if end > int64(cap(ws.buf)) {
+			grown := make([]byte, end, 2*end)
+			copy(grown, ws.buf)
+			ws.buf = grown
+		} else {
+			ws.buf = ws.buf[:end]
+		}
+	}
+
+	copy(ws.buf[off:], p)
+	ws.avail.Add(off, end)
+
+	if end > ws.written {
+		ws.written = end
+	}
+
+	ws.cond.Broadcast()
+
+	return len(p), nil
+}
+
+// contiguous returns how many bytes starting at off have been written without
+// a gap. It must be called with ws.m held.
+func (ws *WriterAtReadSeeker) contiguous(off int64) int64 {
+	return ws.avail.NextCap(off) - off
+}
+
+// ReadAt implements io.ReaderAt for WriterAtReadSeeker. It does not wait for
+// data: if any of [off, off+len(p)) has not been written, ReadAt copies the
+// written prefix and returns ErrNotWritten, or io.EOF if the WriterAtReadSeeker
+// is closed and the range extends past the end of what was written.
+func (ws *WriterAtReadSeeker) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	ws.m.Lock()
+	defer ws.m.Unlock()
+
+	n := ws.contiguous(off)
+	if n > int64(len(p)) {
+		n = int64(len(p))
+	}
+
+	if n > 0 {
+		copy(p, ws.buf[off:off+n])
+	}
+
+	switch {
+	case n == int64(len(p)):
+		return int(n), nil
+	case ws.closed && off+n >= ws.written:
+		return int(n), io.EOF
+	default:
+		return int(n), ErrNotWritten
+	}
+}
+
+// Read implements io.Reader for WriterAtReadSeeker, reading from the current
+// position. If the byte at the position has not been written yet, Read waits
+// for it; once the WriterAtReadSeeker is closed, Read returns io.EOF at the end
+// of the written data (or at a gap that will now never be filled).
+func (ws *WriterAtReadSeeker) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	ws.m.Lock()
+	defer ws.m.Unlock()
+
+	for {
+		if n := ws.contiguous(ws.pos); n > 0 {
+			if n > int64(len(p)) {
+				n = int64(len(p))
+			}
+
+			copy(p, ws.buf[ws.pos:ws.pos+n])
+			ws.pos += n
+
+			return int(n), nil
+		}
+
+		if ws.closed {
+			return 0, io.EOF
+		}
+
+		ws.cond.Wait()
+	}
+}
+
+// Seek implements io.Seeker for WriterAtReadSeeker. io.SeekEnd is relative to
+// the highest offset written so far. Seeking beyond that is allowed; a Read
+// there waits for the data to be written.
+func (ws *WriterAtReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	ws.m.Lock()
+	defer ws.m.Unlock()
+
+	var abs int64
+
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = ws.pos + offset
+	case io.SeekEnd:
+		abs = ws.written + offset
+	default:
+		return 0, errInvalidWhence
+	}
+
+	if abs < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	ws.pos = abs
+
+	return abs, nil
+}
+
+// Close signals that no more writes are coming. Everything written remains
+// readable; Reads that reach the end of the written data return io.EOF instead
+// of waiting, and WriteAt returns ErrWriteAfterClose.
+func (ws *WriterAtReadSeeker) Close() error {
+	ws.m.Lock()
+	defer ws.m.Unlock()
+
+	ws.closed = true
+	ws.cond.Broadcast()
+	ws.leak.markClosed()
+
+	return nil
+}
+
+var errInvalidWhence = errors.New("miscio: invalid whence")
@@ -0,0 +1,62 @@
+package miscio
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestWriterAtReadSeeker(t *testing.T) {
+	ws := NewWriterAtReadSeeker(0)
+	expected := "hello world"
+
+	go func() {
+		WriteInChunks(ws, []byte(expected), 0, 2)
+		ws.Close()
+	}()
+
+	all, err := ioutil.ReadAll(ws)
+	if err != nil || string(all) != expected {
+		t.Fatalf("sequential read got (%q, %v)", all, err)
+	}
+
+	header := make([]byte, 5)
+	if n, err := ws.ReadAt(header, 0); err != nil || string(header[:n]) != "hello" {
+		t.Errorf("ReadAt after streaming got (%q, %v)", header[:n], err)
+	}
+
+	if _, err := ws.Seek(6, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed with %s", err)
+	}
+
+	rest, _ := ioutil.ReadAll(ws)
+	if string(rest) != "world" {
+		t.Errorf("Read after Seek got %q", rest)
+	}
+
+	if n, err := ws.ReadAt(make([]byte, 4), 9); n != 2 || err != io.EOF {
+		t.Errorf("ReadAt past the end got (%d, %v), want (2, io.EOF)", n, err)
+	}
+
+	if _, err := ws.Seek(-1, io.SeekStart); err != ErrNegativeOffset {
+		t.Errorf("expected ErrNegativeOffset from Seek, got %v", err)
+	}
+
+	if _, err := ws.WriteAt([]byte("!"), 11); err != ErrWriteAfterClose {
+		t.Errorf("expected ErrWriteAfterClose from WriteAt after Close, got %v", err)
+	}
+}
+
+func TestWriterAtReadSeekerGap(t *testing.T) {
+	ws := NewWriterAtReadSeeker(0)
+	ws.WriteAt([]byte("ab"), 0)
+	ws.WriteAt([]byte("ef"), 4)
+
+	if n, err := ws.ReadAt(make([]byte, 4), 100); n != 0 || err != ErrNotWritten {
+		t.Errorf("ReadAt far beyond the written data got (%d, %v), want (0, ErrNotWritten)", n, err)
+	}
+
+	if n, err := ws.ReadAt(make([]byte, 6), 0); n != 2 || err != ErrNotWritten {
+		t.Errorf("ReadAt over a gap got (%d, %v), want (2, ErrNotWritten)", n, err)
+	}
+}